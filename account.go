@@ -3,6 +3,8 @@ package binance
 import (
 	"context"
 	"encoding/json"
+	"fmt"
+	"net/url"
 
 	"github.com/luno/jettison/errors"
 )
@@ -23,9 +25,89 @@ type AccountInfo struct {
 
 // Balance contains a breakdown of a wallet's funds.
 type Balance struct {
-	Asset  string
-	Free   float64
-	Locked float64
+	// Asset represents the asset the balance is held in.
+	Asset string `json:"asset"`
+
+	// Free represents the amount available for trading.
+	Free Decimal `json:"free"`
+
+	// Locked represents the amount reserved for open orders.
+	Locked Decimal `json:"locked"`
+}
+
+// AccountTradesRequest contains the parameters for querying the trades of an
+// account on a market.
+type AccountTradesRequest struct {
+	// EndTime represents the unix timestamp in milliseconds to query until.
+	EndTime int64 `schema:"endTime,omitempty"`
+
+	// FromID represents the trade ID to query from. Otherwise the most recent
+	// trades are returned.
+	FromID int64 `schema:"fromId,omitempty"`
+
+	// Limit represents the maximum amount of trades to query.
+	//
+	// Default: 500.
+	// Max: 1000.
+	Limit int64 `schema:"limit,omitempty"`
+
+	// OrderID restricts the trades to those of a single order. Can only be
+	// sent with Symbol.
+	OrderID int64 `schema:"orderId,omitempty"`
+
+	// StartTime represents the unix timestamp in milliseconds to query from.
+	StartTime int64 `schema:"startTime,omitempty"`
+
+	// Symbol represents the market to query.
+	//
+	// Required.
+	Symbol string `schema:"symbol"`
+}
+
+// AccountTrade contains information about a trade executed on behalf of an
+// account.
+type AccountTrade struct {
+	// Commission represents the fee charged for the trade.
+	Commission Decimal `json:"commission"`
+
+	// CommissionAsset represents the asset the fee was charged in.
+	CommissionAsset string `json:"commissionAsset"`
+
+	// ID represents the unique identifier of the trade.
+	ID int64 `json:"id"`
+
+	// IsBestMatch represents whether the trade was executed at the best
+	// price available.
+	IsBestMatch bool `json:"isBestMatch"`
+
+	// IsBuyer represents whether the account was the buyer in the trade.
+	IsBuyer bool `json:"isBuyer"`
+
+	// IsMaker represents whether the account's order was resting on the book
+	// when the trade was executed.
+	IsMaker bool `json:"isMaker"`
+
+	// OrderID represents the order that the trade filled.
+	OrderID int64 `json:"orderId"`
+
+	// OrderListID will always be -1 if the order was not an OCO order.
+	OrderListID int64 `json:"orderListId"`
+
+	// Price represents the price the trade was executed at.
+	Price Decimal `json:"price"`
+
+	// Qty represents the quantity of the base asset traded.
+	Qty Decimal `json:"qty"`
+
+	// QuoteQty represents the quantity of the quote asset traded.
+	QuoteQty Decimal `json:"quoteQty"`
+
+	// Symbol represents the market the trade was executed on.
+	Symbol string `json:"symbol"`
+
+	// Time represents the unix timestamp in milliseconds at which the trade
+	// was executed.
+	Time int64 `json:"time"`
 }
 
 // AccountInfo returns all information and balances for a user account.
@@ -42,3 +124,25 @@ func (c *client) AccountInfo(ctx context.Context) (*AccountInfo, error) {
 
 	return &info, err
 }
+
+// AccountTrades returns the trades executed on behalf of an account on a
+// market.
+func (c *client) AccountTrades(ctx context.Context, r *AccountTradesRequest) (
+	[]AccountTrade, error) {
+	params := make(url.Values)
+	if err := c.encoder.Encode(r, params); err != nil {
+		return nil, errors.Wrap(err, "failed to encode account trades request")
+	}
+
+	res, err := c.get(ctx, fmt.Sprintf("/myTrades?%s", params.Encode()))
+	if err != nil {
+		return nil, err
+	}
+
+	var trades []AccountTrade
+	if err = json.Unmarshal(res, &trades); err != nil {
+		return nil, errors.Wrap(err, "failed to parse account trades")
+	}
+
+	return trades, nil
+}
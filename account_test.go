@@ -0,0 +1,36 @@
+package binance
+
+import (
+	"context"
+	"net/http"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestAccountTrades_OK(t *testing.T) {
+	srv, err := createTestServer(t, http.StatusOK)
+	require.NoError(t, err)
+	defer srv.Close()
+
+	c := NewClient(WithBaseURL(srv.URL))
+	trades, err := c.AccountTrades(context.Background(), &AccountTradesRequest{
+		Symbol: "BNBBTC",
+	})
+	require.NoError(t, err)
+	require.Equal(t, []AccountTrade{{
+		Commission:      MustParseDecimal("10.10000000"),
+		CommissionAsset: "BNB",
+		ID:              28457,
+		IsBestMatch:     true,
+		IsBuyer:         true,
+		IsMaker:         false,
+		OrderID:         100234,
+		OrderListID:     -1,
+		Price:           MustParseDecimal("4.00000100"),
+		Qty:             MustParseDecimal("12.00000000"),
+		QuoteQty:        MustParseDecimal("48.000012"),
+		Symbol:          "BNBBTC",
+		Time:            1499865549590,
+	}}, trades)
+}
@@ -8,12 +8,35 @@ import (
 // Client provides the methods relating to Binance's REST API.
 type Client interface {
 	AccountInfo(context.Context) (*AccountInfo, error)
+	AccountTrades(context.Context, *AccountTradesRequest) ([]AccountTrade, error)
+	AggregateTrades(context.Context, *AggregateTradesRequest) ([]AggregateTrade, error)
+	AllOCO(context.Context, *AllOCORequest) ([]OrderList, error)
+	AllOrders(context.Context, *AllOrdersRequest) ([]QueryOrderResponse, error)
+	AveragePrice(context.Context, string) (*AveragePrice, error)
+	CancelAllOpenOrders(context.Context, string) ([]CancelOrderResponse, error)
+	CancelOCO(context.Context, *CancelOCORequest) (*OrderList, error)
 	CancelOrder(context.Context, *CancelOrderRequest) (*CancelOrderResponse, error)
+	CloseUserDataStream(context.Context, string) error
+	ExchangeInfo(context.Context) (*ExchangeInfo, error)
+	HistoricalTrades(context.Context, *HistoricalTradesRequest) ([]Trade, error)
+	KeepAliveUserDataStream(context.Context, string) error
 	Klines(context.Context, *KlinesRequest) ([]Kline, error)
+	ListOrderBookTickers(context.Context, ...string) ([]OrderBookTicker, error)
+	ListPriceTickers(context.Context, ...string) ([]PriceTicker, error)
+	ListTickerStats(context.Context, ...string) ([]TickerStats, error)
+	OpenOCO(context.Context) ([]OrderList, error)
+	OpenOrders(context.Context, string) ([]QueryOrderResponse, error)
+	OrderBook(context.Context, *OrderBookRequest) (*OrderBook, error)
 	OrderBookTicker(context.Context, string) (*OrderBookTicker, error)
+	NewOCO(context.Context, *NewOCORequest) (*OrderList, error)
 	NewOrder(context.Context, *NewOrderRequest) (*NewOrderResponse, error)
 	NewOrderTest(context.Context, *NewOrderRequest) error
 	Ping(context.Context) error
+	PriceTicker(context.Context, string) (*PriceTicker, error)
 	ServerTime(context.Context) (time.Time, error)
+	QueryOCO(context.Context, *QueryOCORequest) (*OrderList, error)
 	QueryOrder(context.Context, *QueryOrderRequest) (*QueryOrderResponse, error)
+	RecentTrades(context.Context, *RecentTradesRequest) ([]Trade, error)
+	StartUserDataStream(context.Context) (string, error)
+	TickerStats(context.Context, string) (*TickerStats, error)
 }
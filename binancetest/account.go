@@ -0,0 +1,37 @@
+package binancetest
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+
+	"github.com/nickcorin/binance/internal/engine"
+)
+
+type account struct {
+	*engine.Account
+
+	apiKey    string
+	secretKey string
+	listenKey string
+}
+
+func newAccount(apiKey, secretKey string) *account {
+	return &account{
+		Account:   engine.NewAccount(),
+		apiKey:    apiKey,
+		secretKey: secretKey,
+	}
+}
+
+// verify returns whether `signature` is the HMAC-SHA256 of `payload`.
+func (a *account) verify(payload, signature string) bool {
+	mac := hmac.New(sha256.New, []byte(a.secretKey))
+	mac.Write([]byte(payload))
+
+	sig, err := hex.DecodeString(signature)
+	if err != nil {
+		return false
+	}
+	return hmac.Equal(sig, mac.Sum(nil))
+}
@@ -0,0 +1,11 @@
+// Package binancetest provides an in-process fake of the Binance spot REST
+// API, for testing code which uses a binance.Client.
+//
+// The fake verifies API keys and HMAC signatures, keeps balances and orders
+// for each account, and matches orders in memory by price-time priority.
+// Trades are free of commission, and market data is derived from the trades
+// executed on the fake.
+//
+// A Recorder records a client's interactions with the real exchange to a
+// fixture file, and replays them in later test runs.
+package binancetest
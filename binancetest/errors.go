@@ -0,0 +1,103 @@
+package binancetest
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/url"
+	"strconv"
+
+	"github.com/nickcorin/binance"
+	"github.com/nickcorin/binance/internal/engine"
+)
+
+func newError(code binance.ErrorCode, msg string) binance.Error {
+	return binance.Error{Code: code, Message: msg}
+}
+
+// Errors returned by the exchange.
+var (
+	errInvalidSymbol    = newError(binance.ErrBadSymbol, "Invalid symbol.")
+	errNoSuchOrder      = newError(binance.ErrNoSuchOrder, "Order does not exist.")
+	errUnknownOrder     = newError(binance.ErrCancelRejected, "Unknown order sent.")
+	errInvalidListenKey = newError(binance.ErrInvalidListenKey,
+		"This listenKey does not exist.")
+)
+
+// writeError writes `err` as the response, in the form sent by the
+// exchange.
+func writeError(w http.ResponseWriter, err error) {
+	apiErr, ok := err.(binance.Error)
+	if !ok {
+		apiErr = newError(binance.ErrUnknown, err.Error())
+	}
+
+	status := http.StatusBadRequest
+	switch apiErr.Code {
+	case binance.ErrAPIKeyFormat, binance.ErrRejectedMBXKey:
+		status = http.StatusUnauthorized
+	case binance.ErrUnknown:
+		status = http.StatusInternalServerError
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(apiErr)
+}
+
+// stringParam returns the parameter `name`, which must be set if `required`.
+func stringParam(params url.Values, name string, required bool) (string,
+	error) {
+	v := params.Get(name)
+	if v == "" && required {
+		return "", engine.ErrMandatory(name)
+	}
+	return v, nil
+}
+
+// intParam returns the integer parameter `name`, which is zero if it isn't
+// set and not `required`.
+func intParam(params url.Values, name string, required bool) (int64, error) {
+	v, err := stringParam(params, name, required)
+	if err != nil || v == "" {
+		return 0, err
+	}
+
+	i, err := strconv.ParseInt(v, 10, 64)
+	if err != nil {
+		return 0, engine.ErrIllegal(name)
+	}
+	return i, nil
+}
+
+// decimalParam returns the decimal parameter `name`, which is zero if it
+// isn't set and not `required`.
+func decimalParam(params url.Values, name string, required bool) (
+	binance.Decimal, error) {
+	v, err := stringParam(params, name, required)
+	if err != nil || v == "" {
+		return binance.Decimal{}, err
+	}
+
+	d, err := binance.ParseDecimal(v)
+	if err != nil || d.Sign() < 0 {
+		return binance.Decimal{}, engine.ErrIllegal(name)
+	}
+	return d, nil
+}
+
+// limitParam returns the limit parameter, or `def` if it isn't set. Limits
+// above `max` are reduced to it.
+func limitParam(params url.Values, def, max int64) (int64, error) {
+	limit, err := intParam(params, "limit", false)
+	if err != nil {
+		return 0, err
+	}
+
+	switch {
+	case limit <= 0:
+		return def, nil
+	case limit > max:
+		return max, nil
+	}
+	return limit, nil
+}
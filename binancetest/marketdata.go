@@ -0,0 +1,494 @@
+package binancetest
+
+import (
+	"encoding/json"
+	"net/url"
+	"sort"
+	"time"
+
+	"github.com/nickcorin/binance"
+	"github.com/nickcorin/binance/internal/engine"
+)
+
+// market returns the market of the request's symbol parameter.
+func (s *Server) market(params url.Values) (*engine.Market, error) {
+	symbol, err := stringParam(params, "symbol", true)
+	if err != nil {
+		return nil, err
+	}
+
+	m, ok := s.markets[symbol]
+	if !ok {
+		return nil, errInvalidSymbol
+	}
+	return m, nil
+}
+
+// sortedMarkets returns every market, sorted by symbol.
+func (s *Server) sortedMarkets() []*engine.Market {
+	markets := make([]*engine.Market, 0, len(s.markets))
+	for _, m := range s.markets {
+		markets = append(markets, m)
+	}
+	sort.Slice(markets, func(i, j int) bool {
+		return markets[i].Info.Symbol < markets[j].Info.Symbol
+	})
+	return markets
+}
+
+// tickerMarkets returns the markets that a ticker request is for, and whether
+// the response is a single object rather than a list.
+func (s *Server) tickerMarkets(params url.Values) ([]*engine.Market, bool,
+	error) {
+	if params.Get("symbol") != "" {
+		m, err := s.market(params)
+		if err != nil {
+			return nil, false, err
+		}
+		return []*engine.Market{m}, true, nil
+	}
+
+	encoded := params.Get("symbols")
+	if encoded == "" {
+		return s.sortedMarkets(), false, nil
+	}
+
+	var symbols []string
+	if err := json.Unmarshal([]byte(encoded), &symbols); err != nil {
+		return nil, false, engine.ErrIllegal("symbols")
+	}
+
+	markets := make([]*engine.Market, 0, len(symbols))
+	for _, symbol := range symbols {
+		m, ok := s.markets[symbol]
+		if !ok {
+			return nil, false, errInvalidSymbol
+		}
+		markets = append(markets, m)
+	}
+	return markets, false, nil
+}
+
+// tickers calls `fn` for each market of a ticker request, and returns the
+// result in the form the request expects.
+func (s *Server) tickers(params url.Values,
+	fn func(*engine.Market) interface{}) (interface{}, error) {
+	markets, single, err := s.tickerMarkets(params)
+	if err != nil {
+		return nil, err
+	}
+
+	if single {
+		return fn(markets[0]), nil
+	}
+
+	res := make([]interface{}, 0, len(markets))
+	for _, m := range markets {
+		res = append(res, fn(m))
+	}
+	return res, nil
+}
+
+func (s *Server) ping(*request) (interface{}, error) {
+	return struct{}{}, nil
+}
+
+func (s *Server) serverTime(*request) (interface{}, error) {
+	return struct {
+		ServerTime int64 `json:"serverTime"`
+	}{s.timestamp()}, nil
+}
+
+func (s *Server) exchangeInfo(*request) (interface{}, error) {
+	info := binance.ExchangeInfo{
+		RateLimits: binance.DefaultRateLimits,
+		ServerTime: s.timestamp(),
+		Timezone:   "UTC",
+	}
+	for _, m := range s.sortedMarkets() {
+		info.Symbols = append(info.Symbols, m.Info)
+	}
+	return info, nil
+}
+
+// levels aggregates the visible quantity of `orders` into price levels.
+func levels(orders []*engine.Order, limit int64) []binance.PriceLevel {
+	res := make([]binance.PriceLevel, 0)
+	for _, o := range orders {
+		qty := o.Remaining()
+		if o.IcebergQty.LessThan(qty) && !o.IcebergQty.IsZero() {
+			qty = o.IcebergQty
+		}
+
+		if n := len(res); n > 0 && res[n-1].Price.Equal(o.Price) {
+			res[n-1].Qty = res[n-1].Qty.Add(qty)
+			continue
+		}
+
+		if int64(len(res)) == limit {
+			break
+		}
+		res = append(res, binance.PriceLevel{Price: o.Price, Qty: qty})
+	}
+	return res
+}
+
+func (s *Server) depth(r *request) (interface{}, error) {
+	m, err := s.market(r.params)
+	if err != nil {
+		return nil, err
+	}
+
+	limit, err := limitParam(r.params, 100, 5000)
+	if err != nil {
+		return nil, err
+	}
+
+	return binance.OrderBook{
+		Asks:         levels(m.Asks, limit),
+		Bids:         levels(m.Bids, limit),
+		LastUpdateID: m.UpdateID,
+	}, nil
+}
+
+func (s *Server) recentTrades(r *request) (interface{}, error) {
+	m, err := s.market(r.params)
+	if err != nil {
+		return nil, err
+	}
+
+	limit, err := limitParam(r.params, 500, 1000)
+	if err != nil {
+		return nil, err
+	}
+
+	trades := m.Trades
+	if int64(len(trades)) > limit {
+		trades = trades[int64(len(trades))-limit:]
+	}
+	return append([]binance.Trade{}, trades...), nil
+}
+
+func (s *Server) historicalTrades(r *request) (interface{}, error) {
+	m, err := s.market(r.params)
+	if err != nil {
+		return nil, err
+	}
+
+	fromID, err := intParam(r.params, "fromId", false)
+	if err != nil {
+		return nil, err
+	}
+
+	if fromID == 0 {
+		return s.recentTrades(r)
+	}
+
+	limit, err := limitParam(r.params, 500, 1000)
+	if err != nil {
+		return nil, err
+	}
+
+	res := make([]binance.Trade, 0)
+	for _, t := range m.Trades {
+		if t.ID >= fromID && int64(len(res)) < limit {
+			res = append(res, t)
+		}
+	}
+	return res, nil
+}
+
+// timeRange returns the startTime and endTime parameters. The end time is
+// the maximum int64 if it isn't set.
+func timeRange(params url.Values) (int64, int64, error) {
+	start, err := intParam(params, "startTime", false)
+	if err != nil {
+		return 0, 0, err
+	}
+
+	end, err := intParam(params, "endTime", false)
+	if err != nil {
+		return 0, 0, err
+	}
+
+	if end == 0 {
+		end = 1<<63 - 1
+	}
+	return start, end, nil
+}
+
+// aggTrades returns one aggregate trade per trade, since every trade is
+// filled by a different order.
+func (s *Server) aggTrades(r *request) (interface{}, error) {
+	m, err := s.market(r.params)
+	if err != nil {
+		return nil, err
+	}
+
+	fromID, err := intParam(r.params, "fromId", false)
+	if err != nil {
+		return nil, err
+	}
+
+	start, end, err := timeRange(r.params)
+	if err != nil {
+		return nil, err
+	}
+
+	limit, err := limitParam(r.params, 500, 1000)
+	if err != nil {
+		return nil, err
+	}
+
+	res := make([]binance.AggregateTrade, 0)
+	for _, t := range m.Trades {
+		if t.ID < fromID || t.Time < start || t.Time > end {
+			continue
+		}
+
+		res = append(res, binance.AggregateTrade{
+			FirstTradeID: t.ID,
+			ID:           t.ID,
+			IsBestMatch:  t.IsBestMatch,
+			IsBuyerMaker: t.IsBuyerMaker,
+			LastTradeID:  t.ID,
+			Price:        t.Price,
+			Qty:          t.Qty,
+			Time:         t.Time,
+		})
+	}
+
+	// Without a starting point the most recent trades are returned.
+	if fromID == 0 && int64(len(res)) > limit {
+		res = res[int64(len(res))-limit:]
+	}
+	if int64(len(res)) > limit {
+		res = res[:limit]
+	}
+	return res, nil
+}
+
+var intervals = map[binance.KlineInterval]time.Duration{
+	binance.OneMinute:      time.Minute,
+	binance.ThreeMinutes:   3 * time.Minute,
+	binance.FiveMinutes:    5 * time.Minute,
+	binance.FifteenMinutes: 15 * time.Minute,
+	binance.ThirtyMinutes:  30 * time.Minute,
+	binance.OneHour:        time.Hour,
+	binance.TwoHours:       2 * time.Hour,
+	binance.FourHours:      4 * time.Hour,
+	binance.SixHours:       6 * time.Hour,
+	binance.EightHours:     8 * time.Hour,
+	binance.TwelveHours:    12 * time.Hour,
+	binance.OneDay:         24 * time.Hour,
+	binance.ThreeDays:      3 * 24 * time.Hour,
+	binance.OneWeek:        7 * 24 * time.Hour,
+}
+
+// bucket returns the open and close time of the kline that the trade at `ms`
+// belongs to.
+func bucket(interval binance.KlineInterval, ms int64) (int64, int64) {
+	if interval == binance.OneMonth {
+		t := time.Unix(0, ms*1e6).UTC()
+		open := time.Date(t.Year(), t.Month(), 1, 0, 0, 0, 0, time.UTC)
+		return open.UnixNano() / 1e6, open.AddDate(0, 1, 0).UnixNano()/1e6 - 1
+	}
+
+	d := intervals[interval].Milliseconds()
+	open := ms - ms%d
+
+	// Weeks open on Mondays, and the unix epoch was on a Thursday.
+	if interval == binance.OneWeek {
+		monday := 3 * 24 * time.Hour.Milliseconds()
+		open = ms - (ms+monday)%d
+	}
+	return open, open + d - 1
+}
+
+// klines returns klines for the intervals in which there were trades.
+func (s *Server) klines(r *request) (interface{}, error) {
+	m, err := s.market(r.params)
+	if err != nil {
+		return nil, err
+	}
+
+	v, err := stringParam(r.params, "interval", true)
+	if err != nil {
+		return nil, err
+	}
+
+	interval := binance.KlineInterval(v)
+	if _, ok := intervals[interval]; !ok && interval != binance.OneMonth {
+		return nil, newError(binance.ErrBadInterval, "Invalid interval.")
+	}
+
+	start, end, err := timeRange(r.params)
+	if err != nil {
+		return nil, err
+	}
+
+	limit, err := limitParam(r.params, 500, 1000)
+	if err != nil {
+		return nil, err
+	}
+
+	var klines []binance.Kline
+	for _, t := range m.Trades {
+		open, close := bucket(interval, t.Time)
+		if open < start || open > end {
+			continue
+		}
+
+		n := len(klines)
+		if n == 0 || klines[n-1].OpenTime != open {
+			klines = append(klines, binance.Kline{
+				CloseTime: close,
+				High:      t.Price,
+				Low:       t.Price,
+				Open:      t.Price,
+				OpenTime:  open,
+			})
+			n++
+		}
+
+		k := &klines[n-1]
+		k.Close = t.Price
+		if t.Price.GreaterThan(k.High) {
+			k.High = t.Price
+		}
+		if t.Price.LessThan(k.Low) {
+			k.Low = t.Price
+		}
+		k.QuoteAssetVolume = k.QuoteAssetVolume.Add(t.QuoteQty)
+		k.TradeCount++
+		k.Volume = k.Volume.Add(t.Qty)
+	}
+
+	if start == 0 && int64(len(klines)) > limit {
+		klines = klines[int64(len(klines))-limit:]
+	}
+	if int64(len(klines)) > limit {
+		klines = klines[:limit]
+	}
+
+	// Klines are encoded as arrays, with the taker volumes and an unused
+	// field at the end.
+	res := make([][]interface{}, 0, len(klines))
+	for _, k := range klines {
+		res = append(res, []interface{}{k.OpenTime, k.Open, k.High, k.Low,
+			k.Close, k.Volume, k.CloseTime, k.QuoteAssetVolume, k.TradeCount,
+			binance.Decimal{}, binance.Decimal{}, "0"})
+	}
+	return res, nil
+}
+
+// averagePrice returns the volume weighted average price of the trades in
+// the last `window`, or the last price if there weren't any.
+func (s *Server) averagePrice(m *engine.Market,
+	window time.Duration) binance.Decimal {
+	since := s.timestamp() - window.Milliseconds()
+
+	var qty, quote binance.Decimal
+	for _, t := range m.Trades {
+		if t.Time >= since {
+			qty = qty.Add(t.Qty)
+			quote = quote.Add(t.QuoteQty)
+		}
+	}
+
+	if qty.IsZero() {
+		return m.LastPrice()
+	}
+	return quote.Div(qty, int32(m.Info.QuotePrecision))
+}
+
+func (s *Server) avgPrice(r *request) (interface{}, error) {
+	m, err := s.market(r.params)
+	if err != nil {
+		return nil, err
+	}
+
+	return binance.AveragePrice{
+		Mins:  5,
+		Price: s.averagePrice(m, 5*time.Minute),
+	}, nil
+}
+
+// bookTop returns the best price and the quantity at it on the side of the
+// book which `orders` are from.
+func bookTop(orders []*engine.Order) (binance.Decimal, binance.Decimal) {
+	top := levels(orders, 1)
+	if len(top) == 0 {
+		return binance.Decimal{}, binance.Decimal{}
+	}
+	return top[0].Price, top[0].Qty
+}
+
+func (s *Server) bookTicker(r *request) (interface{}, error) {
+	return s.tickers(r.params, func(m *engine.Market) interface{} {
+		t := binance.OrderBookTicker{Symbol: m.Info.Symbol}
+		t.AskPrice, t.AskQty = bookTop(m.Asks)
+		t.BidPrice, t.BidQty = bookTop(m.Bids)
+		return t
+	})
+}
+
+func (s *Server) priceTicker(r *request) (interface{}, error) {
+	return s.tickers(r.params, func(m *engine.Market) interface{} {
+		return binance.PriceTicker{Price: m.LastPrice(), Symbol: m.Info.Symbol}
+	})
+}
+
+// tickerStats returns statistics of the trades in the last 24 hours.
+func (s *Server) tickerStats(r *request) (interface{}, error) {
+	return s.tickers(r.params, func(m *engine.Market) interface{} {
+		closeTime := s.timestamp()
+		openTime := closeTime - (24 * time.Hour).Milliseconds()
+
+		t := binance.TickerStats{
+			CloseTime: closeTime,
+			FirstID:   -1,
+			LastID:    -1,
+			LastPrice: m.LastPrice(),
+			OpenTime:  openTime,
+			Symbol:    m.Info.Symbol,
+		}
+		t.AskPrice, t.AskQty = bookTop(m.Asks)
+		t.BidPrice, t.BidQty = bookTop(m.Bids)
+
+		for _, trade := range m.Trades {
+			if trade.Time < openTime {
+				t.PrevClosePrice = trade.Price
+				continue
+			}
+
+			if t.Count == 0 {
+				t.FirstID = trade.ID
+				t.HighPrice = trade.Price
+				t.LowPrice = trade.Price
+				t.OpenPrice = trade.Price
+			}
+			if trade.Price.GreaterThan(t.HighPrice) {
+				t.HighPrice = trade.Price
+			}
+			if trade.Price.LessThan(t.LowPrice) {
+				t.LowPrice = trade.Price
+			}
+
+			t.Count++
+			t.LastID = trade.ID
+			t.LastQty = trade.Qty
+			t.QuoteVolume = t.QuoteVolume.Add(trade.QuoteQty)
+			t.Volume = t.Volume.Add(trade.Qty)
+		}
+
+		if t.Count > 0 {
+			scale := int32(m.Info.QuotePrecision)
+			t.PriceChange = t.LastPrice.Sub(t.OpenPrice)
+			t.PriceChangePercent = t.PriceChange.Mul(
+				binance.NewDecimalFromInt(100)).Div(t.OpenPrice, 3)
+			t.WeightedAvgPrice = t.QuoteVolume.Div(t.Volume, scale)
+		}
+		return t
+	})
+}
@@ -0,0 +1,179 @@
+package binancetest
+
+import (
+	"fmt"
+	"net/url"
+	"time"
+
+	"github.com/nickcorin/binance"
+	"github.com/nickcorin/binance/internal/engine"
+)
+
+// parseOCO returns the order list described by the parameters of a new OCO
+// request.
+func parseOCO(params url.Values) (*binance.NewOCORequest, error) {
+	symbol, err := stringParam(params, "symbol", true)
+	if err != nil {
+		return nil, err
+	}
+
+	side, err := stringParam(params, "side", true)
+	if err != nil {
+		return nil, err
+	}
+
+	decimals := make(map[string]binance.Decimal)
+	for _, name := range []string{"limitIcebergQty", "price", "quantity",
+		"stopIcebergQty", "stopLimitPrice", "stopPrice"} {
+		required := name == "price" || name == "quantity" ||
+			name == "stopPrice"
+
+		decimals[name], err = decimalParam(params, name, required)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	return &binance.NewOCORequest{
+		LimitClientOrderID: params.Get("limitClientOrderId"),
+		LimitIcebergQty:    decimals["limitIcebergQty"],
+		ListClientOrderID:  params.Get("listClientOrderId"),
+		Price:              decimals["price"],
+		Qty:                decimals["quantity"],
+		Side:               binance.OrderSide(side),
+		StopClientOrderID:  params.Get("stopClientOrderId"),
+		StopIcebergQty:     decimals["stopIcebergQty"],
+		StopLimitPrice:     decimals["stopLimitPrice"],
+		StopLimitTimeInForce: binance.TimeInForce(
+			params.Get("stopLimitTimeInForce")),
+		StopPrice: decimals["stopPrice"],
+		Symbol:    symbol,
+	}, nil
+}
+
+func (s *Server) newOCO(r *request) (interface{}, error) {
+	m, err := s.market(r.params)
+	if err != nil {
+		return nil, err
+	}
+
+	req, err := parseOCO(r.params)
+	if err != nil {
+		return nil, err
+	}
+
+	limitReq, stopReq, err := engine.OCOOrders(req)
+	if err != nil {
+		return nil, err
+	}
+
+	err = engine.CheckOCO(m, r.account.Account, limitReq, stopReq,
+		s.averagePrice(m, 5*time.Minute))
+	if err != nil {
+		return nil, err
+	}
+
+	limit := engine.NewOrder(r.account.Account, limitReq)
+	stop := engine.NewOrder(r.account.Account, stopReq)
+	l, err := s.engine.PlaceOCO(m, r.account.Account, req.ListClientOrderID,
+		limit, stop, m.Makers(limit.Side), m.LastPrice())
+	if err != nil {
+		return nil, err
+	}
+
+	return l.Response(true), nil
+}
+
+// lookupOrderList returns the order list of `acc` identified by the
+// orderListId parameter, or by the client ID in the parameter `clientParam`.
+// It returns nil if there isn't one.
+func (s *Server) lookupOrderList(acc *account, params url.Values,
+	clientParam string) (*engine.List, error) {
+	id, err := intParam(params, "orderListId", false)
+	if err != nil {
+		return nil, err
+	}
+
+	clientID := params.Get(clientParam)
+	if id == 0 && clientID == "" {
+		return nil, newError(binance.ErrMandatoryParamEmptyOrMalformed,
+			fmt.Sprintf("Param '%s' or 'orderListId' must be sent, but both "+
+				"were empty/null!", clientParam))
+	}
+
+	return s.engine.LookupList(acc.Account, id, clientID), nil
+}
+
+func (s *Server) queryOrderList(r *request) (interface{}, error) {
+	l, err := s.lookupOrderList(r.account, r.params, "origClientOrderId")
+	if err != nil {
+		return nil, err
+	} else if l == nil {
+		return nil, newError(binance.ErrNoSuchOrder,
+			"Order list does not exist.")
+	}
+
+	return l.Response(false), nil
+}
+
+func (s *Server) cancelOrderList(r *request) (interface{}, error) {
+	m, err := s.market(r.params)
+	if err != nil {
+		return nil, err
+	}
+
+	l, err := s.lookupOrderList(r.account, r.params, "listClientOrderId")
+	if err != nil {
+		return nil, err
+	} else if l == nil || l.Symbol != m.Info.Symbol || !l.IsOpen() {
+		return nil, newError(binance.ErrCancelRejected,
+			"Unknown order list sent.")
+	}
+
+	s.engine.Cancel(m, l.Orders[0])
+	return l.Response(true), nil
+}
+
+func (s *Server) allOrderLists(r *request) (interface{}, error) {
+	fromID, err := intParam(r.params, "fromId", false)
+	if err != nil {
+		return nil, err
+	}
+
+	start, end, err := timeRange(r.params)
+	if err != nil {
+		return nil, err
+	}
+
+	limit, err := limitParam(r.params, 500, 1000)
+	if err != nil {
+		return nil, err
+	}
+
+	res := make([]binance.OrderList, 0)
+	for _, l := range s.engine.AccountLists(r.account.Account) {
+		if l.ID < fromID || l.Time < start || l.Time > end {
+			continue
+		}
+		res = append(res, l.Response(false))
+	}
+
+	// Without a starting point the most recent lists are returned.
+	if fromID == 0 && start == 0 && int64(len(res)) > limit {
+		res = res[int64(len(res))-limit:]
+	}
+	if int64(len(res)) > limit {
+		res = res[:limit]
+	}
+	return res, nil
+}
+
+func (s *Server) openOrderLists(r *request) (interface{}, error) {
+	res := make([]binance.OrderList, 0)
+	for _, l := range s.engine.AccountLists(r.account.Account) {
+		if l.IsOpen() {
+			res = append(res, l.Response(false))
+		}
+	}
+	return res, nil
+}
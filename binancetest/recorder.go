@@ -0,0 +1,361 @@
+package binancetest
+
+import (
+	"bytes"
+	"encoding/json"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+
+	"github.com/luno/jettison/errors"
+	"github.com/luno/jettison/j"
+)
+
+// ErrNoInteraction is returned by a replaying Recorder when a request doesn't
+// match any of the recorded interactions which haven't been replayed yet.
+var ErrNoInteraction = errors.New("no recorded interaction for request",
+	j.C("ERR_NO_INTERACTION"))
+
+// Mode selects whether a Recorder records or replays interactions.
+type Mode int
+
+const (
+	// ModeReplay serves the interactions recorded in the fixture file,
+	// without sending any requests.
+	ModeReplay Mode = 0
+
+	// ModeRecord sends requests to the exchange, and writes each request and
+	// response to the fixture file.
+	ModeRecord Mode = 1
+)
+
+// scrubbedParams are removed from recorded requests, since they are either
+// secret or change on every request.
+var scrubbedParams = []string{"signature", "timestamp"}
+
+// recordedHeaders are the response headers which are recorded. Other
+// headers, such as cookies, are discarded.
+var recordedHeaders = []string{"Content-Type", "Retry-After"}
+
+// Interaction is a request and the response it received, as written to a
+// fixture file.
+type Interaction struct {
+	Request  RecordedRequest  `json:"request"`
+	Response RecordedResponse `json:"response"`
+}
+
+// RecordedRequest contains the parts of a request which are matched against
+// on replay.
+type RecordedRequest struct {
+	Method string `json:"method"`
+
+	// Params contains the parameters sent in both the query string and the
+	// body, without the scrubbed parameters.
+	Params url.Values `json:"params,omitempty"`
+
+	Path string `json:"path"`
+}
+
+// RecordedResponse contains a recorded response.
+type RecordedResponse struct {
+	// Body contains the body if it is valid JSON, which is every response
+	// from the API. Otherwise BodyText is used.
+	Body     json.RawMessage `json:"body,omitempty"`
+	BodyText string          `json:"bodyText,omitempty"`
+
+	// Header contains the rate limit headers, Content-Type and Retry-After.
+	Header http.Header `json:"header,omitempty"`
+
+	StatusCode int `json:"statusCode"`
+}
+
+// Recorder is an http.RoundTripper which records interactions with the
+// exchange to a fixture file, or replays them from one. Pass it to
+// binance.WithTransport using HTTPClient. It is safe for concurrent use.
+//
+// Requests are matched on their method, path and parameters. The API key,
+// signature and timestamp are never recorded. NewOrder generates a
+// newClientOrderId for requests that don't have one, so tests should either
+// set it or ignore it using WithIgnoredParams.
+type Recorder struct {
+	ignored   map[string]bool
+	mode      Mode
+	path      string
+	transport http.RoundTripper
+
+	mu           sync.Mutex
+	interactions []Interaction
+	replayed     []bool
+}
+
+// RecorderOption is a func-to-Recorder adapter.
+type RecorderOption func(*Recorder)
+
+// WithIgnoredParams returns a RecorderOption to ignore the parameters
+// `names` when matching requests on replay. They are still recorded.
+func WithIgnoredParams(names ...string) RecorderOption {
+	return func(r *Recorder) {
+		for _, name := range names {
+			r.ignored[name] = true
+		}
+	}
+}
+
+// WithRecordTransport returns a RecorderOption to set the transport which
+// requests are sent with in ModeRecord. Defaults to http.DefaultTransport.
+func WithRecordTransport(transport http.RoundTripper) RecorderOption {
+	return func(r *Recorder) {
+		r.transport = transport
+	}
+}
+
+// NewRecorder returns a Recorder for the fixture file at `path`. In
+// ModeReplay the file is read immediately, and in ModeRecord it is replaced
+// once the first interaction is recorded.
+func NewRecorder(path string, mode Mode, opts ...RecorderOption) (*Recorder,
+	error) {
+	r := Recorder{
+		ignored:   make(map[string]bool),
+		mode:      mode,
+		path:      path,
+		transport: http.DefaultTransport,
+	}
+
+	for _, o := range opts {
+		o(&r)
+	}
+
+	if mode != ModeReplay {
+		return &r, nil
+	}
+
+	b, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to read fixture",
+			j.KV("path", path))
+	}
+
+	if err := json.Unmarshal(b, &r.interactions); err != nil {
+		return nil, errors.Wrap(err, "failed to parse fixture",
+			j.KV("path", path))
+	}
+	r.replayed = make([]bool, len(r.interactions))
+
+	return &r, nil
+}
+
+// HTTPClient returns an http.Client which sends requests through the
+// Recorder.
+func (r *Recorder) HTTPClient() *http.Client {
+	return &http.Client{Transport: r}
+}
+
+// Interactions returns the interactions which have been recorded, or which
+// are available to replay.
+func (r *Recorder) Interactions() []Interaction {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return append([]Interaction(nil), r.interactions...)
+}
+
+// RoundTrip satisfies the http.RoundTripper interface.
+func (r *Recorder) RoundTrip(req *http.Request) (*http.Response, error) {
+	var body []byte
+	if req.Body != nil {
+		var err error
+		body, err = ioutil.ReadAll(req.Body)
+		req.Body.Close()
+		if err != nil {
+			return nil, errors.Wrap(err, "failed to read request body")
+		}
+
+		// RoundTrippers mustn't modify the request they are given.
+		req = req.Clone(req.Context())
+		req.Body = ioutil.NopCloser(bytes.NewReader(body))
+	}
+
+	recorded, err := recordRequest(req, body)
+	if err != nil {
+		return nil, err
+	}
+
+	if r.mode == ModeReplay {
+		return r.replay(req, recorded)
+	}
+	return r.record(req, recorded)
+}
+
+// recordRequest returns the scrubbed form of `req`, whose body is `body`.
+func recordRequest(req *http.Request, body []byte) (RecordedRequest,
+	error) {
+	params, err := url.ParseQuery(req.URL.RawQuery)
+	if err != nil {
+		return RecordedRequest{}, errors.Wrap(err,
+			"failed to parse request query")
+	}
+
+	bodyParams, err := url.ParseQuery(string(body))
+	if err != nil {
+		return RecordedRequest{}, errors.Wrap(err,
+			"failed to parse request body")
+	}
+
+	for k, v := range bodyParams {
+		params[k] = append(params[k], v...)
+	}
+
+	for _, name := range scrubbedParams {
+		params.Del(name)
+	}
+
+	if len(params) == 0 {
+		params = nil
+	}
+
+	return RecordedRequest{
+		Method: req.Method,
+		Params: params,
+		Path:   req.URL.Path,
+	}, nil
+}
+
+func (r *Recorder) record(req *http.Request,
+	recorded RecordedRequest) (*http.Response, error) {
+	res, err := r.transport.RoundTrip(req)
+	if err != nil {
+		return nil, err
+	}
+
+	b, err := ioutil.ReadAll(res.Body)
+	res.Body.Close()
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to read response body")
+	}
+	res.Body = ioutil.NopCloser(bytes.NewReader(b))
+
+	interaction := Interaction{
+		Request: recorded,
+		Response: RecordedResponse{
+			Header:     make(http.Header),
+			StatusCode: res.StatusCode,
+		},
+	}
+
+	if json.Valid(b) {
+		interaction.Response.Body = b
+	} else {
+		interaction.Response.BodyText = string(b)
+	}
+
+	for key, values := range res.Header {
+		if isRecordedHeader(key) {
+			interaction.Response.Header[key] = values
+		}
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.interactions = append(r.interactions, interaction)
+	if err := r.save(); err != nil {
+		return nil, err
+	}
+
+	return res, nil
+}
+
+func isRecordedHeader(key string) bool {
+	key = http.CanonicalHeaderKey(key)
+	if strings.HasPrefix(key, "X-Mbx-") {
+		return true
+	}
+	for _, h := range recordedHeaders {
+		if key == h {
+			return true
+		}
+	}
+	return false
+}
+
+// save writes the interactions to the fixture file. It must be called with
+// the lock held.
+func (r *Recorder) save() error {
+	b, err := json.MarshalIndent(r.interactions, "", "  ")
+	if err != nil {
+		return errors.Wrap(err, "failed to encode fixture")
+	}
+
+	if err := ioutil.WriteFile(r.path, append(b, '\n'), 0644); err != nil {
+		return errors.Wrap(err, "failed to write fixture",
+			j.KV("path", r.path))
+	}
+	return nil
+}
+
+// replay returns the response to the first interaction matching `recorded`
+// which hasn't been replayed yet.
+func (r *Recorder) replay(req *http.Request,
+	recorded RecordedRequest) (*http.Response, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	for i, interaction := range r.interactions {
+		if r.replayed[i] || !r.matches(interaction.Request, recorded) {
+			continue
+		}
+		r.replayed[i] = true
+
+		body := []byte(interaction.Response.Body)
+		if len(body) == 0 {
+			body = []byte(interaction.Response.BodyText)
+		}
+
+		header := interaction.Response.Header
+		if header == nil {
+			header = make(http.Header)
+		}
+
+		return &http.Response{
+			Body:          ioutil.NopCloser(bytes.NewReader(body)),
+			ContentLength: int64(len(body)),
+			Header:        header.Clone(),
+			Proto:         "HTTP/1.1",
+			ProtoMajor:    1,
+			ProtoMinor:    1,
+			Request:       req,
+			Status: http.StatusText(
+				interaction.Response.StatusCode),
+			StatusCode: interaction.Response.StatusCode,
+		}, nil
+	}
+
+	return nil, errors.Wrap(ErrNoInteraction, "failed to replay request",
+		j.MKV{
+			"method": recorded.Method,
+			"params": recorded.Params.Encode(),
+			"path":   recorded.Path,
+		})
+}
+
+// matches returns whether two requests are the same, ignoring the order of
+// their parameters and the parameters ignored by the Recorder.
+func (r *Recorder) matches(a, b RecordedRequest) bool {
+	if a.Method != b.Method || a.Path != b.Path {
+		return false
+	}
+	return r.normalize(a.Params) == r.normalize(b.Params)
+}
+
+// normalize returns `params` encoded in sorted order, without the ignored
+// parameters.
+func (r *Recorder) normalize(params url.Values) string {
+	normalized := make(url.Values)
+	for k, v := range params {
+		if !r.ignored[k] {
+			normalized[k] = v
+		}
+	}
+	return normalized.Encode()
+}
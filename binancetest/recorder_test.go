@@ -0,0 +1,132 @@
+package binancetest_test
+
+import (
+	"context"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/luno/jettison/errors"
+	"github.com/nickcorin/binance"
+	"github.com/nickcorin/binance/binancetest"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRecorder(t *testing.T) {
+	ctx := context.Background()
+
+	dir, err := ioutil.TempDir("", "recorder")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+	path := filepath.Join(dir, "fixture.json")
+
+	srv := newServer()
+	defer srv.Close()
+
+	rec, err := binancetest.NewRecorder(path, binancetest.ModeRecord)
+	require.NoError(t, err)
+
+	req := &binance.NewOrderRequest{
+		NewClientOrderID: "my-order",
+		Price:            dec("100"),
+		Qty:              dec("1"),
+		Side:             binance.Sell,
+		Symbol:           "BTCUSDT",
+		TimeInForce:      binance.GoodUntilCancelled,
+		Type:             binance.OrderTypeLimit,
+	}
+	query := &binance.QueryOrderRequest{
+		OrigClientOrderID: "my-order",
+		Symbol:            "BTCUSDT",
+	}
+
+	client := srv.Client("alice", binance.WithTransport(rec.HTTPClient()))
+	placed, err := client.NewOrder(ctx, req)
+	require.NoError(t, err)
+	_, err = client.QueryOrder(ctx, &binance.QueryOrderRequest{
+		OrigClientOrderID: "missing",
+		Symbol:            "BTCUSDT",
+	})
+	require.True(t, binance.IsError(err, binance.ErrNoSuchOrder))
+
+	// Nothing which identifies the account is written to the fixture.
+	b, err := ioutil.ReadFile(path)
+	require.NoError(t, err)
+	require.NotContains(t, string(b), "alice")
+	require.NotContains(t, string(b), "signature")
+	require.NotContains(t, string(b), "timestamp")
+
+	interactions := rec.Interactions()
+	require.Len(t, interactions, 2)
+	require.Equal(t, "/api/v3/order", interactions[0].Request.Path)
+	require.Equal(t, "my-order",
+		interactions[0].Request.Params.Get("newClientOrderId"))
+
+	replay, err := binancetest.NewRecorder(path, binancetest.ModeReplay)
+	require.NoError(t, err)
+
+	// The replaying client never reaches the exchange.
+	client = binance.NewClient(
+		binance.WithAPIKey("other"),
+		binance.WithBaseURL("http://127.0.0.1:0/api/v3"),
+		binance.WithSecretKey("other-secret"),
+		binance.WithTransport(replay.HTTPClient()),
+	)
+
+	replayed, err := client.NewOrder(ctx, req)
+	require.NoError(t, err)
+	require.Equal(t, placed, replayed)
+
+	_, err = client.QueryOrder(ctx, &binance.QueryOrderRequest{
+		OrigClientOrderID: "missing",
+		Symbol:            "BTCUSDT",
+	})
+	require.True(t, binance.IsError(err, binance.ErrNoSuchOrder))
+
+	// Each interaction is only replayed once, and unrecorded requests fail.
+	_, err = client.QueryOrder(ctx, &binance.QueryOrderRequest{
+		OrigClientOrderID: "missing",
+		Symbol:            "BTCUSDT",
+	})
+	require.True(t, errors.Is(err, binancetest.ErrNoInteraction))
+
+	_, err = client.QueryOrder(ctx, query)
+	require.True(t, errors.Is(err, binancetest.ErrNoInteraction))
+}
+
+func TestRecorder_IgnoredParams(t *testing.T) {
+	ctx := context.Background()
+
+	dir, err := ioutil.TempDir("", "recorder")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+	path := filepath.Join(dir, "fixture.json")
+
+	srv := newServer()
+	defer srv.Close()
+
+	rec, err := binancetest.NewRecorder(path, binancetest.ModeRecord)
+	require.NoError(t, err)
+
+	req := &binance.NewOrderRequest{
+		Qty:    dec("1"),
+		Side:   binance.Buy,
+		Symbol: "BTCUSDT",
+		Type:   binance.OrderTypeMarket,
+	}
+
+	client := srv.Client("alice", binance.WithTransport(rec.HTTPClient()))
+	_, err = client.NewOrder(ctx, req)
+	require.NoError(t, err)
+
+	replay, err := binancetest.NewRecorder(path, binancetest.ModeReplay,
+		binancetest.WithIgnoredParams("newClientOrderId"))
+	require.NoError(t, err)
+
+	client = srv.Client("alice",
+		binance.WithTransport(replay.HTTPClient()))
+	res, err := client.NewOrder(ctx, req)
+	require.NoError(t, err)
+	require.Equal(t, binance.OrderStatusExpired, res.Status)
+}
@@ -0,0 +1,400 @@
+package binancetest
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/nickcorin/binance"
+	"github.com/nickcorin/binance/internal/engine"
+)
+
+// Server is a fake Binance exchange, served over HTTP. It is safe for
+// concurrent use.
+type Server struct {
+	*httptest.Server
+
+	now    func() time.Time
+	router map[string]map[string]route
+
+	// mu guards all of the exchange's state.
+	mu         sync.Mutex
+	accounts   map[string]*account
+	engine     *engine.Engine
+	listenKeys map[string]*account
+	markets    map[string]*engine.Market
+}
+
+// Option is a func-to-Server adapter.
+type Option func(*Server)
+
+// WithClock returns an Option to set the func used to read the exchange's
+// time, which stamps orders and trades and is checked against the timestamps
+// of signed requests. Defaults to time.Now.
+func WithClock(now func() time.Time) Option {
+	return func(s *Server) {
+		s.now = now
+	}
+}
+
+// WithSymbols returns an Option to list `symbols` on the exchange. See
+// NewSymbol.
+func WithSymbols(symbols ...binance.SymbolInfo) Option {
+	return func(s *Server) {
+		for _, info := range symbols {
+			s.addSymbol(info)
+		}
+	}
+}
+
+// NewServer starts and returns a Server, which should be closed once it is
+// no longer needed.
+func NewServer(opts ...Option) *Server {
+	s := Server{
+		now:        time.Now,
+		accounts:   make(map[string]*account),
+		listenKeys: make(map[string]*account),
+		markets:    make(map[string]*engine.Market),
+	}
+
+	for _, o := range opts {
+		o(&s)
+	}
+
+	s.engine = &engine.Engine{ClientIDPrefix: "binancetest", Now: s.now}
+
+	s.router = s.routes()
+	s.Server = httptest.NewServer(http.HandlerFunc(s.serveHTTP))
+	return &s
+}
+
+// BaseURL returns the URL which binance.WithBaseURL should be set to.
+func (s *Server) BaseURL() string {
+	return s.URL + "/api/v3"
+}
+
+// Client returns a binance.Client which sends requests to the server as the
+// account with `apiKey`. The account must have been added with AddAccount.
+func (s *Server) Client(apiKey string,
+	opts ...binance.ClientOption) binance.Client {
+	s.mu.Lock()
+	acc, ok := s.accounts[apiKey]
+	s.mu.Unlock()
+
+	base := []binance.ClientOption{binance.WithBaseURL(s.BaseURL()),
+		binance.WithAPIKey(apiKey)}
+	if ok {
+		base = append(base, binance.WithSecretKey(acc.secretKey))
+	}
+
+	return binance.NewClient(append(base, opts...)...)
+}
+
+// NewSymbol returns the SymbolInfo of a symbol trading `base` against
+// `quote`, which allows every order type and has no filters.
+func NewSymbol(base, quote string) binance.SymbolInfo {
+	return binance.SymbolInfo{
+		BaseAsset:                  base,
+		BaseAssetPrecision:         8,
+		BaseCommissionPrecision:    8,
+		IcebergAllowed:             true,
+		IsSpotTradingAllowed:       true,
+		OCOAllowed:                 true,
+		OrderTypes:                 orderTypes,
+		Permissions:                []string{"SPOT"},
+		QuoteAsset:                 quote,
+		QuoteAssetPrecision:        8,
+		QuoteCommissionPrecision:   8,
+		QuoteOrderQtyMarketAllowed: true,
+		QuotePrecision:             8,
+		Status:                     binance.SymbolStatusTrading,
+		Symbol:                     base + quote,
+	}
+}
+
+var orderTypes = []binance.OrderType{
+	binance.OrderTypeLimit,
+	binance.OrderTypeLimitMaker,
+	binance.OrderTypeMarket,
+	binance.OrderTypeStopLoss,
+	binance.OrderTypeStopLossLimit,
+	binance.OrderTypeTakeProfit,
+	binance.OrderTypeTakeProfitLimit,
+}
+
+// AddSymbol lists `info` on the exchange, replacing its trading rules if it
+// is already listed.
+func (s *Server) AddSymbol(info binance.SymbolInfo) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.addSymbol(info)
+}
+
+func (s *Server) addSymbol(info binance.SymbolInfo) {
+	if m, ok := s.markets[info.Symbol]; ok {
+		m.Info = info
+		return
+	}
+	s.markets[info.Symbol] = engine.NewMarket(info)
+}
+
+// AddAccount opens an account which authenticates with `apiKey` and signs
+// requests with `secretKey`. Adding an existing account replaces its secret
+// key.
+func (s *Server) AddAccount(apiKey, secretKey string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if acc, ok := s.accounts[apiKey]; ok {
+		acc.secretKey = secretKey
+		return
+	}
+	s.accounts[apiKey] = newAccount(apiKey, secretKey)
+}
+
+// Deposit adds `amount` of `asset` to the free balance of the account with
+// `apiKey`. It panics if the account doesn't exist.
+func (s *Server) Deposit(apiKey, asset string, amount binance.Decimal) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.mustAccount(apiKey).Credit(asset, amount)
+}
+
+// Balance returns the balance of `asset` held by the account with `apiKey`.
+// It panics if the account doesn't exist.
+func (s *Server) Balance(apiKey, asset string) binance.Balance {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.mustAccount(apiKey).Balance(asset)
+}
+
+func (s *Server) mustAccount(apiKey string) *account {
+	acc, ok := s.accounts[apiKey]
+	if !ok {
+		panic("binancetest: unknown account " + apiKey)
+	}
+	return acc
+}
+
+// timestamp returns the exchange's time in milliseconds.
+func (s *Server) timestamp() int64 {
+	return s.now().UnixNano() / 1e6
+}
+
+// request contains a parsed request, passed to handlers.
+type request struct {
+	account *account
+	method  string
+	params  url.Values
+}
+
+// handler serves a request, returning the value to encode as the response.
+// Handlers are called with the server's lock held.
+type handler func(*request) (interface{}, error)
+
+type route struct {
+	handler  handler
+	security binance.SecurityLevel
+}
+
+func (s *Server) routes() map[string]map[string]route {
+	public := func(h handler) route {
+		return route{handler: h, security: binance.SecurityLevelNone}
+	}
+	marketData := func(h handler) route {
+		return route{handler: h, security: binance.SecurityLevelMarketData}
+	}
+	userStream := func(h handler) route {
+		return route{handler: h, security: binance.SecurityLevelUserStream}
+	}
+	trade := func(h handler) route {
+		return route{handler: h, security: binance.SecurityLevelTrade}
+	}
+	userData := func(h handler) route {
+		return route{handler: h, security: binance.SecurityLevelUserData}
+	}
+
+	return map[string]map[string]route{
+		"/account":          {http.MethodGet: userData(s.accountInfo)},
+		"/aggTrades":        {http.MethodGet: public(s.aggTrades)},
+		"/allOrderList":     {http.MethodGet: userData(s.allOrderLists)},
+		"/allOrders":        {http.MethodGet: userData(s.allOrders)},
+		"/avgPrice":         {http.MethodGet: public(s.avgPrice)},
+		"/depth":            {http.MethodGet: public(s.depth)},
+		"/exchangeInfo":     {http.MethodGet: public(s.exchangeInfo)},
+		"/historicalTrades": {http.MethodGet: marketData(s.historicalTrades)},
+		"/klines":           {http.MethodGet: public(s.klines)},
+		"/myTrades":         {http.MethodGet: userData(s.myTrades)},
+		"/openOrderList":    {http.MethodGet: userData(s.openOrderLists)},
+		"/openOrders": {
+			http.MethodGet:    userData(s.openOrders),
+			http.MethodDelete: trade(s.cancelOpenOrders),
+		},
+		"/order": {
+			http.MethodGet:    userData(s.queryOrder),
+			http.MethodPost:   trade(s.newOrder),
+			http.MethodDelete: trade(s.cancelOrder),
+		},
+		"/order/oco":  {http.MethodPost: trade(s.newOCO)},
+		"/order/test": {http.MethodPost: trade(s.testOrder)},
+		"/orderList": {
+			http.MethodGet:    userData(s.queryOrderList),
+			http.MethodDelete: trade(s.cancelOrderList),
+		},
+		"/ping":              {http.MethodGet: public(s.ping)},
+		"/ticker/24hr":       {http.MethodGet: public(s.tickerStats)},
+		"/ticker/bookTicker": {http.MethodGet: public(s.bookTicker)},
+		"/ticker/price":      {http.MethodGet: public(s.priceTicker)},
+		"/time":              {http.MethodGet: public(s.serverTime)},
+		"/trades":            {http.MethodGet: public(s.recentTrades)},
+		"/userDataStream": {
+			http.MethodPost:   userStream(s.startUserDataStream),
+			http.MethodPut:    userStream(s.keepAliveUserDataStream),
+			http.MethodDelete: userStream(s.closeUserDataStream),
+		},
+	}
+}
+
+func (s *Server) serveHTTP(w http.ResponseWriter, r *http.Request) {
+	path := strings.TrimPrefix(r.URL.Path, "/api/v3")
+	methods, ok := s.router[path]
+	if !ok {
+		http.NotFound(w, r)
+		return
+	}
+
+	rt, ok := methods[r.Method]
+	if !ok {
+		w.WriteHeader(http.StatusMethodNotAllowed)
+		return
+	}
+
+	body, err := ioutil.ReadAll(r.Body)
+	if err != nil {
+		writeError(w, newError(binance.ErrUnknown, err.Error()))
+		return
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	req, err := s.authenticate(r, body, rt.security)
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+
+	res, err := rt.handler(req)
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(res)
+}
+
+// authenticate parses the parameters of `r`, which are sent in the query
+// string or in the body for any method, and checks that it has the API key
+// and signature required by `level`.
+func (s *Server) authenticate(r *http.Request, body []byte,
+	level binance.SecurityLevel) (*request, error) {
+	params, err := url.ParseQuery(r.URL.RawQuery)
+	if err != nil {
+		return nil, newError(binance.ErrIllegalChars, "Illegal characters "+
+			"found in a parameter.")
+	}
+
+	bodyParams, err := url.ParseQuery(string(body))
+	if err != nil {
+		return nil, newError(binance.ErrIllegalChars, "Illegal characters "+
+			"found in a parameter.")
+	}
+
+	for k, v := range bodyParams {
+		params[k] = append(params[k], v...)
+	}
+
+	req := request{method: r.Method, params: params}
+	if !level.RequiresAuth() {
+		return &req, nil
+	}
+
+	key := r.Header.Get(binance.HeaderAPIKey)
+	if key == "" {
+		return nil, newError(binance.ErrAPIKeyFormat,
+			"API-key format invalid.")
+	}
+
+	acc, ok := s.accounts[key]
+	if !ok {
+		return nil, newError(binance.ErrRejectedMBXKey,
+			"Invalid API-key, IP, or permissions for action.")
+	}
+	req.account = acc
+
+	if !level.RequiresSigning() {
+		return &req, nil
+	}
+
+	signature := params.Get("signature")
+	if signature == "" {
+		return nil, newError(binance.ErrMandatoryParamEmptyOrMalformed,
+			"Mandatory parameter 'signature' was not sent, was empty/null, "+
+				"or malformed.")
+	}
+
+	payload := withoutSignature(r.URL.RawQuery) + withoutSignature(
+		string(body))
+	if !acc.verify(payload, signature) {
+		return nil, newError(binance.ErrInvalidSignature, "Signature for "+
+			"this request is not valid.")
+	}
+
+	return &req, s.checkTimestamp(params)
+}
+
+// withoutSignature returns the url encoded `query` without its signature
+// parameter, preserving the order of the others.
+func withoutSignature(query string) string {
+	var parts []string
+	for _, p := range strings.Split(query, "&") {
+		if p != "" && !strings.HasPrefix(p, "signature=") {
+			parts = append(parts, p)
+		}
+	}
+	return strings.Join(parts, "&")
+}
+
+// checkTimestamp checks that a signed request is within its receive window.
+func (s *Server) checkTimestamp(params url.Values) error {
+	timestamp, err := intParam(params, "timestamp", true)
+	if err != nil {
+		return err
+	}
+
+	recvWindow, err := intParam(params, "recvWindow", false)
+	if err != nil {
+		return err
+	}
+	if recvWindow == 0 {
+		recvWindow = 5000
+	}
+	if recvWindow > 60000 {
+		return newError(binance.ErrInvalidParam, "recvWindow must be less "+
+			"than 60000")
+	}
+
+	now := s.timestamp()
+	if timestamp > now+1000 || now-timestamp > recvWindow {
+		return newError(binance.ErrInvalidTimestamp, "Timestamp for this "+
+			"request is outside of the recvWindow.")
+	}
+
+	return nil
+}
@@ -0,0 +1,552 @@
+package binancetest_test
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/nickcorin/binance"
+	"github.com/nickcorin/binance/binancetest"
+	"github.com/stretchr/testify/require"
+)
+
+var dec = binance.MustParseDecimal
+
+func requireBalance(t *testing.T, srv *binancetest.Server, apiKey,
+	asset, free, locked string) {
+	t.Helper()
+
+	b := srv.Balance(apiKey, asset)
+	require.True(t, dec(free).Equal(b.Free), "%s free: %s", asset, b.Free)
+	require.True(t, dec(locked).Equal(b.Locked), "%s locked: %s", asset,
+		b.Locked)
+}
+
+func newServer() *binancetest.Server {
+	srv := binancetest.NewServer(binancetest.WithSymbols(
+		binancetest.NewSymbol("BTC", "USDT")))
+
+	for _, name := range []string{"alice", "bob", "carol"} {
+		srv.AddAccount(name, name+"-secret")
+		srv.Deposit(name, "BTC", dec("10"))
+		srv.Deposit(name, "USDT", dec("10000"))
+	}
+	return srv
+}
+
+func TestServer_LimitOrder(t *testing.T) {
+	ctx := context.Background()
+	srv := newServer()
+	defer srv.Close()
+	alice, bob := srv.Client("alice"), srv.Client("bob")
+
+	placed, err := alice.NewOrder(ctx, &binance.NewOrderRequest{
+		Price:       dec("100"),
+		Qty:         dec("1"),
+		Side:        binance.Sell,
+		Symbol:      "BTCUSDT",
+		TimeInForce: binance.GoodUntilCancelled,
+		Type:        binance.OrderTypeLimit,
+	})
+	require.NoError(t, err)
+	require.Equal(t, binance.OrderStatusNew, placed.Status)
+	requireBalance(t, srv, "alice", "BTC", "9", "1")
+
+	// Bob's limit is above the ask, so he trades at Alice's price.
+	taken, err := bob.NewOrder(ctx, &binance.NewOrderRequest{
+		Price:       dec("110"),
+		Qty:         dec("0.4"),
+		Side:        binance.Buy,
+		Symbol:      "BTCUSDT",
+		TimeInForce: binance.GoodUntilCancelled,
+		Type:        binance.OrderTypeLimit,
+	})
+	require.NoError(t, err)
+	require.Equal(t, binance.OrderStatusFilled, taken.Status)
+	require.Len(t, taken.Fills, 1)
+	require.Equal(t, "100", taken.Fills[0].Price.String())
+	require.Equal(t, "0.4", taken.Fills[0].Qty.String())
+	requireBalance(t, srv, "bob", "BTC", "10.4", "0")
+	requireBalance(t, srv, "bob", "USDT", "9960", "0")
+
+	query := &binance.QueryOrderRequest{
+		OrderID: placed.OrderID,
+		Symbol:  "BTCUSDT",
+	}
+	order, err := alice.QueryOrder(ctx, query)
+	require.NoError(t, err)
+	require.Equal(t, binance.OrderStatusPartiallyFilled, order.Status)
+	require.True(t, order.IsWorking)
+	require.Equal(t, "0.4", order.ExecutedQty.String())
+
+	cancelled, err := alice.CancelOrder(ctx, &binance.CancelOrderRequest{
+		OrigClientOrderID: placed.ClientOrderID,
+		Symbol:            "BTCUSDT",
+	})
+	require.NoError(t, err)
+	require.Equal(t, binance.OrderStatusCancelled, cancelled.Status)
+	requireBalance(t, srv, "alice", "BTC", "9.6", "0")
+	requireBalance(t, srv, "alice", "USDT", "10040", "0")
+
+	order, err = alice.QueryOrder(ctx, query)
+	require.NoError(t, err)
+	require.Equal(t, binance.OrderStatusCancelled, order.Status)
+	require.False(t, order.IsWorking)
+
+	_, err = alice.CancelOrder(ctx, &binance.CancelOrderRequest{
+		OrderID: placed.OrderID,
+		Symbol:  "BTCUSDT",
+	})
+	require.True(t, binance.IsError(err, binance.ErrCancelRejected))
+
+	trades, err := bob.AccountTrades(ctx, &binance.AccountTradesRequest{
+		Symbol: "BTCUSDT",
+	})
+	require.NoError(t, err)
+	require.Len(t, trades, 1)
+	require.True(t, trades[0].IsBuyer)
+	require.False(t, trades[0].IsMaker)
+}
+
+func TestServer_MarketOrder(t *testing.T) {
+	ctx := context.Background()
+	srv := newServer()
+	defer srv.Close()
+	alice, bob := srv.Client("alice"), srv.Client("bob")
+
+	for _, price := range []string{"100", "101"} {
+		_, err := alice.NewOrder(ctx, &binance.NewOrderRequest{
+			Price:       dec(price),
+			Qty:         dec("1"),
+			Side:        binance.Sell,
+			Symbol:      "BTCUSDT",
+			TimeInForce: binance.GoodUntilCancelled,
+			Type:        binance.OrderTypeLimit,
+		})
+		require.NoError(t, err)
+	}
+
+	// 150 USDT buys one BTC at 100 and the rest at 101.
+	res, err := bob.NewOrder(ctx, &binance.NewOrderRequest{
+		QuoteOrderQty: dec("150"),
+		Side:          binance.Buy,
+		Symbol:        "BTCUSDT",
+		Type:          binance.OrderTypeMarket,
+	})
+	require.NoError(t, err)
+	require.Equal(t, binance.OrderStatusFilled, res.Status)
+	require.Len(t, res.Fills, 2)
+	require.True(t, dec("1.49504950").Equal(res.ExecutedQty))
+	requireBalance(t, srv, "bob", "USDT", "9850.0000005", "0")
+
+	// The book only has 0.5 BTC left, so the rest of the order expires.
+	res, err = bob.NewOrder(ctx, &binance.NewOrderRequest{
+		Qty:    dec("2"),
+		Side:   binance.Buy,
+		Symbol: "BTCUSDT",
+		Type:   binance.OrderTypeMarket,
+	})
+	require.NoError(t, err)
+	require.Equal(t, binance.OrderStatusExpired, res.Status)
+	require.True(t, dec("0.50495050").Equal(res.ExecutedQty))
+	requireBalance(t, srv, "bob", "BTC", "12", "0")
+
+	book, err := bob.OrderBook(ctx, &binance.OrderBookRequest{
+		Symbol: "BTCUSDT",
+	})
+	require.NoError(t, err)
+	require.Empty(t, book.Asks)
+}
+
+func TestServer_Rejections(t *testing.T) {
+	ctx := context.Background()
+	srv := newServer()
+	defer srv.Close()
+
+	limit := func(price, qty string) *binance.NewOrderRequest {
+		return &binance.NewOrderRequest{
+			Price:       dec(price),
+			Qty:         dec(qty),
+			Side:        binance.Buy,
+			Symbol:      "BTCUSDT",
+			TimeInForce: binance.GoodUntilCancelled,
+			Type:        binance.OrderTypeLimit,
+		}
+	}
+
+	tests := []struct {
+		client binance.Client
+		req    *binance.NewOrderRequest
+		code   binance.ErrorCode
+	}{
+		{
+			client: srv.Client("alice"),
+			req:    limit("100", "1000"),
+			code:   binance.ErrNewOrderRejected,
+		},
+		{
+			client: srv.Client("alice"),
+			req: &binance.NewOrderRequest{
+				Qty:    dec("1"),
+				Side:   binance.Buy,
+				Symbol: "ETHUSDT",
+				Type:   binance.OrderTypeMarket,
+			},
+			code: binance.ErrBadSymbol,
+		},
+		{
+			client: srv.Client("alice"),
+			req: &binance.NewOrderRequest{
+				Qty:    dec("1"),
+				Side:   binance.Buy,
+				Symbol: "BTCUSDT",
+				Type:   binance.OrderTypeLimit,
+			},
+			code: binance.ErrMandatoryParamEmptyOrMalformed,
+		},
+		{
+			client: srv.Client("alice"),
+			req: &binance.NewOrderRequest{
+				Qty:         dec("1"),
+				Side:        binance.Buy,
+				Symbol:      "BTCUSDT",
+				TimeInForce: binance.GoodUntilCancelled,
+				Type:        binance.OrderTypeMarket,
+			},
+			code: binance.ErrTIFNotRequired,
+		},
+		{
+			client: srv.Client("alice", binance.WithSecretKey("wrong")),
+			req:    limit("100", "1"),
+			code:   binance.ErrInvalidSignature,
+		},
+		{
+			client: srv.Client("mallory"),
+			req:    limit("100", "1"),
+			code:   binance.ErrRejectedMBXKey,
+		},
+	}
+
+	for i, test := range tests {
+		t.Run(fmt.Sprintf("%d", i), func(t *testing.T) {
+			_, err := test.client.NewOrder(ctx, test.req)
+			require.True(t, binance.IsError(err, test.code), "%v", err)
+		})
+	}
+
+	requireBalance(t, srv, "alice", "USDT", "10000", "0")
+}
+
+func TestServer_Filters(t *testing.T) {
+	ctx := context.Background()
+
+	info := binancetest.NewSymbol("BTC", "USDT")
+	info.Filters = binance.Filters{
+		&binance.PriceFilter{
+			MaxPrice: dec("1000000"),
+			MinPrice: dec("0.01"),
+			TickSize: dec("0.01"),
+		},
+	}
+
+	srv := binancetest.NewServer(binancetest.WithSymbols(info))
+	defer srv.Close()
+	srv.AddAccount("alice", "secret")
+	srv.Deposit("alice", "USDT", dec("100"))
+
+	_, err := srv.Client("alice").NewOrder(ctx, &binance.NewOrderRequest{
+		Price:       dec("1.001"),
+		Qty:         dec("1"),
+		Side:        binance.Buy,
+		Symbol:      "BTCUSDT",
+		TimeInForce: binance.GoodUntilCancelled,
+		Type:        binance.OrderTypeLimit,
+	})
+	require.True(t, binance.IsError(err, binance.ErrInvalidMessage))
+	require.Contains(t, err.Error(), "Filter failure: PRICE_FILTER")
+
+	_, err = srv.Client("alice").NewOrder(ctx, &binance.NewOrderRequest{
+		QuoteOrderQty: dec("1.000000001"),
+		Side:          binance.Buy,
+		Symbol:        "BTCUSDT",
+		Type:          binance.OrderTypeMarket,
+	})
+	require.True(t, binance.IsError(err, binance.ErrBadPrecision))
+}
+
+func TestServer_OCO(t *testing.T) {
+	ctx := context.Background()
+	srv := newServer()
+	defer srv.Close()
+	alice, bob, carol := srv.Client("alice"), srv.Client("bob"),
+		srv.Client("carol")
+
+	_, err := bob.NewOrder(ctx, &binance.NewOrderRequest{
+		Price:       dec("90"),
+		Qty:         dec("5"),
+		Side:        binance.Buy,
+		Symbol:      "BTCUSDT",
+		TimeInForce: binance.GoodUntilCancelled,
+		Type:        binance.OrderTypeLimit,
+	})
+	require.NoError(t, err)
+
+	list, err := alice.NewOCO(ctx, &binance.NewOCORequest{
+		Price:     dec("120"),
+		Qty:       dec("2"),
+		Side:      binance.Sell,
+		StopPrice: dec("90"),
+		Symbol:    "BTCUSDT",
+	})
+	require.NoError(t, err)
+	require.Equal(t, binance.ListOrderStatusExecuting, list.ListOrderStatus)
+	require.Len(t, list.OrderReports, 2)
+	requireBalance(t, srv, "alice", "BTC", "8", "2")
+
+	// Carol trading at 90 triggers Alice's stop, which sells into Bob's bid
+	// and expires her limit order.
+	_, err = carol.NewOrder(ctx, &binance.NewOrderRequest{
+		Qty:    dec("1"),
+		Side:   binance.Sell,
+		Symbol: "BTCUSDT",
+		Type:   binance.OrderTypeMarket,
+	})
+	require.NoError(t, err)
+
+	queried, err := alice.QueryOCO(ctx, &binance.QueryOCORequest{
+		OrderListID: list.OrderListID,
+	})
+	require.NoError(t, err)
+	require.Equal(t, binance.ListOrderStatusAllDone, queried.ListOrderStatus)
+
+	statuses := make(map[binance.OrderType]binance.OrderStatus)
+	for _, entry := range queried.Orders {
+		order, err := alice.QueryOrder(ctx, &binance.QueryOrderRequest{
+			OrderID: entry.OrderID,
+			Symbol:  "BTCUSDT",
+		})
+		require.NoError(t, err)
+		statuses[order.Type] = order.Status
+	}
+	require.Equal(t, map[binance.OrderType]binance.OrderStatus{
+		binance.OrderTypeLimitMaker: binance.OrderStatusExpired,
+		binance.OrderTypeStopLoss:   binance.OrderStatusFilled,
+	}, statuses)
+
+	requireBalance(t, srv, "alice", "BTC", "8", "0")
+	requireBalance(t, srv, "alice", "USDT", "10180", "0")
+	requireBalance(t, srv, "bob", "BTC", "13", "0")
+	requireBalance(t, srv, "bob", "USDT", "9550", "180")
+
+	open, err := alice.OpenOCO(ctx)
+	require.NoError(t, err)
+	require.Empty(t, open)
+}
+
+func TestServer_CancelOCO(t *testing.T) {
+	ctx := context.Background()
+	srv := newServer()
+	defer srv.Close()
+	alice := srv.Client("alice")
+
+	list, err := alice.NewOCO(ctx, &binance.NewOCORequest{
+		Price:                dec("80"),
+		Qty:                  dec("1"),
+		Side:                 binance.Buy,
+		StopLimitPrice:       dec("125"),
+		StopLimitTimeInForce: binance.GoodUntilCancelled,
+		StopPrice:            dec("120"),
+		Symbol:               "BTCUSDT",
+	})
+	require.NoError(t, err)
+	requireBalance(t, srv, "alice", "USDT", "9920", "80")
+
+	// Cancelling either order cancels the list.
+	cancelled, err := alice.CancelOrder(ctx, &binance.CancelOrderRequest{
+		OrderID: list.Orders[0].OrderID,
+		Symbol:  "BTCUSDT",
+	})
+	require.NoError(t, err)
+	require.Equal(t, binance.OrderStatusCancelled, cancelled.Status)
+	requireBalance(t, srv, "alice", "USDT", "10000", "0")
+
+	_, err = alice.CancelOCO(ctx, &binance.CancelOCORequest{
+		OrderListID: list.OrderListID,
+		Symbol:      "BTCUSDT",
+	})
+	require.True(t, binance.IsError(err, binance.ErrCancelRejected))
+
+	_, err = alice.NewOCO(ctx, &binance.NewOCORequest{
+		Price:     dec("130"),
+		Qty:       dec("1"),
+		Side:      binance.Buy,
+		StopPrice: dec("120"),
+		Symbol:    "BTCUSDT",
+	})
+	require.True(t, binance.IsError(err, binance.ErrNewOrderRejected))
+}
+
+func TestServer_CancelOpenOrders(t *testing.T) {
+	ctx := context.Background()
+	srv := newServer()
+	defer srv.Close()
+
+	dir, err := ioutil.TempDir("", "server")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	rec, err := binancetest.NewRecorder(filepath.Join(dir, "fixture.json"),
+		binancetest.ModeRecord)
+	require.NoError(t, err)
+	alice := srv.Client("alice", binance.WithTransport(rec.HTTPClient()))
+
+	_, err = alice.NewOrder(ctx, &binance.NewOrderRequest{
+		Price:       dec("120"),
+		Qty:         dec("1"),
+		Side:        binance.Sell,
+		Symbol:      "BTCUSDT",
+		TimeInForce: binance.GoodUntilCancelled,
+		Type:        binance.OrderTypeLimit,
+	})
+	require.NoError(t, err)
+
+	list, err := alice.NewOCO(ctx, &binance.NewOCORequest{
+		Price:                dec("80"),
+		Qty:                  dec("1"),
+		Side:                 binance.Buy,
+		StopLimitPrice:       dec("125"),
+		StopLimitTimeInForce: binance.GoodUntilCancelled,
+		StopPrice:            dec("120"),
+		Symbol:               "BTCUSDT",
+	})
+	require.NoError(t, err)
+
+	cancelled, err := alice.CancelAllOpenOrders(ctx, "BTCUSDT")
+	require.NoError(t, err)
+	require.Len(t, cancelled, 3)
+	for _, o := range cancelled {
+		require.Equal(t, binance.OrderStatusCancelled, o.Status)
+	}
+	require.Equal(t, int64(-1), cancelled[0].OrderListID)
+	require.Equal(t, list.OrderListID, cancelled[1].OrderListID)
+	require.Equal(t, list.OrderListID, cancelled[2].OrderListID)
+	requireBalance(t, srv, "alice", "BTC", "10", "0")
+	requireBalance(t, srv, "alice", "USDT", "10000", "0")
+
+	// Like the exchange, the list is returned in place of its orders.
+	interactions := rec.Interactions()
+	var res []map[string]json.RawMessage
+	err = json.Unmarshal(interactions[len(interactions)-1].Response.Body, &res)
+	require.NoError(t, err)
+	require.Len(t, res, 2)
+	require.NotContains(t, res[0], "orderReports")
+	require.Contains(t, res[1], "orderReports")
+}
+
+func TestServer_MarketData(t *testing.T) {
+	ctx := context.Background()
+	now := time.Date(2021, 1, 4, 10, 30, 0, 0, time.UTC)
+	srv := binancetest.NewServer(
+		binancetest.WithClock(func() time.Time { return now }),
+		binancetest.WithSymbols(binancetest.NewSymbol("BTC", "USDT"),
+			binancetest.NewSymbol("ETH", "USDT")))
+	defer srv.Close()
+
+	srv.AddAccount("alice", "secret")
+	srv.Deposit("alice", "BTC", dec("10"))
+	srv.Deposit("alice", "USDT", dec("10000"))
+	alice := srv.Client("alice", binance.WithClock(func() time.Time {
+		return now
+	}))
+
+	for _, o := range []struct {
+		price string
+		side  binance.OrderSide
+	}{
+		{"100", binance.Sell},
+		{"100", binance.Buy},
+		{"102", binance.Sell},
+		{"102", binance.Buy},
+		{"98", binance.Buy},
+		{"105", binance.Sell},
+	} {
+		_, err := alice.NewOrder(ctx, &binance.NewOrderRequest{
+			Price:       dec(o.price),
+			Qty:         dec("1"),
+			Side:        o.side,
+			Symbol:      "BTCUSDT",
+			TimeInForce: binance.GoodUntilCancelled,
+			Type:        binance.OrderTypeLimit,
+		})
+		require.NoError(t, err)
+	}
+
+	book, err := alice.OrderBook(ctx, &binance.OrderBookRequest{
+		Symbol: "BTCUSDT",
+	})
+	require.NoError(t, err)
+	require.Len(t, book.Asks, 1)
+	require.Equal(t, "105", book.Asks[0].Price.String())
+	require.Len(t, book.Bids, 1)
+	require.Equal(t, "98", book.Bids[0].Price.String())
+
+	ticker, err := alice.PriceTicker(ctx, "BTCUSDT")
+	require.NoError(t, err)
+	require.Equal(t, "102", ticker.Price.String())
+
+	tickers, err := alice.ListPriceTickers(ctx)
+	require.NoError(t, err)
+	require.Len(t, tickers, 2)
+
+	avg, err := alice.AveragePrice(ctx, "BTCUSDT")
+	require.NoError(t, err)
+	require.True(t, dec("101").Equal(avg.Price))
+
+	stats, err := alice.TickerStats(ctx, "BTCUSDT")
+	require.NoError(t, err)
+	require.Equal(t, int64(2), stats.Count)
+	require.True(t, dec("2").Equal(stats.PriceChange))
+	require.True(t, dec("2").Equal(stats.PriceChangePercent))
+
+	klines, err := alice.Klines(ctx, &binance.KlinesRequest{
+		Interval: binance.OneHour,
+		Symbol:   "BTCUSDT",
+	})
+	require.NoError(t, err)
+	require.Len(t, klines, 1)
+	require.Equal(t, now.Truncate(time.Hour).UnixNano()/1e6,
+		klines[0].OpenTime)
+	require.Equal(t, int64(2), klines[0].TradeCount)
+	require.True(t, dec("102").Equal(klines[0].High))
+
+	info, err := alice.ExchangeInfo(ctx)
+	require.NoError(t, err)
+	require.Len(t, info.Symbols, 2)
+	require.Equal(t, "BTCUSDT", info.Symbols[0].Symbol)
+}
+
+func TestServer_UserDataStream(t *testing.T) {
+	ctx := context.Background()
+	srv := newServer()
+	defer srv.Close()
+	alice := srv.Client("alice")
+
+	key, err := alice.StartUserDataStream(ctx)
+	require.NoError(t, err)
+	require.NotEmpty(t, key)
+
+	again, err := alice.StartUserDataStream(ctx)
+	require.NoError(t, err)
+	require.Equal(t, key, again)
+
+	require.NoError(t, alice.KeepAliveUserDataStream(ctx, key))
+	require.NoError(t, alice.CloseUserDataStream(ctx, key))
+
+	err = alice.KeepAliveUserDataStream(ctx, key)
+	require.True(t, binance.IsError(err, binance.ErrInvalidListenKey))
+}
@@ -0,0 +1,368 @@
+package binancetest
+
+import (
+	"net/url"
+	"time"
+
+	"github.com/nickcorin/binance"
+	"github.com/nickcorin/binance/internal/engine"
+)
+
+// parseOrder returns the order described by the parameters of a new order
+// request.
+func parseOrder(params url.Values) (*binance.NewOrderRequest, error) {
+	var (
+		req binance.NewOrderRequest
+		err error
+	)
+
+	req.Symbol, err = stringParam(params, "symbol", true)
+	if err != nil {
+		return nil, err
+	}
+
+	side, err := stringParam(params, "side", true)
+	if err != nil {
+		return nil, err
+	}
+	req.Side = binance.OrderSide(side)
+
+	typ, err := stringParam(params, "type", true)
+	if err != nil {
+		return nil, err
+	}
+	req.Type = binance.OrderType(typ)
+
+	decimals := []struct {
+		name  string
+		value *binance.Decimal
+	}{
+		{"icebergQty", &req.IcebergQty},
+		{"price", &req.Price},
+		{"quantity", &req.Qty},
+		{"quoteOrderQty", &req.QuoteOrderQty},
+		{"stopPrice", &req.StopPrice},
+	}
+	for _, d := range decimals {
+		*d.value, err = decimalParam(params, d.name, false)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	req.NewClientOrderID = params.Get("newClientOrderId")
+	req.ResponseType = binance.OrderResponseType(
+		params.Get("newOrderRespType"))
+	req.TimeInForce = binance.TimeInForce(params.Get("timeInForce"))
+	return &req, nil
+}
+
+// checkOrder returns the error that the exchange would reject `req` from
+// `acc` with, if any.
+func (s *Server) checkOrder(m *engine.Market, acc *account,
+	req *binance.NewOrderRequest) error {
+	return engine.CheckOrder(m, acc.Account, req,
+		s.averagePrice(m, 5*time.Minute))
+}
+
+// place places `o`, and then triggers any stop orders that its trades
+// reached.
+func (s *Server) place(m *engine.Market, o *engine.Order) error {
+	err := s.engine.Place(m, o, m.Makers(o.Side), m.LastPrice())
+	if err != nil {
+		return err
+	}
+
+	s.trigger(m)
+	return nil
+}
+
+// trigger executes stop orders whose stop price has been reached, until no
+// more are triggered.
+func (s *Server) trigger(m *engine.Market) {
+	for {
+		var triggered *engine.Order
+		for _, o := range m.Stops {
+			if o.TriggeredBy(m.LastPrice(), m.LastPrice()) {
+				triggered = o
+				break
+			}
+		}
+
+		if triggered == nil {
+			return
+		}
+		s.engine.Trigger(m, triggered, m.Makers(triggered.Side))
+	}
+}
+
+type ackResponse struct {
+	ClientOrderID string `json:"clientOrderId"`
+	OrderID       int64  `json:"orderId"`
+	OrderListID   int64  `json:"orderListId"`
+	Symbol        string `json:"symbol"`
+	TransactTime  int64  `json:"transactTime"`
+}
+
+func newOrderResponse(o *engine.Order,
+	respType binance.OrderResponseType) interface{} {
+	if respType == binance.OrderResponseTypeAck {
+		return ackResponse{
+			ClientOrderID: o.ClientOrderID,
+			OrderID:       o.ID,
+			OrderListID:   o.ListID(),
+			Symbol:        o.Symbol,
+			TransactTime:  o.Time,
+		}
+	}
+	return o.Response(respType)
+}
+
+func (s *Server) newOrder(r *request) (interface{}, error) {
+	m, err := s.market(r.params)
+	if err != nil {
+		return nil, err
+	}
+
+	req, err := parseOrder(r.params)
+	if err != nil {
+		return nil, err
+	}
+
+	respType, err := engine.ResponseType(req)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := s.checkOrder(m, r.account, req); err != nil {
+		return nil, err
+	}
+
+	o := engine.NewOrder(r.account.Account, req)
+	if err := s.place(m, o); err != nil {
+		return nil, err
+	}
+
+	return newOrderResponse(o, respType), nil
+}
+
+// testOrder checks an order without placing it.
+func (s *Server) testOrder(r *request) (interface{}, error) {
+	m, err := s.market(r.params)
+	if err != nil {
+		return nil, err
+	}
+
+	req, err := parseOrder(r.params)
+	if err != nil {
+		return nil, err
+	}
+
+	if _, err := engine.ResponseType(req); err != nil {
+		return nil, err
+	}
+
+	if err := s.checkOrder(m, r.account, req); err != nil {
+		return nil, err
+	}
+
+	return struct{}{}, nil
+}
+
+// lookupOrder returns the order of `acc` identified by the orderId or
+// origClientOrderId parameter, or nil if there isn't one.
+func lookupOrder(m *engine.Market, acc *account, params url.Values) (
+	*engine.Order, error) {
+	id, err := intParam(params, "orderId", false)
+	if err != nil {
+		return nil, err
+	}
+
+	clientID := params.Get("origClientOrderId")
+	if id == 0 && clientID == "" {
+		return nil, newError(binance.ErrMandatoryParamEmptyOrMalformed,
+			"Param 'origClientOrderId' or 'orderId' must be sent, but both "+
+				"were empty/null!")
+	}
+
+	return m.Lookup(acc.Account, id, clientID), nil
+}
+
+func (s *Server) queryOrder(r *request) (interface{}, error) {
+	m, err := s.market(r.params)
+	if err != nil {
+		return nil, err
+	}
+
+	o, err := lookupOrder(m, r.account, r.params)
+	if err != nil {
+		return nil, err
+	} else if o == nil {
+		return nil, errNoSuchOrder
+	}
+
+	return o.QueryResponse(), nil
+}
+
+// cancelOrder cancels an open order. Cancelling an order in an OCO cancels
+// the whole list.
+func (s *Server) cancelOrder(r *request) (interface{}, error) {
+	m, err := s.market(r.params)
+	if err != nil {
+		return nil, err
+	}
+
+	o, err := lookupOrder(m, r.account, r.params)
+	if err != nil {
+		return nil, err
+	} else if o == nil || !o.IsOpen() {
+		return nil, errUnknownOrder
+	}
+
+	s.engine.Cancel(m, o)
+	return o.CancelResponse(), nil
+}
+
+func (s *Server) openOrders(r *request) (interface{}, error) {
+	markets := s.sortedMarkets()
+	if r.params.Get("symbol") != "" {
+		m, err := s.market(r.params)
+		if err != nil {
+			return nil, err
+		}
+		markets = []*engine.Market{m}
+	}
+
+	res := make([]binance.QueryOrderResponse, 0)
+	for _, m := range markets {
+		for _, o := range m.OpenOrders(r.account.Account) {
+			res = append(res, o.QueryResponse())
+		}
+	}
+	return res, nil
+}
+
+func (s *Server) cancelOpenOrders(r *request) (interface{}, error) {
+	m, err := s.market(r.params)
+	if err != nil {
+		return nil, err
+	}
+
+	orders := m.OpenOrders(r.account.Account)
+	if len(orders) == 0 {
+		return nil, errUnknownOrder
+	}
+
+	// Orders in a list are cancelled with their list, which is returned in
+	// their place.
+	res := make([]interface{}, 0, len(orders))
+	for _, o := range orders {
+		if o.List != nil && !o.IsOpen() {
+			continue
+		}
+
+		s.engine.Cancel(m, o)
+		if o.List != nil {
+			res = append(res, o.List.Response(true))
+		} else {
+			res = append(res, o.CancelResponse())
+		}
+	}
+	return res, nil
+}
+
+func (s *Server) allOrders(r *request) (interface{}, error) {
+	m, err := s.market(r.params)
+	if err != nil {
+		return nil, err
+	}
+
+	fromID, err := intParam(r.params, "orderId", false)
+	if err != nil {
+		return nil, err
+	}
+
+	start, end, err := timeRange(r.params)
+	if err != nil {
+		return nil, err
+	}
+
+	limit, err := limitParam(r.params, 500, 1000)
+	if err != nil {
+		return nil, err
+	}
+
+	res := make([]binance.QueryOrderResponse, 0)
+	for _, o := range m.Orders {
+		if o.Account != r.account.Account || o.ID < fromID ||
+			o.Time < start || o.Time > end {
+			continue
+		}
+		res = append(res, o.QueryResponse())
+	}
+
+	// Without a starting point the most recent orders are returned.
+	if fromID == 0 && start == 0 && int64(len(res)) > limit {
+		res = res[int64(len(res))-limit:]
+	}
+	if int64(len(res)) > limit {
+		res = res[:limit]
+	}
+	return res, nil
+}
+
+func (s *Server) myTrades(r *request) (interface{}, error) {
+	m, err := s.market(r.params)
+	if err != nil {
+		return nil, err
+	}
+
+	orderID, err := intParam(r.params, "orderId", false)
+	if err != nil {
+		return nil, err
+	}
+
+	fromID, err := intParam(r.params, "fromId", false)
+	if err != nil {
+		return nil, err
+	}
+
+	start, end, err := timeRange(r.params)
+	if err != nil {
+		return nil, err
+	}
+
+	limit, err := limitParam(r.params, 500, 1000)
+	if err != nil {
+		return nil, err
+	}
+
+	res := make([]binance.AccountTrade, 0)
+	for _, t := range r.account.Trades {
+		if t.Symbol != m.Info.Symbol || orderID != 0 && t.OrderID != orderID ||
+			t.ID < fromID || t.Time < start || t.Time > end {
+			continue
+		}
+		res = append(res, t)
+	}
+
+	// Without a starting point the most recent trades are returned.
+	if fromID == 0 && start == 0 && int64(len(res)) > limit {
+		res = res[int64(len(res))-limit:]
+	}
+	if int64(len(res)) > limit {
+		res = res[:limit]
+	}
+	return res, nil
+}
+
+func (s *Server) accountInfo(r *request) (interface{}, error) {
+	return binance.AccountInfo{
+		AccountType: "SPOT",
+		Balances:    r.account.Balances(),
+		CanDeposit:  true,
+		CanTrade:    true,
+		CanWithdraw: true,
+		UpdateTime:  s.timestamp(),
+	}, nil
+}
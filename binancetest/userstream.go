@@ -0,0 +1,59 @@
+package binancetest
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+)
+
+type listenKeyResponse struct {
+	ListenKey string `json:"listenKey"`
+}
+
+// startUserDataStream returns the account's listen key, creating one if it
+// doesn't have one.
+func (s *Server) startUserDataStream(r *request) (interface{}, error) {
+	acc := r.account
+	if acc.listenKey == "" {
+		b := make([]byte, 32)
+		if _, err := rand.Read(b); err != nil {
+			return nil, err
+		}
+
+		acc.listenKey = hex.EncodeToString(b)
+		s.listenKeys[acc.listenKey] = acc
+	}
+
+	return listenKeyResponse{ListenKey: acc.listenKey}, nil
+}
+
+// checkListenKey returns an error unless the listenKey parameter belongs to
+// the request's account.
+func (s *Server) checkListenKey(r *request) (string, error) {
+	key, err := stringParam(r.params, "listenKey", true)
+	if err != nil {
+		return "", err
+	}
+
+	if acc, ok := s.listenKeys[key]; !ok || acc != r.account {
+		return "", errInvalidListenKey
+	}
+	return key, nil
+}
+
+func (s *Server) keepAliveUserDataStream(r *request) (interface{}, error) {
+	if _, err := s.checkListenKey(r); err != nil {
+		return nil, err
+	}
+	return struct{}{}, nil
+}
+
+func (s *Server) closeUserDataStream(r *request) (interface{}, error) {
+	key, err := s.checkListenKey(r)
+	if err != nil {
+		return nil, err
+	}
+
+	delete(s.listenKeys, key)
+	r.account.listenKey = ""
+	return struct{}{}, nil
+}
@@ -3,9 +3,6 @@ package binance
 import (
 	"bytes"
 	"context"
-	"crypto/hmac"
-	"crypto/sha256"
-	"encoding/hex"
 	"encoding/json"
 	"fmt"
 	"io/ioutil"
@@ -18,27 +15,60 @@ import (
 	"github.com/luno/jettison/errors"
 	"github.com/luno/jettison/j"
 	"github.com/luno/jettison/log"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/propagation"
+	"go.opentelemetry.io/otel/trace"
 )
 
 type client struct {
-	encoder *schema.Encoder
-	decoder *schema.Decoder
-	options *ClientOptions
+	encoder  *schema.Encoder
+	decoder  *schema.Decoder
+	metrics  *metrics
+	options  *ClientOptions
+	timeSync *timeSync
+	tracer   trace.Tracer
 }
 
 // NewClient returns a Client implementation.
 func NewClient(opts ...ClientOption) Client {
+	// Copy the defaults so that options don't leak between clients.
+	options := defaultOptions
+
 	c := client{
 		encoder: schema.NewEncoder(),
 		decoder: schema.NewDecoder(),
-		options: &defaultOptions,
+		options: &options,
 	}
 
+	registerDecimalEncoder(c.encoder)
+
 	// Apply each of the options to the client.
 	for _, o := range opts {
 		o(c.options)
 	}
 
+	if c.options.signer == nil {
+		c.options.signer = NewHMACSigner(c.options.secretKey)
+	}
+
+	if c.options.timeSync > 0 {
+		c.timeSync = newTimeSync(c.options.timeSync, c.options.clock)
+	}
+
+	provider := c.options.tracerProvider
+	if provider == nil {
+		provider = trace.NewNoopTracerProvider()
+	}
+	c.tracer = provider.Tracer(tracerName)
+
+	// The client still works if its metrics can't be exported, so a
+	// conflicting registration is only logged.
+	var err error
+	c.metrics, err = newMetrics(c.options.registerer)
+	if err != nil {
+		c.error(context.Background(), err)
+	}
+
 	return &c
 }
 
@@ -58,7 +88,6 @@ func (c *client) debug(ctx context.Context, msg string,
 // logging.
 func (c *client) error(ctx context.Context, err error,
 	opts ...jettison.Option) error {
-	clientErrorsCounter.WithLabelValues(err.Error()).Inc()
 	if c.options.logLevel >= LogLevelError {
 		log.Error(ctx, err, opts...)
 	}
@@ -73,40 +102,141 @@ func (c *client) setAuthHeader(r *http.Request) *http.Request {
 	return r
 }
 
-func (c *client) signRequest(r *http.Request, body []byte) *http.Request {
-	sig := hmac.New(sha256.New, []byte(c.options.secretKey))
+// timestamp returns the time to stamp a signed request with, in milliseconds.
+func (c *client) timestamp() int64 {
+	now := c.options.clock()
+	if c.timeSync != nil {
+		now = now.Add(c.timeSync.Offset())
+	}
+	return now.UnixNano() / 1e6
+}
 
-	timestamp := time.Now().UnixNano() / 1e6
+func (c *client) signRequest(ctx context.Context, r *http.Request,
+	body []byte) (*http.Request, error) {
 	values := r.URL.Query()
 
-	values.Set("timestamp", fmt.Sprintf("%d", timestamp))
-	sig.Write([]byte(values.Encode()))
-	sig.Write(body)
+	// A recvWindow set on the request itself takes precedence.
+	if c.options.recvWindow > 0 && values.Get("recvWindow") == "" {
+		bodyValues, _ := url.ParseQuery(string(body))
+		if bodyValues.Get("recvWindow") == "" {
+			values.Set("recvWindow", fmt.Sprintf("%d",
+				c.options.recvWindow.Milliseconds()))
+		}
+	}
+
+	values.Set("timestamp", fmt.Sprintf("%d", c.timestamp()))
+	payload := append([]byte(values.Encode()), body...)
 
-	values.Set("signature", hex.EncodeToString(sig.Sum(nil)))
+	sig, err := c.options.signer.Sign(ctx, payload)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to sign request")
+	}
+
+	values.Set("signature", sig)
 	r.URL.RawQuery = values.Encode()
-	return r
+	return r, nil
 }
 
 func (c *client) call(ctx context.Context, method, path string,
 	body []byte) ([]byte, error) {
+	b, _, err := c.send(ctx, method, path, body)
+	return b, err
+}
+
+// send sends a request, retrying it according to the client's RetryPolicy.
+// The last response received is returned alongside any error, or nil if no
+// response was received.
+func (c *client) send(ctx context.Context, method, path string,
+	body []byte) ([]byte, *http.Response, error) {
 
 	// Add useful data into the context to be included in logs.
 	ctx = log.ContextWith(ctx, j.MKV{"method": method, "path": path})
 	u, err := url.ParseRequestURI(fmt.Sprintf("%s%s", c.options.baseURL,
 		path))
 	if err != nil {
-		return nil, errors.Wrap(err, "failed to parse uri")
+		return nil, nil, errors.Wrap(err, "failed to parse uri")
+	}
+
+	ctx, span := c.startSpan(ctx, method, path, u)
+
+	for attempt := 1; ; attempt++ {
+		b, res, err := c.do(ctx, method, u, body)
+		if err == nil {
+			endSpan(span, attempt, res, nil)
+			return b, res, nil
+		}
+
+		wait, ok := c.options.retryPolicy.retryWait(ctx, method, attempt,
+			res, err)
+		if !ok {
+			c.metrics.observeError(err)
+			endSpan(span, attempt, res, err)
+			return nil, res, err
+		}
+
+		c.metrics.retries.WithLabelValues(u.Path).Inc()
+		span.AddEvent("retry", trace.WithAttributes(
+			attrAttempt.Int(attempt),
+			attrRetryWait.Int64(wait.Milliseconds())))
+
+		c.debug(ctx, "Retrying request", j.MKV{"attempt": attempt,
+			"wait": wait.String()})
+
+		t := time.NewTimer(wait)
+		select {
+		case <-ctx.Done():
+			t.Stop()
+			c.metrics.observeError(err)
+			endSpan(span, attempt, res, err)
+			return nil, res, err
+		case <-t.C:
+		}
+	}
+}
+
+// errNotSent is wrapped around errors which stopped a request from being
+// sent, such as a failed time sync, so that they aren't mistaken for a
+// failure of the request itself.
+var errNotSent = errors.New("request not sent", j.C("ERR_REQUEST_NOT_SENT"))
+
+func notSent(err error) error {
+	return errors.Wrap(err, "request not sent", j.C("ERR_REQUEST_NOT_SENT"))
+}
+
+// failedInTransport returns whether a request which received no response
+// failed after it was sent, so that the exchange may have received it.
+func failedInTransport(err error) bool {
+	if errors.Is(err, errNotSent) {
+		return false
 	}
 
+	var urlErr *url.Error
+	return errors.As(err, &urlErr)
+}
+
+// do sends a single request. The response is returned alongside any error
+// once it has been received, with its body already read and closed.
+func (c *client) do(ctx context.Context, method string, u *url.URL,
+	body []byte) ([]byte, *http.Response, error) {
 	req, err := http.NewRequestWithContext(ctx, method, u.String(),
 		bytes.NewBuffer(body))
 	if err != nil {
-		return nil, errors.Wrap(err, "failed to create request")
+		return nil, nil, errors.Wrap(err, "failed to create request")
+	}
+
+	// Wait for the rate limiter before signing, so that the timestamp isn't
+	// stale by the time the request is sent.
+	if c.options.rateLimiter != nil {
+		cost := getRequestCost(u, method, body)
+		if err := c.options.rateLimiter.wait(ctx, cost); err != nil {
+			return nil, nil, notSent(err)
+		}
 	}
 
 	// Set required headers and sign request.
 	req.Header.Set("Content-type", "application/x-www-form-urlencoded")
+	otel.GetTextMapPropagator().Inject(ctx,
+		propagation.HeaderCarrier(req.Header))
 	securityLevel := getSecurityLevel(u, method)
 
 	if securityLevel.RequiresAuth() {
@@ -114,48 +244,65 @@ func (c *client) call(ctx context.Context, method, path string,
 	}
 
 	if securityLevel.RequiresSigning() {
-		req = c.signRequest(req, body)
+		if c.timeSync != nil {
+			if err := c.timeSync.Sync(ctx, c.ServerTime); err != nil {
+				return nil, nil, notSent(err)
+			}
+		}
+		req, err = c.signRequest(ctx, req, body)
+		if err != nil {
+			return nil, nil, notSent(err)
+		}
 	}
 
 	reqStart := time.Now()
 	res, err := c.options.transport.Do(req)
 	if err != nil {
-		return nil, errors.Wrap(err, "failed to execute request")
+		return nil, nil, errors.Wrap(err, "failed to execute request")
 	}
 	latency := time.Since(reqStart)
 
+	if c.options.rateLimiter != nil {
+		c.options.rateLimiter.update(res.Header, res.StatusCode)
+	}
+
 	// Record system metrics.
-	httpRequestLatencyHist.WithLabelValues(u.Path).Observe(latency.Seconds())
-	httpResponseCodesCounter.WithLabelValues(u.Path, fmt.Sprintf("%d",
-		res.StatusCode)).Inc()
+	c.metrics.observeResponse(u.Path, res, latency.Seconds())
 	c.debug(ctx, "HTTPS client request", j.KV("status_code", res.StatusCode))
 
 	defer res.Body.Close()
 	b, err := ioutil.ReadAll(res.Body)
 	if err != nil {
-		return nil, errors.Wrap(err, "failed to read response body")
+		return nil, res, errors.Wrap(err, "failed to read response body")
 	}
 
 	// We can assume that 2XX response codes mean that the request was
 	// successful.
 	if res.StatusCode < http.StatusMultipleChoices {
-		return b, nil
+		return b, res, nil
 	}
 
 	// Return a generic error since we didn't receive any extra information
 	// about the error.
 	if len(b) == 0 {
-		return nil, errors.New("unsuccessful response code received",
+		return nil, res, errors.New("unsuccessful response code received",
 			j.KV("response_code", res.StatusCode))
 	}
 
 	var apiError Error
 	err = json.Unmarshal(b, &apiError)
 	if err != nil {
-		return nil, errors.Wrap(err, "failed to parse response error")
+		return nil, res, errors.Wrap(err,
+			"failed to parse response error")
+	}
+
+	// Our clock has drifted from the server's, so measure it again before
+	// the next signed request.
+	if c.timeSync != nil && apiError.Is(ErrInvalidTimestamp) {
+		c.timeSync.Invalidate()
 	}
 
-	return nil, apiError
+	return nil, res, apiError
 }
 
 func (c *client) get(ctx context.Context, path string) ([]byte, error) {
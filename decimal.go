@@ -0,0 +1,389 @@
+package binance
+
+import (
+	"bytes"
+	"encoding/json"
+	"math"
+	"math/big"
+	"reflect"
+	"strconv"
+	"strings"
+
+	"github.com/gorilla/schema"
+	"github.com/luno/jettison/errors"
+	"github.com/luno/jettison/j"
+)
+
+// Decimal is an exact decimal number, used for all prices and quantities.
+// Floating point numbers can't represent most decimal fractions exactly, so
+// amounts would otherwise lose precision between requests and responses.
+//
+// The zero value is zero. Decimals are immutable, and every operation returns
+// a new Decimal. Use Equal or Cmp to compare Decimals, since == compares their
+// representation rather than their value.
+type Decimal struct {
+	// unscaled is the value multiplied by 10^scale. It is nil for the zero
+	// value, and is never modified once set.
+	unscaled *big.Int
+	scale    int32
+}
+
+var bigTen = big.NewInt(10)
+
+// pow10 returns 10^n.
+func pow10(n int32) *big.Int {
+	return new(big.Int).Exp(bigTen, big.NewInt(int64(n)), nil)
+}
+
+// newDecimal returns a Decimal which takes ownership of `unscaled`,
+// normalizing negative scales.
+func newDecimal(unscaled *big.Int, scale int32) Decimal {
+	if scale < 0 {
+		unscaled.Mul(unscaled, pow10(-scale))
+		scale = 0
+	}
+	return Decimal{unscaled: unscaled, scale: scale}
+}
+
+// NewDecimal returns the Decimal unscaled * 10^-scale. For example,
+// NewDecimal(123, 2) is 1.23.
+func NewDecimal(unscaled int64, scale int32) Decimal {
+	return newDecimal(big.NewInt(unscaled), scale)
+}
+
+// NewDecimalFromInt returns `i` as a Decimal.
+func NewDecimalFromInt(i int64) Decimal {
+	return NewDecimal(i, 0)
+}
+
+// NewDecimalFromFloat returns the shortest Decimal which converts back to
+// `f`. NaN and infinities are converted to zero.
+func NewDecimalFromFloat(f float64) Decimal {
+	if math.IsNaN(f) || math.IsInf(f, 0) {
+		return Decimal{}
+	}
+
+	d, _ := ParseDecimal(strconv.FormatFloat(f, 'f', -1, 64))
+	return d
+}
+
+// ParseDecimal parses a decimal number such as "-12.345". Exponents such as
+// "1.5e-8" are accepted, although the API never sends them.
+func ParseDecimal(s string) (Decimal, error) {
+	invalid := func() (Decimal, error) {
+		return Decimal{}, errors.New("invalid decimal", j.KV("value", s))
+	}
+
+	num := s
+	var exp int64
+	if i := strings.IndexAny(num, "eE"); i != -1 {
+		var err error
+		exp, err = strconv.ParseInt(num[i+1:], 10, 32)
+		if err != nil {
+			return invalid()
+		}
+		num = num[:i]
+	}
+
+	var neg bool
+	if num != "" && (num[0] == '-' || num[0] == '+') {
+		neg = num[0] == '-'
+		num = num[1:]
+	}
+
+	whole, frac := num, ""
+	if i := strings.IndexByte(num, '.'); i != -1 {
+		whole, frac = num[:i], num[i+1:]
+	}
+
+	digits := whole + frac
+	if digits == "" {
+		return invalid()
+	}
+	for _, c := range digits {
+		if c < '0' || c > '9' {
+			return invalid()
+		}
+	}
+
+	unscaled, ok := new(big.Int).SetString(digits, 10)
+	if !ok {
+		return invalid()
+	}
+
+	if neg {
+		unscaled.Neg(unscaled)
+	}
+
+	scale := int64(len(frac)) - exp
+	if scale > math.MaxInt32 || scale < math.MinInt32 {
+		return invalid()
+	}
+
+	return newDecimal(unscaled, int32(scale)), nil
+}
+
+// MustParseDecimal is like ParseDecimal, but panics if `s` is invalid. It is
+// intended for constants.
+func MustParseDecimal(s string) Decimal {
+	d, err := ParseDecimal(s)
+	if err != nil {
+		panic(err)
+	}
+	return d
+}
+
+// int returns the unscaled value, treating nil as zero.
+func (d Decimal) int() *big.Int {
+	if d.unscaled == nil {
+		return new(big.Int)
+	}
+	return d.unscaled
+}
+
+// rescale returns the unscaled value of `d` at a larger `scale`.
+func (d Decimal) rescale(scale int32) *big.Int {
+	if scale == d.scale {
+		return d.int()
+	}
+	return new(big.Int).Mul(d.int(), pow10(scale-d.scale))
+}
+
+// align returns the unscaled values of `d` and `o` at a common scale.
+func (d Decimal) align(o Decimal) (*big.Int, *big.Int, int32) {
+	scale := d.scale
+	if o.scale > scale {
+		scale = o.scale
+	}
+	return d.rescale(scale), o.rescale(scale), scale
+}
+
+// Scale returns the number of digits after the decimal point.
+func (d Decimal) Scale() int32 {
+	return d.scale
+}
+
+// String returns the decimal without an exponent, for example "0.00012300".
+// Trailing zeros are kept.
+func (d Decimal) String() string {
+	digits := new(big.Int).Abs(d.int()).String()
+	if d.scale > 0 {
+		if pad := int(d.scale) + 1 - len(digits); pad > 0 {
+			digits = strings.Repeat("0", pad) + digits
+		}
+		i := len(digits) - int(d.scale)
+		digits = digits[:i] + "." + digits[i:]
+	}
+
+	if d.Sign() < 0 {
+		return "-" + digits
+	}
+	return digits
+}
+
+// Float64 returns the nearest float64 to `d`.
+func (d Decimal) Float64() float64 {
+	f, _ := strconv.ParseFloat(d.String(), 64)
+	return f
+}
+
+// IsZero returns whether `d` is zero.
+func (d Decimal) IsZero() bool {
+	return d.Sign() == 0
+}
+
+// Sign returns -1, 0 or 1 depending on whether `d` is negative, zero or
+// positive.
+func (d Decimal) Sign() int {
+	return d.int().Sign()
+}
+
+// Cmp returns -1, 0 or 1 depending on whether `d` is less than, equal to or
+// greater than `o`.
+func (d Decimal) Cmp(o Decimal) int {
+	a, b, _ := d.align(o)
+	return a.Cmp(b)
+}
+
+// Equal returns whether `d` and `o` have the same value, regardless of their
+// scales.
+func (d Decimal) Equal(o Decimal) bool {
+	return d.Cmp(o) == 0
+}
+
+// LessThan returns whether `d` is less than `o`.
+func (d Decimal) LessThan(o Decimal) bool {
+	return d.Cmp(o) < 0
+}
+
+// GreaterThan returns whether `d` is greater than `o`.
+func (d Decimal) GreaterThan(o Decimal) bool {
+	return d.Cmp(o) > 0
+}
+
+// Add returns d + o.
+func (d Decimal) Add(o Decimal) Decimal {
+	a, b, scale := d.align(o)
+	return Decimal{unscaled: new(big.Int).Add(a, b), scale: scale}
+}
+
+// Sub returns d - o.
+func (d Decimal) Sub(o Decimal) Decimal {
+	a, b, scale := d.align(o)
+	return Decimal{unscaled: new(big.Int).Sub(a, b), scale: scale}
+}
+
+// Mul returns d * o.
+func (d Decimal) Mul(o Decimal) Decimal {
+	return Decimal{
+		unscaled: new(big.Int).Mul(d.int(), o.int()),
+		scale:    d.scale + o.scale,
+	}
+}
+
+// Div returns d / o with `scale` digits after the decimal point, truncated
+// towards zero. It panics if `o` is zero.
+func (d Decimal) Div(o Decimal, scale int32) Decimal {
+	if o.IsZero() {
+		panic("binance: decimal division by zero")
+	}
+
+	// d / o = (ud * 10^so) / (uo * 10^sd), which is scaled up by 10^scale.
+	num := new(big.Int).Mul(d.int(), pow10(o.scale))
+	den := new(big.Int).Mul(o.int(), pow10(d.scale))
+	if scale >= 0 {
+		num.Mul(num, pow10(scale))
+	} else {
+		den.Mul(den, pow10(-scale))
+	}
+
+	return newDecimal(num.Quo(num, den), scale)
+}
+
+// Neg returns -d.
+func (d Decimal) Neg() Decimal {
+	return Decimal{unscaled: new(big.Int).Neg(d.int()), scale: d.scale}
+}
+
+// Abs returns the absolute value of `d`.
+func (d Decimal) Abs() Decimal {
+	return Decimal{unscaled: new(big.Int).Abs(d.int()), scale: d.scale}
+}
+
+// Truncate returns `d` with at most `scale` digits after the decimal point,
+// discarding the rest.
+func (d Decimal) Truncate(scale int32) Decimal {
+	if scale < 0 {
+		scale = 0
+	}
+
+	if scale >= d.scale {
+		return d
+	}
+
+	unscaled := new(big.Int).Quo(d.int(), pow10(d.scale-scale))
+	return Decimal{unscaled: unscaled, scale: scale}
+}
+
+// quoRem returns the quotient of `d` divided by `step`, rounded towards
+// negative infinity, and whether there was a remainder. `step` must be
+// positive.
+func (d Decimal) quoRem(step Decimal) (*big.Int, bool) {
+	a, b, _ := d.align(step)
+	q, m := new(big.Int).DivMod(a, b, new(big.Int))
+	return q, m.Sign() != 0
+}
+
+// IsMultipleOf returns whether `d` is a whole multiple of `step`. Every
+// Decimal is a multiple of a non-positive step.
+func (d Decimal) IsMultipleOf(step Decimal) bool {
+	if step.Sign() <= 0 {
+		return true
+	}
+
+	_, rem := d.quoRem(step)
+	return !rem
+}
+
+// RoundDown returns the largest multiple of `step` which is less than or
+// equal to `d`, with the same scale as `step`. For example, 1.2345 rounded
+// down to a step of 0.01 is 1.23. `d` is returned unchanged if `step` is not
+// positive.
+func (d Decimal) RoundDown(step Decimal) Decimal {
+	if step.Sign() <= 0 {
+		return d
+	}
+
+	q, _ := d.quoRem(step)
+	return Decimal{unscaled: q}.Mul(step)
+}
+
+// RoundUp returns the smallest multiple of `step` which is greater than or
+// equal to `d`, with the same scale as `step`. For example, 1.2345 rounded up
+// to a step of 0.01 is 1.24. `d` is returned unchanged if `step` is not
+// positive.
+func (d Decimal) RoundUp(step Decimal) Decimal {
+	if step.Sign() <= 0 {
+		return d
+	}
+
+	q, rem := d.quoRem(step)
+	if rem {
+		q.Add(q, big.NewInt(1))
+	}
+	return Decimal{unscaled: q}.Mul(step)
+}
+
+// MarshalText satisfies the encoding.TextMarshaler interface.
+func (d Decimal) MarshalText() ([]byte, error) {
+	return []byte(d.String()), nil
+}
+
+// UnmarshalText satisfies the encoding.TextUnmarshaler interface.
+func (d *Decimal) UnmarshalText(text []byte) error {
+	parsed, err := ParseDecimal(string(text))
+	if err != nil {
+		return err
+	}
+
+	*d = parsed
+	return nil
+}
+
+// MarshalJSON satisfies the json.Marshaler interface. Decimals are encoded as
+// strings, as the API does.
+func (d Decimal) MarshalJSON() ([]byte, error) {
+	return json.Marshal(d.String())
+}
+
+// UnmarshalJSON satisfies the json.Unmarshaler interface. Both strings and
+// numbers are accepted, and an empty string is decoded as zero.
+func (d *Decimal) UnmarshalJSON(data []byte) error {
+	if bytes.Equal(data, []byte("null")) {
+		return nil
+	}
+
+	text := data
+	if len(data) > 0 && data[0] == '"' {
+		var s string
+		if err := json.Unmarshal(data, &s); err != nil {
+			return err
+		}
+
+		if s == "" {
+			*d = Decimal{}
+			return nil
+		}
+		text = []byte(s)
+	}
+
+	return d.UnmarshalText(text)
+}
+
+// registerDecimalEncoder registers Decimal with `e`, so that request structs
+// can contain Decimals.
+func registerDecimalEncoder(e *schema.Encoder) {
+	e.RegisterEncoder(Decimal{}, func(v reflect.Value) string {
+		return v.Interface().(Decimal).String()
+	})
+}
@@ -0,0 +1,170 @@
+package binance
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/url"
+	"testing"
+
+	"github.com/gorilla/schema"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseDecimal(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected string
+		valid    bool
+	}{
+		{"0", "0", true},
+		{"12", "12", true},
+		{"-12.345", "-12.345", true},
+		{"+1.5", "1.5", true},
+		{"0.00012300", "0.00012300", true},
+		{".5", "0.5", true},
+		{"5.", "5", true},
+		{"1.5e-8", "0.000000015", true},
+		{"1.5E3", "1500", true},
+		{"123456789012345678901234567890.000000001",
+			"123456789012345678901234567890.000000001", true},
+		{"", "", false},
+		{"-", "", false},
+		{".", "", false},
+		{"1.2.3", "", false},
+		{"1,5", "", false},
+		{"1e", "", false},
+		{"NaN", "", false},
+	}
+
+	for i, test := range tests {
+		t.Run(fmt.Sprintf("%d", i), func(t *testing.T) {
+			d, err := ParseDecimal(test.input)
+			if !test.valid {
+				require.Error(t, err)
+				return
+			}
+
+			require.NoError(t, err)
+			require.Equal(t, test.expected, d.String())
+		})
+	}
+}
+
+func TestDecimal_Arithmetic(t *testing.T) {
+	a := MustParseDecimal("0.1")
+	b := MustParseDecimal("0.2")
+
+	require.Equal(t, "0.3", a.Add(b).String())
+	require.Equal(t, "-0.1", a.Sub(b).String())
+	require.Equal(t, "0.02", a.Mul(b).String())
+	require.Equal(t, "0.50", a.Div(b, 2).String())
+	require.Equal(t, "3.333", NewDecimalFromInt(10).Div(
+		NewDecimalFromInt(3), 3).String())
+	require.Equal(t, "-3.333", NewDecimalFromInt(-10).Div(
+		NewDecimalFromInt(3), 3).String())
+	require.Equal(t, "1200", NewDecimalFromInt(12345).Div(
+		NewDecimalFromInt(10), -2).String())
+	require.Equal(t, "0.1", b.Neg().Add(b).Add(a).String())
+	require.Equal(t, "0.2", b.Neg().Abs().String())
+	require.Equal(t, "1.23", MustParseDecimal("1.23456").Truncate(2).String())
+	require.Equal(t, "-1.23", MustParseDecimal("-1.23956").Truncate(2).String())
+	require.Equal(t, "1.2", MustParseDecimal("1.2").Truncate(4).String())
+
+	require.Panics(t, func() {
+		a.Div(Decimal{}, 2)
+	})
+
+	// The zero value is usable.
+	var zero Decimal
+	require.True(t, zero.IsZero())
+	require.Equal(t, "0", zero.String())
+	require.Equal(t, "0.1", zero.Add(a).String())
+}
+
+func TestDecimal_Cmp(t *testing.T) {
+	require.True(t, MustParseDecimal("1.10").Equal(MustParseDecimal("1.1")))
+	require.True(t, MustParseDecimal("0.00").Equal(Decimal{}))
+	require.True(t, MustParseDecimal("0.00").IsZero())
+	require.True(t, MustParseDecimal("1.09").LessThan(MustParseDecimal("1.1")))
+	require.True(t, MustParseDecimal("-1").LessThan(Decimal{}))
+	require.True(t, MustParseDecimal("2").GreaterThan(MustParseDecimal("1.99")))
+	require.Equal(t, 0, NewDecimal(150, 2).Cmp(MustParseDecimal("1.5")))
+	require.Equal(t, -1, MustParseDecimal("-0.5").Sign())
+}
+
+func TestDecimal_Round(t *testing.T) {
+	tests := []struct {
+		value string
+		step  string
+		down  string
+		up    string
+	}{
+		{"1.2345", "0.01", "1.23", "1.24"},
+		{"1.23", "0.01000000", "1.23000000", "1.23000000"},
+		{"-1.2345", "0.01", "-1.24", "-1.23"},
+		{"17", "5", "15", "20"},
+		{"0.00004", "0.0001", "0.0000", "0.0001"},
+		{"1.2345", "0", "1.2345", "1.2345"},
+	}
+
+	for i, test := range tests {
+		t.Run(fmt.Sprintf("%d", i), func(t *testing.T) {
+			value := MustParseDecimal(test.value)
+			step := MustParseDecimal(test.step)
+			require.Equal(t, test.down, value.RoundDown(step).String())
+			require.Equal(t, test.up, value.RoundUp(step).String())
+			require.Equal(t, test.down == test.up, value.IsMultipleOf(step))
+		})
+	}
+}
+
+func TestDecimal_FromFloat(t *testing.T) {
+	require.Equal(t, "0.1", NewDecimalFromFloat(0.1).String())
+	require.Equal(t, "0.00000001", NewDecimalFromFloat(1e-8).String())
+	require.Equal(t, "120000000000", NewDecimalFromFloat(1.2e11).String())
+	require.Equal(t, 0.1, MustParseDecimal("0.1").Float64())
+}
+
+func TestDecimal_JSON(t *testing.T) {
+	var v struct {
+		A Decimal `json:"a"`
+		B Decimal `json:"b"`
+		C Decimal `json:"c"`
+		D Decimal `json:"d"`
+	}
+
+	err := json.Unmarshal([]byte(
+		`{"a":"0.00100000","b":12.5,"c":"","d":null}`), &v)
+	require.NoError(t, err)
+	require.Equal(t, "0.00100000", v.A.String())
+	require.Equal(t, "12.5", v.B.String())
+	require.True(t, v.C.IsZero())
+	require.True(t, v.D.IsZero())
+
+	b, err := json.Marshal(v)
+	require.NoError(t, err)
+	require.Equal(t, `{"a":"0.00100000","b":"12.5","c":"0","d":"0"}`,
+		string(b))
+
+	err = json.Unmarshal([]byte(`{"a":"abc"}`), &v)
+	require.Error(t, err)
+}
+
+func TestDecimal_Schema(t *testing.T) {
+	type request struct {
+		Price    Decimal `schema:"price,omitempty"`
+		Quantity Decimal `schema:"quantity"`
+		Stop     Decimal `schema:"stopPrice,omitempty"`
+	}
+
+	encoder := schema.NewEncoder()
+	registerDecimalEncoder(encoder)
+
+	params := make(url.Values)
+	err := encoder.Encode(&request{
+		Price:    MustParseDecimal("0.00000123"),
+		Quantity: NewDecimalFromInt(100000000),
+	}, params)
+	require.NoError(t, err)
+	require.Equal(t, "price=0.00000123&quantity=100000000", params.Encode())
+}
@@ -1,5 +1,7 @@
 // Package binance provides an HTTP Client implementation for the Binance REST
 // API.
 //
-// It records system metrics using Prometheus by default.
+// It records system metrics using Prometheus by default, registered with
+// prometheus.DefaultRegisterer. Use WithMetrics to register them elsewhere.
+// Requests can also be traced with OpenTelemetry using WithTracerProvider.
 package binance
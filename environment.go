@@ -0,0 +1,56 @@
+package binance
+
+// Environment contains the endpoints of a Binance deployment. Using the same
+// Environment for a Client and its streams ensures that they all connect to
+// the same deployment.
+type Environment struct {
+	// Name represents the name of the environment, for use in logs.
+	Name string
+
+	// RESTURL represents the base URL of the REST API, including its version.
+	RESTURL string
+
+	// StreamURL represents the base URL of the WebSocket market and user
+	// data streams.
+	StreamURL string
+
+	// WebSocketAPIURL represents the URL of the WebSocket API.
+	WebSocketAPIURL string
+}
+
+// Environments provided by Binance.
+var (
+	// Production is the live exchange.
+	Production = Environment{
+		Name:            "production",
+		RESTURL:         "https://api.binance.com/api/v3",
+		StreamURL:       "wss://stream.binance.com:9443",
+		WebSocketAPIURL: "wss://ws-api.binance.com:443/ws-api/v3",
+	}
+
+	// ProductionAPI1 to ProductionAPI4 are the live exchange, using
+	// alternative REST API clusters which may perform better than the
+	// default cluster, but may be less stable.
+	ProductionAPI1 = productionCluster("api1")
+	ProductionAPI2 = productionCluster("api2")
+	ProductionAPI3 = productionCluster("api3")
+	ProductionAPI4 = productionCluster("api4")
+
+	// Testnet is the spot test network, which trades with test funds. API
+	// keys for it are created at https://testnet.binance.vision.
+	Testnet = Environment{
+		Name:            "testnet",
+		RESTURL:         "https://testnet.binance.vision/api/v3",
+		StreamURL:       "wss://stream.testnet.binance.vision",
+		WebSocketAPIURL: "wss://ws-api.testnet.binance.vision/ws-api/v3",
+	}
+)
+
+// productionCluster returns the Production environment using the REST API
+// cluster `host`.
+func productionCluster(host string) Environment {
+	env := Production
+	env.Name = "production-" + host
+	env.RESTURL = "https://" + host + ".binance.com/api/v3"
+	return env
+}
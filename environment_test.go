@@ -0,0 +1,52 @@
+package binance
+
+import (
+	"context"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+type roundTripFunc func(*http.Request) (*http.Response, error)
+
+func (f roundTripFunc) RoundTrip(r *http.Request) (*http.Response, error) {
+	return f(r)
+}
+
+func TestWithEnvironment(t *testing.T) {
+	envs := []Environment{Production, ProductionAPI1, ProductionAPI2,
+		ProductionAPI3, ProductionAPI4, Testnet}
+
+	for i, env := range envs {
+		t.Run(fmt.Sprintf("%d", i), func(t *testing.T) {
+			for _, u := range []string{env.RESTURL, env.StreamURL,
+				env.WebSocketAPIURL} {
+				_, err := url.ParseRequestURI(u)
+				require.NoError(t, err)
+			}
+			require.True(t, strings.HasPrefix(env.StreamURL, "wss://"))
+			require.True(t, strings.HasPrefix(env.WebSocketAPIURL, "wss://"))
+
+			var requested string
+			transport := roundTripFunc(func(r *http.Request) (*http.Response,
+				error) {
+				requested = r.URL.String()
+				return &http.Response{
+					StatusCode: http.StatusOK,
+					Header:     make(http.Header),
+					Body:       ioutil.NopCloser(strings.NewReader("{}")),
+				}, nil
+			})
+
+			c := NewClient(WithEnvironment(env),
+				WithTransport(&http.Client{Transport: transport}))
+			require.NoError(t, c.Ping(context.Background()))
+			require.Equal(t, env.RESTURL+"/ping", requested)
+		})
+	}
+}
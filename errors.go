@@ -1,5 +1,7 @@
 package binance
 
+import "github.com/luno/jettison/errors"
+
 // Error defines the structured error that is returned in some reponses.
 //
 // More information regarding errors can be found in the error codes
@@ -31,6 +33,16 @@ func (e Error) IsAny(codes ...ErrorCode) bool {
 	return false
 }
 
+// IsError returns whether `err` is, or wraps, an Error with any of the given
+// codes.
+func IsError(err error, codes ...ErrorCode) bool {
+	var apiErr Error
+	if !errors.As(err, &apiErr) {
+		return false
+	}
+	return apiErr.IsAny(codes...)
+}
+
 // ErrorCode defines a more granular error type that can be returned from the
 // API.
 type ErrorCode int
@@ -42,6 +54,7 @@ var (
 	ErrTooManyRequests                ErrorCode = -1003
 	ErrUnexpectedResponse             ErrorCode = -1006
 	ErrTimeout                        ErrorCode = -1007
+	ErrInvalidMessage                 ErrorCode = -1013
 	ErrUnknownOrderComposition        ErrorCode = -1014
 	ErrTooManyOrders                  ErrorCode = -1015
 	ErrServiceShuttingDown            ErrorCode = -1016
@@ -76,3 +89,37 @@ var (
 	ErrRejectedMBXKey                 ErrorCode = -2015
 	ErrNoTradingWindow                ErrorCode = -2016
 )
+
+// ErrorCategory groups ErrorCodes by the kind of failure they describe.
+type ErrorCategory string
+
+const (
+	// ErrorCategoryServer contains general server or network issues, such as
+	// ErrDisconnected and ErrTimeout.
+	ErrorCategoryServer ErrorCategory = "server"
+
+	// ErrorCategoryRequest contains problems with the request, such as
+	// ErrBadSymbol and ErrMandatoryParamEmptyOrMalformed.
+	ErrorCategoryRequest ErrorCategory = "request"
+
+	// ErrorCategoryProcessing contains failures to act on a valid request,
+	// such as ErrNewOrderRejected and ErrNoSuchOrder.
+	ErrorCategoryProcessing ErrorCategory = "processing"
+
+	// ErrorCategoryUnknown contains codes outside of the documented ranges.
+	ErrorCategoryUnknown ErrorCategory = "unknown"
+)
+
+// Category returns the category of `code`, according to the range it is in.
+func (code ErrorCode) Category() ErrorCategory {
+	switch {
+	case code <= -1000 && code > -1100:
+		return ErrorCategoryServer
+	case code <= -1100 && code > -1200:
+		return ErrorCategoryRequest
+	case code <= -2000 && code > -2100:
+		return ErrorCategoryProcessing
+	default:
+		return ErrorCategoryUnknown
+	}
+}
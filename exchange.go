@@ -0,0 +1,660 @@
+package binance
+
+import (
+	"context"
+	"encoding/json"
+	"strings"
+
+	"github.com/luno/jettison/errors"
+	"github.com/luno/jettison/j"
+)
+
+// RateLimitType describes what a RateLimit is counting.
+type RateLimitType string
+
+// Enumerated types for RateLimitType.
+const (
+	RateLimitTypeRequestWeight RateLimitType = "REQUEST_WEIGHT"
+	RateLimitTypeOrders        RateLimitType = "ORDERS"
+	RateLimitTypeRawRequests   RateLimitType = "RAW_REQUESTS"
+)
+
+// RateLimitInterval describes the unit of time a RateLimit is measured over.
+type RateLimitInterval string
+
+// Enumerated types for RateLimitInterval.
+const (
+	RateLimitIntervalSecond RateLimitInterval = "SECOND"
+	RateLimitIntervalMinute RateLimitInterval = "MINUTE"
+	RateLimitIntervalDay    RateLimitInterval = "DAY"
+)
+
+// RateLimit describes a limit enforced by the exchange.
+type RateLimit struct {
+	// Interval represents the unit of time that the limit is measured over.
+	Interval RateLimitInterval `json:"interval"`
+
+	// IntervalNum represents the number of Intervals the limit is measured
+	// over. For example, an Interval of MINUTE with an IntervalNum of 5
+	// represents a five minute window.
+	IntervalNum int `json:"intervalNum"`
+
+	// Limit represents the maximum allowed within the window.
+	Limit int `json:"limit"`
+
+	// RateLimitType represents what is being counted.
+	RateLimitType RateLimitType `json:"rateLimitType"`
+}
+
+// SymbolStatus describes the trading state of a symbol.
+type SymbolStatus string
+
+// Enumerated types for SymbolStatus.
+const (
+	SymbolStatusPreTrading   SymbolStatus = "PRE_TRADING"
+	SymbolStatusTrading      SymbolStatus = "TRADING"
+	SymbolStatusPostTrading  SymbolStatus = "POST_TRADING"
+	SymbolStatusEndOfDay     SymbolStatus = "END_OF_DAY"
+	SymbolStatusHalt         SymbolStatus = "HALT"
+	SymbolStatusAuctionMatch SymbolStatus = "AUCTION_MATCH"
+	SymbolStatusBreak        SymbolStatus = "BREAK"
+)
+
+// ExchangeInfo contains the current trading rules of the exchange and
+// information about every symbol.
+type ExchangeInfo struct {
+	// ExchangeFilters contains the filters applied across the whole exchange.
+	ExchangeFilters Filters `json:"exchangeFilters"`
+
+	// RateLimits contains the limits enforced on requests and orders.
+	RateLimits []RateLimit `json:"rateLimits"`
+
+	// ServerTime represents the unix timestamp in milliseconds at which the
+	// response was generated.
+	ServerTime int64 `json:"serverTime"`
+
+	// Symbols contains the trading rules of every symbol.
+	Symbols []SymbolInfo `json:"symbols"`
+
+	// Timezone represents the timezone of the exchange's timestamps.
+	Timezone string `json:"timezone"`
+}
+
+// FindSymbol returns the SymbolInfo for `symbol`, and whether it was found.
+func (info *ExchangeInfo) FindSymbol(symbol string) (*SymbolInfo, bool) {
+	for i := range info.Symbols {
+		if strings.EqualFold(info.Symbols[i].Symbol, symbol) {
+			return &info.Symbols[i], true
+		}
+	}
+	return nil, false
+}
+
+// SymbolInfo contains the trading rules of a single symbol.
+type SymbolInfo struct {
+	// BaseAsset represents the asset being bought or sold.
+	BaseAsset string `json:"baseAsset"`
+
+	// BaseAssetPrecision represents the number of decimal places supported
+	// for the base asset.
+	BaseAssetPrecision int `json:"baseAssetPrecision"`
+
+	// BaseCommissionPrecision represents the number of decimal places used
+	// for commission paid in the base asset.
+	BaseCommissionPrecision int `json:"baseCommissionPrecision"`
+
+	// Filters contains the rules that orders on the symbol must satisfy.
+	Filters Filters `json:"filters"`
+
+	// IcebergAllowed represents whether iceberg orders may be placed.
+	IcebergAllowed bool `json:"icebergAllowed"`
+
+	// IsMarginTradingAllowed represents whether the symbol may be traded on
+	// margin.
+	IsMarginTradingAllowed bool `json:"isMarginTradingAllowed"`
+
+	// IsSpotTradingAllowed represents whether the symbol may be traded on
+	// the spot market.
+	IsSpotTradingAllowed bool `json:"isSpotTradingAllowed"`
+
+	// OCOAllowed represents whether OCO order lists may be placed.
+	OCOAllowed bool `json:"ocoAllowed"`
+
+	// OrderTypes contains the types of orders that may be placed.
+	OrderTypes []OrderType `json:"orderTypes"`
+
+	// Permissions contains the account types that may trade the symbol.
+	Permissions []string `json:"permissions"`
+
+	// QuoteAsset represents the asset used to price the base asset.
+	QuoteAsset string `json:"quoteAsset"`
+
+	// QuoteAssetPrecision represents the number of decimal places supported
+	// for the quote asset.
+	QuoteAssetPrecision int `json:"quoteAssetPrecision"`
+
+	// QuoteCommissionPrecision represents the number of decimal places used
+	// for commission paid in the quote asset.
+	QuoteCommissionPrecision int `json:"quoteCommissionPrecision"`
+
+	// QuoteOrderQtyMarketAllowed represents whether MARKET orders may be
+	// placed using QuoteOrderQty.
+	QuoteOrderQtyMarketAllowed bool `json:"quoteOrderQtyMarketAllowed"`
+
+	// QuotePrecision represents the number of decimal places supported for
+	// prices.
+	QuotePrecision int `json:"quotePrecision"`
+
+	// Status represents the current trading state of the symbol.
+	Status SymbolStatus `json:"status"`
+
+	// Symbol represents the market.
+	Symbol string `json:"symbol"`
+}
+
+// AllowsOrderType returns whether orders of type `t` may be placed on the
+// symbol.
+func (s *SymbolInfo) AllowsOrderType(t OrderType) bool {
+	for _, ot := range s.OrderTypes {
+		if ot == t {
+			return true
+		}
+	}
+	return false
+}
+
+// FilterType describes the rule that a Filter enforces.
+type FilterType string
+
+// Enumerated types for FilterType.
+const (
+	FilterTypePrice               FilterType = "PRICE_FILTER"
+	FilterTypePercentPrice        FilterType = "PERCENT_PRICE"
+	FilterTypePercentPriceBySide  FilterType = "PERCENT_PRICE_BY_SIDE"
+	FilterTypeLotSize             FilterType = "LOT_SIZE"
+	FilterTypeMarketLotSize       FilterType = "MARKET_LOT_SIZE"
+	FilterTypeMinNotional         FilterType = "MIN_NOTIONAL"
+	FilterTypeNotional            FilterType = "NOTIONAL"
+	FilterTypeIcebergParts        FilterType = "ICEBERG_PARTS"
+	FilterTypeMaxNumOrders        FilterType = "MAX_NUM_ORDERS"
+	FilterTypeMaxNumAlgoOrders    FilterType = "MAX_NUM_ALGO_ORDERS"
+	FilterTypeMaxNumIcebergOrders FilterType = "MAX_NUM_ICEBERG_ORDERS"
+	FilterTypeMaxPosition         FilterType = "MAX_POSITION"
+	FilterTypeTrailingDelta       FilterType = "TRAILING_DELTA"
+
+	FilterTypeExchangeMaxNumOrders     FilterType = "EXCHANGE_MAX_NUM_ORDERS"
+	FilterTypeExchangeMaxNumAlgoOrders FilterType = "EXCHANGE_MAX_NUM_ALGO_ORDERS"
+)
+
+// Filter is a trading rule defined by the exchange. Use a type switch or one
+// of the Filters accessors to get at the concrete filter.
+type Filter interface {
+	FilterType() FilterType
+}
+
+// PriceFilter defines the price rules for a symbol.
+type PriceFilter struct {
+	// MaxPrice represents the maximum price allowed. Disabled when zero.
+	MaxPrice Decimal `json:"maxPrice"`
+
+	// MinPrice represents the minimum price allowed. Disabled when zero.
+	MinPrice Decimal `json:"minPrice"`
+
+	// TickSize represents the interval that a price must be a multiple of.
+	// Disabled when zero.
+	TickSize Decimal `json:"tickSize"`
+}
+
+// FilterType satisfies the Filter interface.
+func (*PriceFilter) FilterType() FilterType { return FilterTypePrice }
+
+// PercentPriceFilter defines the valid range of a price relative to the
+// average price over the previous AvgPriceMins minutes.
+type PercentPriceFilter struct {
+	// AvgPriceMins represents the number of minutes the average price is
+	// calculated over. Zero means the last price is used.
+	AvgPriceMins int `json:"avgPriceMins"`
+
+	// MultiplierDown represents the lowest price allowed as a multiple of
+	// the average price.
+	MultiplierDown Decimal `json:"multiplierDown"`
+
+	// MultiplierUp represents the highest price allowed as a multiple of the
+	// average price.
+	MultiplierUp Decimal `json:"multiplierUp"`
+}
+
+// FilterType satisfies the Filter interface.
+func (*PercentPriceFilter) FilterType() FilterType {
+	return FilterTypePercentPrice
+}
+
+// PercentPriceBySideFilter defines the valid range of a price relative to the
+// average price, with separate bounds for bids and asks.
+type PercentPriceBySideFilter struct {
+	AskMultiplierDown Decimal `json:"askMultiplierDown"`
+	AskMultiplierUp   Decimal `json:"askMultiplierUp"`
+	AvgPriceMins      int     `json:"avgPriceMins"`
+	BidMultiplierDown Decimal `json:"bidMultiplierDown"`
+	BidMultiplierUp   Decimal `json:"bidMultiplierUp"`
+}
+
+// FilterType satisfies the Filter interface.
+func (*PercentPriceBySideFilter) FilterType() FilterType {
+	return FilterTypePercentPriceBySide
+}
+
+// LotSizeFilter defines the quantity rules for a symbol.
+type LotSizeFilter struct {
+	// MaxQty represents the maximum quantity allowed.
+	MaxQty Decimal `json:"maxQty"`
+
+	// MinQty represents the minimum quantity allowed.
+	MinQty Decimal `json:"minQty"`
+
+	// StepSize represents the interval that a quantity must be a multiple
+	// of.
+	StepSize Decimal `json:"stepSize"`
+}
+
+// FilterType satisfies the Filter interface.
+func (*LotSizeFilter) FilterType() FilterType { return FilterTypeLotSize }
+
+// MarketLotSizeFilter defines the quantity rules for MARKET orders on a
+// symbol.
+type MarketLotSizeFilter struct {
+	// MaxQty represents the maximum quantity allowed.
+	MaxQty Decimal `json:"maxQty"`
+
+	// MinQty represents the minimum quantity allowed.
+	MinQty Decimal `json:"minQty"`
+
+	// StepSize represents the interval that a quantity must be a multiple
+	// of.
+	StepSize Decimal `json:"stepSize"`
+}
+
+// FilterType satisfies the Filter interface.
+func (*MarketLotSizeFilter) FilterType() FilterType {
+	return FilterTypeMarketLotSize
+}
+
+// MinNotionalFilter defines the minimum notional value (price * quantity)
+// allowed for an order.
+type MinNotionalFilter struct {
+	// ApplyToMarket represents whether the filter applies to MARKET orders.
+	ApplyToMarket bool `json:"applyToMarket"`
+
+	// AvgPriceMins represents the number of minutes the average price is
+	// calculated over when checking MARKET orders.
+	AvgPriceMins int `json:"avgPriceMins"`
+
+	// MinNotional represents the minimum notional value allowed.
+	MinNotional Decimal `json:"minNotional"`
+}
+
+// FilterType satisfies the Filter interface.
+func (*MinNotionalFilter) FilterType() FilterType {
+	return FilterTypeMinNotional
+}
+
+// NotionalFilter defines the range of notional values (price * quantity)
+// allowed for an order. It supersedes MinNotionalFilter.
+type NotionalFilter struct {
+	// ApplyMaxToMarket represents whether MaxNotional applies to MARKET
+	// orders.
+	ApplyMaxToMarket bool `json:"applyMaxToMarket"`
+
+	// ApplyMinToMarket represents whether MinNotional applies to MARKET
+	// orders.
+	ApplyMinToMarket bool `json:"applyMinToMarket"`
+
+	// AvgPriceMins represents the number of minutes the average price is
+	// calculated over when checking MARKET orders.
+	AvgPriceMins int `json:"avgPriceMins"`
+
+	// MaxNotional represents the maximum notional value allowed.
+	MaxNotional Decimal `json:"maxNotional"`
+
+	// MinNotional represents the minimum notional value allowed.
+	MinNotional Decimal `json:"minNotional"`
+}
+
+// FilterType satisfies the Filter interface.
+func (*NotionalFilter) FilterType() FilterType { return FilterTypeNotional }
+
+// IcebergPartsFilter defines the maximum number of parts an iceberg order may
+// be split into.
+type IcebergPartsFilter struct {
+	Limit int `json:"limit"`
+}
+
+// FilterType satisfies the Filter interface.
+func (*IcebergPartsFilter) FilterType() FilterType {
+	return FilterTypeIcebergParts
+}
+
+// MaxNumOrdersFilter defines the maximum number of open orders an account may
+// have on a symbol.
+type MaxNumOrdersFilter struct {
+	MaxNumOrders int `json:"maxNumOrders"`
+}
+
+// FilterType satisfies the Filter interface.
+func (*MaxNumOrdersFilter) FilterType() FilterType {
+	return FilterTypeMaxNumOrders
+}
+
+// MaxNumAlgoOrdersFilter defines the maximum number of open STOP_LOSS,
+// STOP_LOSS_LIMIT, TAKE_PROFIT and TAKE_PROFIT_LIMIT orders an account may
+// have on a symbol.
+type MaxNumAlgoOrdersFilter struct {
+	MaxNumAlgoOrders int `json:"maxNumAlgoOrders"`
+}
+
+// FilterType satisfies the Filter interface.
+func (*MaxNumAlgoOrdersFilter) FilterType() FilterType {
+	return FilterTypeMaxNumAlgoOrders
+}
+
+// MaxNumIcebergOrdersFilter defines the maximum number of open iceberg orders
+// an account may have on a symbol.
+type MaxNumIcebergOrdersFilter struct {
+	MaxNumIcebergOrders int `json:"maxNumIcebergOrders"`
+}
+
+// FilterType satisfies the Filter interface.
+func (*MaxNumIcebergOrdersFilter) FilterType() FilterType {
+	return FilterTypeMaxNumIcebergOrders
+}
+
+// MaxPositionFilter defines the maximum position an account may hold in the
+// base asset of a symbol, including open buy orders.
+type MaxPositionFilter struct {
+	MaxPosition Decimal `json:"maxPosition"`
+}
+
+// FilterType satisfies the Filter interface.
+func (*MaxPositionFilter) FilterType() FilterType {
+	return FilterTypeMaxPosition
+}
+
+// TrailingDeltaFilter defines the range of trailing deltas, in basis points,
+// allowed for trailing stop orders.
+type TrailingDeltaFilter struct {
+	MaxTrailingAboveDelta int `json:"maxTrailingAboveDelta"`
+	MaxTrailingBelowDelta int `json:"maxTrailingBelowDelta"`
+	MinTrailingAboveDelta int `json:"minTrailingAboveDelta"`
+	MinTrailingBelowDelta int `json:"minTrailingBelowDelta"`
+}
+
+// FilterType satisfies the Filter interface.
+func (*TrailingDeltaFilter) FilterType() FilterType {
+	return FilterTypeTrailingDelta
+}
+
+// ExchangeMaxNumOrdersFilter defines the maximum number of open orders an
+// account may have across the exchange.
+type ExchangeMaxNumOrdersFilter struct {
+	MaxNumOrders int `json:"maxNumOrders"`
+}
+
+// FilterType satisfies the Filter interface.
+func (*ExchangeMaxNumOrdersFilter) FilterType() FilterType {
+	return FilterTypeExchangeMaxNumOrders
+}
+
+// ExchangeMaxNumAlgoOrdersFilter defines the maximum number of open algo
+// orders an account may have across the exchange.
+type ExchangeMaxNumAlgoOrdersFilter struct {
+	MaxNumAlgoOrders int `json:"maxNumAlgoOrders"`
+}
+
+// FilterType satisfies the Filter interface.
+func (*ExchangeMaxNumAlgoOrdersFilter) FilterType() FilterType {
+	return FilterTypeExchangeMaxNumAlgoOrders
+}
+
+// UnknownFilter holds a filter type which this package does not yet
+// support, so that new filters don't break decoding.
+type UnknownFilter struct {
+	Raw  json.RawMessage
+	Type FilterType
+}
+
+// FilterType satisfies the Filter interface.
+func (f *UnknownFilter) FilterType() FilterType { return f.Type }
+
+// newFilter returns an empty concrete Filter for a given FilterType.
+func newFilter(t FilterType) Filter {
+	switch t {
+	case FilterTypePrice:
+		return new(PriceFilter)
+	case FilterTypePercentPrice:
+		return new(PercentPriceFilter)
+	case FilterTypePercentPriceBySide:
+		return new(PercentPriceBySideFilter)
+	case FilterTypeLotSize:
+		return new(LotSizeFilter)
+	case FilterTypeMarketLotSize:
+		return new(MarketLotSizeFilter)
+	case FilterTypeMinNotional:
+		return new(MinNotionalFilter)
+	case FilterTypeNotional:
+		return new(NotionalFilter)
+	case FilterTypeIcebergParts:
+		return new(IcebergPartsFilter)
+	case FilterTypeMaxNumOrders:
+		return new(MaxNumOrdersFilter)
+	case FilterTypeMaxNumAlgoOrders:
+		return new(MaxNumAlgoOrdersFilter)
+	case FilterTypeMaxNumIcebergOrders:
+		return new(MaxNumIcebergOrdersFilter)
+	case FilterTypeMaxPosition:
+		return new(MaxPositionFilter)
+	case FilterTypeTrailingDelta:
+		return new(TrailingDeltaFilter)
+	case FilterTypeExchangeMaxNumOrders:
+		return new(ExchangeMaxNumOrdersFilter)
+	case FilterTypeExchangeMaxNumAlgoOrders:
+		return new(ExchangeMaxNumAlgoOrdersFilter)
+	default:
+		return nil
+	}
+}
+
+// Filters is a list of trading rules which decodes each rule into its
+// concrete Filter type.
+type Filters []Filter
+
+// UnmarshalJSON satisfies the json.Unmarshaler interface for the Filters
+// type.
+func (f *Filters) UnmarshalJSON(data []byte) error {
+	var raw []json.RawMessage
+	if err := json.Unmarshal(data, &raw); err != nil {
+		return err
+	}
+
+	filters := make(Filters, 0, len(raw))
+	for _, r := range raw {
+		var header struct {
+			FilterType FilterType `json:"filterType"`
+		}
+		if err := json.Unmarshal(r, &header); err != nil {
+			return err
+		}
+
+		filter := newFilter(header.FilterType)
+		if filter == nil {
+			filters = append(filters, &UnknownFilter{
+				Raw:  append(json.RawMessage(nil), r...),
+				Type: header.FilterType,
+			})
+			continue
+		}
+
+		if err := json.Unmarshal(r, filter); err != nil {
+			return errors.Wrap(err, "failed to parse filter",
+				j.KV("filter_type", header.FilterType))
+		}
+		filters = append(filters, filter)
+	}
+
+	*f = filters
+	return nil
+}
+
+// MarshalJSON satisfies the json.Marshaler interface for the Filters type,
+// adding the filterType of each rule so that it can be decoded again.
+func (f Filters) MarshalJSON() ([]byte, error) {
+	raw := make([]json.RawMessage, 0, len(f))
+	for _, filter := range f {
+		if u, ok := filter.(*UnknownFilter); ok {
+			raw = append(raw, u.Raw)
+			continue
+		}
+
+		b, err := json.Marshal(filter)
+		if err != nil {
+			return nil, err
+		}
+
+		var fields map[string]json.RawMessage
+		if err := json.Unmarshal(b, &fields); err != nil {
+			return nil, err
+		}
+
+		fields["filterType"], err = json.Marshal(filter.FilterType())
+		if err != nil {
+			return nil, err
+		}
+
+		b, err = json.Marshal(fields)
+		if err != nil {
+			return nil, err
+		}
+		raw = append(raw, b)
+	}
+
+	return json.Marshal(raw)
+}
+
+// Get returns the first Filter of type `t`, and whether it was found.
+func (f Filters) Get(t FilterType) (Filter, bool) {
+	for _, filter := range f {
+		if filter.FilterType() == t {
+			return filter, true
+		}
+	}
+	return nil, false
+}
+
+// Price returns the PriceFilter, or nil if there isn't one.
+func (f Filters) Price() *PriceFilter {
+	filter, _ := f.Get(FilterTypePrice)
+	pf, _ := filter.(*PriceFilter)
+	return pf
+}
+
+// PercentPrice returns the PercentPriceFilter, or nil if there isn't one.
+func (f Filters) PercentPrice() *PercentPriceFilter {
+	filter, _ := f.Get(FilterTypePercentPrice)
+	pf, _ := filter.(*PercentPriceFilter)
+	return pf
+}
+
+// PercentPriceBySide returns the PercentPriceBySideFilter, or nil if there
+// isn't one.
+func (f Filters) PercentPriceBySide() *PercentPriceBySideFilter {
+	filter, _ := f.Get(FilterTypePercentPriceBySide)
+	pf, _ := filter.(*PercentPriceBySideFilter)
+	return pf
+}
+
+// LotSize returns the LotSizeFilter, or nil if there isn't one.
+func (f Filters) LotSize() *LotSizeFilter {
+	filter, _ := f.Get(FilterTypeLotSize)
+	lf, _ := filter.(*LotSizeFilter)
+	return lf
+}
+
+// MarketLotSize returns the MarketLotSizeFilter, or nil if there isn't one.
+func (f Filters) MarketLotSize() *MarketLotSizeFilter {
+	filter, _ := f.Get(FilterTypeMarketLotSize)
+	lf, _ := filter.(*MarketLotSizeFilter)
+	return lf
+}
+
+// MinNotional returns the MinNotionalFilter, or nil if there isn't one.
+func (f Filters) MinNotional() *MinNotionalFilter {
+	filter, _ := f.Get(FilterTypeMinNotional)
+	nf, _ := filter.(*MinNotionalFilter)
+	return nf
+}
+
+// Notional returns the NotionalFilter, or nil if there isn't one.
+func (f Filters) Notional() *NotionalFilter {
+	filter, _ := f.Get(FilterTypeNotional)
+	nf, _ := filter.(*NotionalFilter)
+	return nf
+}
+
+// IcebergParts returns the IcebergPartsFilter, or nil if there isn't one.
+func (f Filters) IcebergParts() *IcebergPartsFilter {
+	filter, _ := f.Get(FilterTypeIcebergParts)
+	ipf, _ := filter.(*IcebergPartsFilter)
+	return ipf
+}
+
+// MaxNumOrders returns the MaxNumOrdersFilter, or nil if there isn't one.
+func (f Filters) MaxNumOrders() *MaxNumOrdersFilter {
+	filter, _ := f.Get(FilterTypeMaxNumOrders)
+	mf, _ := filter.(*MaxNumOrdersFilter)
+	return mf
+}
+
+// MaxNumAlgoOrders returns the MaxNumAlgoOrdersFilter, or nil if there isn't
+// one.
+func (f Filters) MaxNumAlgoOrders() *MaxNumAlgoOrdersFilter {
+	filter, _ := f.Get(FilterTypeMaxNumAlgoOrders)
+	mf, _ := filter.(*MaxNumAlgoOrdersFilter)
+	return mf
+}
+
+// MaxNumIcebergOrders returns the MaxNumIcebergOrdersFilter, or nil if there
+// isn't one.
+func (f Filters) MaxNumIcebergOrders() *MaxNumIcebergOrdersFilter {
+	filter, _ := f.Get(FilterTypeMaxNumIcebergOrders)
+	mf, _ := filter.(*MaxNumIcebergOrdersFilter)
+	return mf
+}
+
+// MaxPosition returns the MaxPositionFilter, or nil if there isn't one.
+func (f Filters) MaxPosition() *MaxPositionFilter {
+	filter, _ := f.Get(FilterTypeMaxPosition)
+	mf, _ := filter.(*MaxPositionFilter)
+	return mf
+}
+
+// TrailingDelta returns the TrailingDeltaFilter, or nil if there isn't one.
+func (f Filters) TrailingDelta() *TrailingDeltaFilter {
+	filter, _ := f.Get(FilterTypeTrailingDelta)
+	tf, _ := filter.(*TrailingDeltaFilter)
+	return tf
+}
+
+// ExchangeInfo returns the current trading rules of the exchange and
+// information about every symbol.
+func (c *client) ExchangeInfo(ctx context.Context) (*ExchangeInfo, error) {
+	res, err := c.get(ctx, "/exchangeInfo")
+	if err != nil {
+		return nil, err
+	}
+
+	var info ExchangeInfo
+	if err = json.Unmarshal(res, &info); err != nil {
+		return nil, errors.Wrap(err, "failed to parse exchange info")
+	}
+
+	return &info, nil
+}
@@ -0,0 +1,68 @@
+package binance
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestExchangeInfo_OK(t *testing.T) {
+	srv, err := createTestServer(t, http.StatusOK)
+	require.NoError(t, err)
+	defer srv.Close()
+
+	c := NewClient(WithBaseURL(srv.URL))
+	info, err := c.ExchangeInfo(context.Background())
+	require.NoError(t, err)
+
+	require.Len(t, info.RateLimits, 3)
+	require.Equal(t, RateLimitTypeRequestWeight, info.RateLimits[0].RateLimitType)
+	require.Equal(t, 1200, info.RateLimits[0].Limit)
+
+	require.Len(t, info.ExchangeFilters, 1)
+	require.IsType(t, &ExchangeMaxNumOrdersFilter{}, info.ExchangeFilters[0])
+
+	symbol, ok := info.FindSymbol("ethbtc")
+	require.True(t, ok)
+	require.Equal(t, SymbolStatusTrading, symbol.Status)
+	require.Equal(t, "ETH", symbol.BaseAsset)
+	require.Equal(t, "BTC", symbol.QuoteAsset)
+	require.True(t, symbol.AllowsOrderType(OrderTypeLimitMaker))
+	require.False(t, symbol.AllowsOrderType(OrderTypeStopLoss))
+
+	require.Len(t, symbol.Filters, 12)
+	require.Equal(t, "0.00000100", symbol.Filters.Price().TickSize.String())
+	require.Equal(t, "0.2", symbol.Filters.PercentPrice().MultiplierDown.String())
+	require.Equal(t, "0.00100000", symbol.Filters.LotSize().StepSize.String())
+	require.Equal(t, "2000.00000000", symbol.Filters.MarketLotSize().MaxQty.String())
+	require.True(t, symbol.Filters.MinNotional().ApplyToMarket)
+	require.Equal(t, "9000000.00000000", symbol.Filters.Notional().MaxNotional.String())
+	require.Equal(t, 10, symbol.Filters.IcebergParts().Limit)
+	require.Equal(t, 200, symbol.Filters.MaxNumOrders().MaxNumOrders)
+	require.Equal(t, 5, symbol.Filters.MaxNumAlgoOrders().MaxNumAlgoOrders)
+	require.Equal(t, "10.00000000", symbol.Filters.MaxPosition().MaxPosition.String())
+	require.Equal(t, 2000, symbol.Filters.TrailingDelta().MaxTrailingAboveDelta)
+
+	unknown, ok := symbol.Filters.Get("SOME_FUTURE_FILTER")
+	require.True(t, ok)
+	require.IsType(t, &UnknownFilter{}, unknown)
+
+	_, ok = info.FindSymbol("LTCBTC")
+	require.False(t, ok)
+
+	// Filters survive being encoded again.
+	b, err := json.Marshal(symbol.Filters)
+	require.NoError(t, err)
+
+	var filters Filters
+	require.NoError(t, json.Unmarshal(b, &filters))
+	require.Len(t, filters, 12)
+	require.Equal(t, symbol.Filters[:11], filters[:11])
+
+	again, err := json.Marshal(filters)
+	require.NoError(t, err)
+	require.JSONEq(t, string(b), string(again))
+}
@@ -3,9 +3,12 @@ module github.com/nickcorin/binance
 go 1.13
 
 require (
-	github.com/gorilla/schema v1.1.0
+	github.com/gorilla/schema v1.2.0
+	github.com/gorilla/websocket v1.4.2
 	github.com/luno/jettison v0.0.0-20191223144501-7fe4a971f291
 	github.com/prometheus/client_golang v1.4.1
-	github.com/stretchr/testify v1.5.1
-	gopkg.in/yaml.v2 v2.2.8 // indirect
+	github.com/stretchr/testify v1.7.0
+	go.opentelemetry.io/otel v1.0.1
+	go.opentelemetry.io/otel/sdk v1.0.1
+	go.opentelemetry.io/otel/trace v1.0.1
 )
@@ -0,0 +1,100 @@
+package engine
+
+import (
+	"sort"
+
+	"github.com/nickcorin/binance"
+)
+
+// ErrInsufficientBalance is returned when an account can't afford an order.
+var ErrInsufficientBalance = binance.Error{Code: binance.ErrNewOrderRejected,
+	Message: "Account has insufficient balance for requested action."}
+
+// holding is an account's balance of a single asset.
+type holding struct {
+	free   binance.Decimal
+	locked binance.Decimal
+}
+
+// Account contains the balances and trades of an account which places
+// orders.
+type Account struct {
+	holdings map[string]*holding
+
+	// Trades contains the account's trades, in order of execution.
+	Trades []binance.AccountTrade
+}
+
+// NewAccount returns an Account without any balances.
+func NewAccount() *Account {
+	return &Account{holdings: make(map[string]*holding)}
+}
+
+func (a *Account) holding(asset string) *holding {
+	h, ok := a.holdings[asset]
+	if !ok {
+		h = &holding{}
+		a.holdings[asset] = h
+	}
+	return h
+}
+
+// Balance returns the account's balance of `asset`.
+func (a *Account) Balance(asset string) binance.Balance {
+	h := a.holding(asset)
+	return binance.Balance{Asset: asset, Free: h.free, Locked: h.locked}
+}
+
+// Balances returns every balance the account has held, sorted by asset.
+func (a *Account) Balances() []binance.Balance {
+	assets := make([]string, 0, len(a.holdings))
+	for asset := range a.holdings {
+		assets = append(assets, asset)
+	}
+	sort.Strings(assets)
+
+	res := make([]binance.Balance, 0, len(assets))
+	for _, asset := range assets {
+		res = append(res, a.Balance(asset))
+	}
+	return res
+}
+
+// Credit adds `amount` to the free balance of `asset`.
+func (a *Account) Credit(asset string, amount binance.Decimal) {
+	h := a.holding(asset)
+	h.free = h.free.Add(amount)
+}
+
+// Debit removes `amount` from the free balance of `asset`.
+func (a *Account) Debit(asset string, amount binance.Decimal) error {
+	h := a.holding(asset)
+	if h.free.LessThan(amount) {
+		return ErrInsufficientBalance
+	}
+	h.free = h.free.Sub(amount)
+	return nil
+}
+
+// Lock moves `amount` of `asset` from the free to the locked balance.
+func (a *Account) Lock(asset string, amount binance.Decimal) error {
+	if err := a.Debit(asset, amount); err != nil {
+		return err
+	}
+	h := a.holding(asset)
+	h.locked = h.locked.Add(amount)
+	return nil
+}
+
+// Unlock moves `amount` of `asset` from the locked to the free balance.
+func (a *Account) Unlock(asset string, amount binance.Decimal) {
+	h := a.holding(asset)
+	h.locked = h.locked.Sub(amount)
+	h.free = h.free.Add(amount)
+}
+
+// Spend removes `amount` of `asset` from the locked balance.
+func (a *Account) Spend(asset string, amount binance.Decimal) {
+	h := a.holding(asset)
+	h.locked = h.locked.Sub(amount)
+}
@@ -0,0 +1,286 @@
+package engine
+
+import (
+	"fmt"
+	"sort"
+
+	"github.com/nickcorin/binance"
+)
+
+func newError(code binance.ErrorCode, msg string) binance.Error {
+	return binance.Error{Code: code, Message: msg}
+}
+
+// ErrMandatory returns the error that the exchange rejects a request with
+// when the parameter `name` is missing.
+func ErrMandatory(name string) error {
+	return newError(binance.ErrMandatoryParamEmptyOrMalformed,
+		fmt.Sprintf("Mandatory parameter '%s' was not sent, was empty/null, "+
+			"or malformed.", name))
+}
+
+// ErrIllegal returns the error that the exchange rejects a request with when
+// the parameter `name` has an invalid value.
+func ErrIllegal(name string) error {
+	return newError(binance.ErrInvalidParam,
+		fmt.Sprintf("Illegal value for parameter '%s'.", name))
+}
+
+// ResponseType returns the response type of `r`, which defaults to FULL for
+// LIMIT and MARKET orders and to ACK for the others.
+func ResponseType(r *binance.NewOrderRequest) (binance.OrderResponseType,
+	error) {
+	switch r.ResponseType {
+	case binance.OrderResponseTypeAck, binance.OrderResponseTypeResult,
+		binance.OrderResponseTypeFull:
+		return r.ResponseType, nil
+	case "":
+	default:
+		return "", ErrIllegal("newOrderRespType")
+	}
+
+	if r.Type == binance.OrderTypeLimit || r.Type == binance.OrderTypeMarket {
+		return binance.OrderResponseTypeFull, nil
+	}
+	return binance.OrderResponseTypeAck, nil
+}
+
+// orderParams contains the parameters which each order type requires, and
+// the other parameters which it accepts.
+var orderParams = map[binance.OrderType]struct {
+	required []string
+	optional []string
+}{
+	binance.OrderTypeLimit: {
+		required: []string{"price", "quantity", "timeInForce"},
+		optional: []string{"icebergQty"},
+	},
+	binance.OrderTypeLimitMaker: {
+		required: []string{"price", "quantity"},
+		optional: []string{"icebergQty"},
+	},
+	binance.OrderTypeMarket: {
+		optional: []string{"quantity", "quoteOrderQty"},
+	},
+	binance.OrderTypeStopLoss: {
+		required: []string{"quantity", "stopPrice"},
+	},
+	binance.OrderTypeStopLossLimit: {
+		required: []string{"price", "quantity", "stopPrice", "timeInForce"},
+		optional: []string{"icebergQty"},
+	},
+	binance.OrderTypeTakeProfit: {
+		required: []string{"quantity", "stopPrice"},
+	},
+	binance.OrderTypeTakeProfitLimit: {
+		required: []string{"price", "quantity", "stopPrice", "timeInForce"},
+		optional: []string{"icebergQty"},
+	},
+}
+
+// CheckOrder returns the error that the exchange would reject the order `r`
+// of `acc` on `m` with, if any. The market's filters are checked against
+// `avgPrice`.
+func CheckOrder(m *Market, acc *Account, r *binance.NewOrderRequest,
+	avgPrice binance.Decimal) error {
+	if r.Side != binance.Buy && r.Side != binance.Sell {
+		return newError(binance.ErrInvalidSide, "Invalid side.")
+	}
+
+	params, ok := orderParams[r.Type]
+	if !ok || !m.Info.AllowsOrderType(r.Type) {
+		return newError(binance.ErrInvalidOrderType, "Invalid orderType.")
+	}
+
+	sent := map[string]bool{
+		"icebergQty":    !r.IcebergQty.IsZero(),
+		"price":         !r.Price.IsZero(),
+		"quantity":      !r.Qty.IsZero(),
+		"quoteOrderQty": !r.QuoteOrderQty.IsZero(),
+		"stopPrice":     !r.StopPrice.IsZero(),
+		"timeInForce":   r.TimeInForce != "",
+	}
+
+	for _, name := range params.required {
+		if !sent[name] {
+			return ErrMandatory(name)
+		}
+		delete(sent, name)
+	}
+	for _, name := range params.optional {
+		delete(sent, name)
+	}
+	for _, name := range sortedKeys(sent) {
+		if !sent[name] {
+			continue
+		}
+		if name == "timeInForce" {
+			return newError(binance.ErrTIFNotRequired, "Parameter "+
+				"'timeInForce' sent when not required.")
+		}
+		return newError(binance.ErrParamNotRequired, fmt.Sprintf(
+			"Parameter '%s' sent when not required.", name))
+	}
+
+	if r.Type == binance.OrderTypeMarket {
+		if err := checkMarketOrder(m, r); err != nil {
+			return err
+		}
+	}
+
+	switch r.TimeInForce {
+	case "", binance.GoodUntilCancelled, binance.FillOrKill,
+		binance.ImmediateOrCancel:
+	default:
+		return newError(binance.ErrInvalidTIF, "Invalid timeInForce.")
+	}
+
+	if !r.IcebergQty.IsZero() {
+		if !m.Info.IcebergAllowed {
+			return newError(binance.ErrInvalidMessage, "Iceberg orders are "+
+				"not supported for this symbol.")
+		}
+		if r.TimeInForce != "" && r.TimeInForce != binance.GoodUntilCancelled {
+			return newError(binance.ErrInvalidTIF, "Iceberg orders must "+
+				"be GTC.")
+		}
+	}
+
+	if m.Info.Status != binance.SymbolStatusTrading {
+		return newError(binance.ErrInvalidMessage, "Market is closed.")
+	}
+
+	violations := m.Info.ValidateOrder(r, m.state(acc, avgPrice))
+	if len(violations) > 0 {
+		if violations[0].Filter == "" {
+			return newError(binance.ErrBadPrecision, "Precision is over "+
+				"the maximum defined for this asset.")
+		}
+		return newError(binance.ErrInvalidMessage, "Filter failure: "+
+			string(violations[0].Filter))
+	}
+
+	if r.NewClientOrderID != "" {
+		for _, o := range m.OpenOrders(acc) {
+			if o.ClientOrderID == r.NewClientOrderID {
+				return newError(binance.ErrNewOrderRejected,
+					"Duplicate order sent.")
+			}
+		}
+	}
+
+	return nil
+}
+
+// checkMarketOrder checks that a MARKET order has exactly one of quantity
+// and quoteOrderQty.
+func checkMarketOrder(m *Market, r *binance.NewOrderRequest) error {
+	switch {
+	case r.Qty.IsZero() && r.QuoteOrderQty.IsZero():
+		return newError(binance.ErrMandatoryParamEmptyOrMalformed,
+			"Param 'quantity' or 'quoteOrderQty' must be sent, but both "+
+				"were empty/null!")
+	case !r.Qty.IsZero() && !r.QuoteOrderQty.IsZero():
+		return newError(binance.ErrOptionalParamsBadCombo,
+			"Combination of optional parameters invalid.")
+	case !r.QuoteOrderQty.IsZero() && !m.Info.QuoteOrderQtyMarketAllowed:
+		return newError(binance.ErrInvalidMessage, "Quote order qty market "+
+			"orders are not supported for this symbol.")
+	}
+	return nil
+}
+
+func sortedKeys(m map[string]bool) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+// OCOOrders returns the limit and stop orders that make up `r`.
+func OCOOrders(r *binance.NewOCORequest) (limit,
+	stop *binance.NewOrderRequest, err error) {
+	limit = &binance.NewOrderRequest{
+		IcebergQty:       r.LimitIcebergQty,
+		NewClientOrderID: r.LimitClientOrderID,
+		Price:            r.Price,
+		Qty:              r.Qty,
+		Side:             r.Side,
+		Symbol:           r.Symbol,
+		Type:             binance.OrderTypeLimitMaker,
+	}
+
+	stop = &binance.NewOrderRequest{
+		IcebergQty:       r.StopIcebergQty,
+		NewClientOrderID: r.StopClientOrderID,
+		Price:            r.StopLimitPrice,
+		Qty:              r.Qty,
+		Side:             r.Side,
+		StopPrice:        r.StopPrice,
+		Symbol:           r.Symbol,
+		TimeInForce:      r.StopLimitTimeInForce,
+		Type:             binance.OrderTypeStopLoss,
+	}
+
+	switch {
+	case r.Price.IsZero():
+		return nil, nil, ErrMandatory("price")
+	case r.StopPrice.IsZero():
+		return nil, nil, ErrMandatory("stopPrice")
+	case !stop.Price.IsZero() && stop.TimeInForce == "":
+		return nil, nil, ErrMandatory("stopLimitTimeInForce")
+	case stop.Price.IsZero() && stop.TimeInForce != "":
+		return nil, nil, newError(binance.ErrParamNotRequired, "Parameter "+
+			"'stopLimitTimeInForce' sent when not required.")
+	case !stop.Price.IsZero():
+		stop.Type = binance.OrderTypeStopLossLimit
+	}
+
+	return limit, stop, nil
+}
+
+// CheckOCO returns the error that the exchange would reject the order list
+// of `limit` and `stop` with, if any.
+func CheckOCO(m *Market, acc *Account, limit, stop *binance.NewOrderRequest,
+	avgPrice binance.Decimal) error {
+	if !m.Info.OCOAllowed {
+		return newError(binance.ErrInvalidMessage, "OCO orders are not "+
+			"supported for this symbol.")
+	}
+
+	for _, r := range []*binance.NewOrderRequest{limit, stop} {
+		if err := CheckOrder(m, acc, r, avgPrice); err != nil {
+			return err
+		}
+	}
+
+	// A sell needs the limit order above the stop, and a buy needs it below.
+	cmp := limit.Price.Cmp(stop.StopPrice)
+	if limit.Side == binance.Sell && cmp <= 0 ||
+		limit.Side == binance.Buy && cmp >= 0 {
+		return newError(binance.ErrNewOrderRejected, "The relationship "+
+			"of the prices for the orders is not correct.")
+	}
+
+	return nil
+}
+
+// CheckPlacement returns the error that `o` is rejected with because of the
+// state of the market: the `levels` it would take from, and the `last`
+// traded price.
+func CheckPlacement(o *Order, levels []Level, last binance.Decimal) error {
+	if o.Type == binance.OrderTypeLimitMaker && len(levels) > 0 &&
+		o.Crosses(levels[0].Price) {
+		return newError(binance.ErrNewOrderRejected, "Order would "+
+			"immediately match and take.")
+	}
+
+	if o.TriggeredBy(last, last) {
+		return newError(binance.ErrNewOrderRejected, "Stop price would "+
+			"trigger immediately.")
+	}
+
+	return nil
+}
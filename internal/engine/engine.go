@@ -0,0 +1,612 @@
+// Package engine places and matches orders for the simulated exchanges in
+// this module, binancetest.Server and paper.Client, keeping the balances of
+// the accounts which placed them.
+package engine
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/nickcorin/binance"
+)
+
+// Engine places orders on markets and executes them. It is not safe for
+// concurrent use.
+type Engine struct {
+	// ClientIDPrefix prefixes the client IDs generated for orders and lists
+	// which weren't given one.
+	ClientIDPrefix string
+
+	// MakerCommission and TakerCommission are charged on trades, in
+	// hundredths of a percent.
+	MakerCommission int
+	TakerCommission int
+
+	// Now returns the time which stamps orders and trades.
+	Now func() time.Time
+
+	// Lists contains every order list placed, in order of placement.
+	Lists []*List
+
+	lastListID  int64
+	lastOrderID int64
+}
+
+// Market contains the orders and trades of a symbol.
+type Market struct {
+	Info binance.SymbolInfo
+
+	// Asks and Bids contain the resting orders, best first and then in order
+	// of arrival.
+	Asks []*Order
+	Bids []*Order
+
+	// Stops contains the stop orders which haven't been triggered yet.
+	Stops []*Order
+
+	// Orders contains every order placed, in order of placement.
+	Orders []*Order
+
+	// Trades contains the market's trades, in order of execution.
+	Trades []binance.Trade
+
+	// UpdateID is incremented whenever the book changes.
+	UpdateID int64
+
+	lastTradeID int64
+}
+
+// NewMarket returns a Market without any orders for the symbol `info`.
+func NewMarket(info binance.SymbolInfo) *Market {
+	return &Market{Info: info}
+}
+
+// Level is a price level that an order takes from. Maker is the resting
+// order at the level, or nil if the level is liquidity outside of the
+// engine, such as the order book of the real exchange.
+type Level struct {
+	Maker *Order
+	Price binance.Decimal
+	Qty   binance.Decimal
+}
+
+// BookLevels returns `levels` of an order book as Levels without makers.
+func BookLevels(levels []binance.PriceLevel) []Level {
+	res := make([]Level, 0, len(levels))
+	for _, l := range levels {
+		res = append(res, Level{Price: l.Price, Qty: l.Qty})
+	}
+	return res
+}
+
+// Makers returns the resting orders that orders on `side` take from, best
+// first.
+func (m *Market) Makers(side binance.OrderSide) []Level {
+	book := *m.book(opposite(side))
+	res := make([]Level, 0, len(book))
+	for _, o := range book {
+		res = append(res, Level{Maker: o, Price: o.Price, Qty: o.Remaining()})
+	}
+	return res
+}
+
+// LastPrice returns the price of the latest trade, or zero if there haven't
+// been any.
+func (m *Market) LastPrice() binance.Decimal {
+	if len(m.Trades) == 0 {
+		return binance.Decimal{}
+	}
+	return m.Trades[len(m.Trades)-1].Price
+}
+
+// Lookup returns the order of `acc` identified by `id` or, if it is zero,
+// `clientID`. It returns nil if there isn't one.
+func (m *Market) Lookup(acc *Account, id int64, clientID string) *Order {
+	// Client order IDs may be reused once an order is closed, so the most
+	// recent order is found.
+	for i := len(m.Orders) - 1; i >= 0; i-- {
+		o := m.Orders[i]
+		if o.Account != acc {
+			continue
+		}
+		if id != 0 && o.ID == id || id == 0 && o.ClientOrderID == clientID {
+			return o
+		}
+	}
+	return nil
+}
+
+// OpenOrders returns the open orders of `acc`, in order of placement.
+func (m *Market) OpenOrders(acc *Account) []*Order {
+	var orders []*Order
+	for _, o := range m.Orders {
+		if o.Account == acc && o.IsOpen() {
+			orders = append(orders, o)
+		}
+	}
+	return orders
+}
+
+// state returns the state which the filters of the market are checked
+// against for orders from `acc`.
+func (m *Market) state(acc *Account,
+	avgPrice binance.Decimal) *binance.MarketState {
+	state := binance.MarketState{AvgPrice: avgPrice}
+	for _, o := range m.OpenOrders(acc) {
+		state.OpenOrders++
+		if o.IsStop() {
+			state.OpenAlgoOrders++
+		}
+		if !o.IcebergQty.IsZero() {
+			state.OpenIcebergOrders++
+		}
+	}
+	return &state
+}
+
+// lockAsset returns the asset which an order on `side` reserves.
+func (m *Market) lockAsset(side binance.OrderSide) string {
+	if side == binance.Buy {
+		return m.Info.QuoteAsset
+	}
+	return m.Info.BaseAsset
+}
+
+// book returns the side of the book which orders on `side` rest on.
+func (m *Market) book(side binance.OrderSide) *[]*Order {
+	if side == binance.Buy {
+		return &m.Bids
+	}
+	return &m.Asks
+}
+
+// rest adds `o` to the book behind the orders at the same price.
+func (m *Market) rest(o *Order) {
+	book := m.book(o.Side)
+
+	i := 0
+	for ; i < len(*book); i++ {
+		other := (*book)[i]
+		if o.Side == binance.Buy && o.Price.GreaterThan(other.Price) ||
+			o.Side == binance.Sell && o.Price.LessThan(other.Price) {
+			break
+		}
+	}
+
+	*book = append(*book, nil)
+	copy((*book)[i+1:], (*book)[i:])
+	(*book)[i] = o
+	m.UpdateID++
+}
+
+// remove removes `o` from the book and from the pending stop orders.
+func (m *Market) remove(o *Order) {
+	for _, l := range []*[]*Order{&m.Bids, &m.Asks, &m.Stops} {
+		for i, other := range *l {
+			if other == o {
+				*l = append((*l)[:i], (*l)[i+1:]...)
+				m.UpdateID++
+				break
+			}
+		}
+	}
+}
+
+// qtyStep returns the interval that quantities must be a multiple of.
+func (m *Market) qtyStep() binance.Decimal {
+	if f := m.Info.Filters.LotSize(); f != nil && f.StepSize.Sign() > 0 {
+		return f.StepSize
+	}
+	return binance.NewDecimal(1, int32(m.Info.BaseAssetPrecision))
+}
+
+// takeable returns the quantity that `o` can take from a level at `price`,
+// ignoring the level's size.
+func (m *Market) takeable(o *Order, price binance.Decimal) binance.Decimal {
+	if o.QuoteOrderQty.IsZero() {
+		return o.Remaining()
+	}
+
+	quote := o.QuoteOrderQty.Sub(o.CumQuote)
+	step := m.qtyStep()
+	return quote.Div(price, step.Scale()).RoundDown(step)
+}
+
+// simulate returns the quantity and cost of what `o` would execute against
+// `levels`.
+func (m *Market) simulate(o *Order, levels []Level) (qty,
+	quote binance.Decimal) {
+	sim := *o
+	for _, l := range levels {
+		if l.Maker != nil && !l.Maker.IsOpen() {
+			continue
+		}
+		if !sim.Crosses(l.Price) {
+			break
+		}
+
+		q := min(m.takeable(&sim, l.Price), l.Qty)
+		if q.IsZero() {
+			break
+		}
+
+		sim.Executed = sim.Executed.Add(q)
+		sim.CumQuote = sim.CumQuote.Add(q.Mul(l.Price))
+	}
+	return sim.Executed.Sub(o.Executed), sim.CumQuote.Sub(o.CumQuote)
+}
+
+func opposite(side binance.OrderSide) binance.OrderSide {
+	if side == binance.Buy {
+		return binance.Sell
+	}
+	return binance.Buy
+}
+
+func min(a, b binance.Decimal) binance.Decimal {
+	if a.LessThan(b) {
+		return a
+	}
+	return b
+}
+
+// timestamp returns the engine's time in milliseconds.
+func (e *Engine) timestamp() int64 {
+	return e.Now().UnixNano() / 1e6
+}
+
+// register assigns `o` an ID and adds it to the orders of `m`.
+func (e *Engine) register(m *Market, o *Order) {
+	e.lastOrderID++
+	o.ID = e.lastOrderID
+
+	if o.ClientOrderID == "" {
+		o.ClientOrderID = fmt.Sprintf("%s-%d", e.ClientIDPrefix, o.ID)
+	}
+	o.Time = e.timestamp()
+	o.UpdateTime = o.Time
+	m.Orders = append(m.Orders, o)
+}
+
+// Place reserves the funds for `o`, which has been checked, and adds it to
+// `m`. Stop orders wait to be triggered, and other orders are executed
+// against `levels` straight away. Stop orders which `last`, the latest traded
+// price, would trigger are rejected.
+func (e *Engine) Place(m *Market, o *Order, levels []Level,
+	last binance.Decimal) error {
+	if err := CheckPlacement(o, levels, last); err != nil {
+		return err
+	}
+
+	// The cost of a stop market buy isn't known until it is triggered.
+	if !(o.IsStop() && o.IsMarket() && o.Side == binance.Buy) {
+		if err := e.lockFor(m, o, levels); err != nil {
+			return err
+		}
+	}
+
+	e.register(m, o)
+	if o.IsStop() {
+		m.Stops = append(m.Stops, o)
+		return nil
+	}
+
+	e.execute(m, o, levels)
+	return nil
+}
+
+// PlaceOCO places the order list of `limit` and `stop` on `m`, which have
+// been checked. The orders share their funds, which the limit order
+// reserves, and which the stop order reserves again once it is triggered.
+func (e *Engine) PlaceOCO(m *Market, acc *Account, clientID string, limit,
+	stop *Order, levels []Level, last binance.Decimal) (*List, error) {
+	for _, o := range []*Order{limit, stop} {
+		if err := CheckPlacement(o, levels, last); err != nil {
+			return nil, err
+		}
+	}
+
+	if err := e.lockFor(m, limit, levels); err != nil {
+		return nil, err
+	}
+
+	e.lastListID++
+	l := List{
+		Account:         acc,
+		ClientID:        clientID,
+		ID:              e.lastListID,
+		ListOrderStatus: binance.ListOrderStatusExecuting,
+		ListStatusType:  binance.ListStatusTypeExecStarted,
+		Orders:          []*Order{stop, limit},
+		Symbol:          m.Info.Symbol,
+		Time:            e.timestamp(),
+	}
+
+	if l.ClientID == "" {
+		l.ClientID = fmt.Sprintf("%s-list-%d", e.ClientIDPrefix, l.ID)
+	}
+	e.Lists = append(e.Lists, &l)
+
+	for _, o := range l.Orders {
+		o.List = &l
+		e.register(m, o)
+	}
+
+	m.Stops = append(m.Stops, stop)
+	e.execute(m, limit, levels)
+	return &l, nil
+}
+
+// LookupList returns the order list of `acc` identified by `id` or, if it is
+// zero, `clientID`. It returns nil if there isn't one.
+func (e *Engine) LookupList(acc *Account, id int64, clientID string) *List {
+	for i := len(e.Lists) - 1; i >= 0; i-- {
+		l := e.Lists[i]
+		if l.Account != acc {
+			continue
+		}
+		if id != 0 && l.ID == id || id == 0 && l.ClientID == clientID {
+			return l
+		}
+	}
+	return nil
+}
+
+// AccountLists returns the order lists of `acc`, in order of placement.
+func (e *Engine) AccountLists(acc *Account) []*List {
+	var lists []*List
+	for _, l := range e.Lists {
+		if l.Account == acc {
+			lists = append(lists, l)
+		}
+	}
+	return lists
+}
+
+// lockFor reserves the funds that `o` needs to be placed, or to execute
+// against `levels` once it is triggered.
+func (e *Engine) lockFor(m *Market, o *Order, levels []Level) error {
+	var amount binance.Decimal
+	switch {
+	case o.Side == binance.Sell && !o.QuoteOrderQty.IsZero():
+		amount, _ = m.simulate(o, levels)
+	case o.Side == binance.Sell:
+		amount = o.OrigQty
+	case o.IsMarket():
+		_, amount = m.simulate(o, levels)
+	default:
+		amount = o.Price.Mul(o.OrigQty)
+	}
+
+	if err := o.Account.Lock(m.lockAsset(o.Side), amount); err != nil {
+		return err
+	}
+	o.locked = o.locked.Add(amount)
+	return nil
+}
+
+// release returns the funds still reserved for `o`.
+func (e *Engine) release(m *Market, o *Order) {
+	if o.locked.Sign() > 0 {
+		o.Account.Unlock(m.lockAsset(o.Side), o.locked)
+	}
+	o.locked = binance.Decimal{}
+}
+
+// execute takes from `levels` with `o`, then rests, expires or completes it
+// according to its type and time in force.
+func (e *Engine) execute(m *Market, o *Order, levels []Level) {
+	o.Working = true
+
+	if o.TimeInForce == binance.FillOrKill {
+		if qty, _ := m.simulate(o, levels); qty.LessThan(o.Remaining()) {
+			e.finish(m, o, binance.OrderStatusExpired)
+			return
+		}
+	}
+
+	i := 0
+	for ; i < len(levels) && o.IsOpen(); i++ {
+		l := levels[i]
+		if l.Maker != nil && !l.Maker.IsOpen() {
+			continue
+		}
+		if !o.Crosses(l.Price) {
+			break
+		}
+
+		qty := min(m.takeable(o, l.Price), l.Qty)
+		if qty.IsZero() {
+			break
+		}
+		e.Fill(m, o, l.Maker, l.Price, qty)
+
+		if qty.LessThan(l.Qty) {
+			break
+		}
+	}
+
+	if !o.QuoteOrderQty.IsZero() {
+		// The quantity of a quote order is only known once it has executed.
+		// It is filled unless it ran out of levels to take from.
+		o.OrigQty = o.Executed
+		if o.Executed.Sign() > 0 && (i < len(levels) ||
+			o.CumQuote.Equal(o.QuoteOrderQty)) {
+			e.finish(m, o, binance.OrderStatusFilled)
+		} else {
+			e.finish(m, o, binance.OrderStatusExpired)
+		}
+		return
+	}
+
+	switch {
+	case !o.IsOpen():
+	case o.IsMarket(), o.TimeInForce == binance.ImmediateOrCancel:
+		e.finish(m, o, binance.OrderStatusExpired)
+	default:
+		m.rest(o)
+	}
+}
+
+// Fill executes `qty` between `taker` and the resting `maker`, at `price`.
+// Either order may be nil if it isn't one of the engine's.
+func (e *Engine) Fill(m *Market, taker, maker *Order, price,
+	qty binance.Decimal) {
+	buyerMaker := maker != nil && maker.Side == binance.Buy ||
+		taker != nil && taker.Side == binance.Sell
+
+	m.lastTradeID++
+	trade := binance.Trade{
+		ID:           m.lastTradeID,
+		IsBestMatch:  true,
+		IsBuyerMaker: buyerMaker,
+		Price:        price,
+		Qty:          qty,
+		QuoteQty:     price.Mul(qty),
+		Time:         e.timestamp(),
+	}
+	m.Trades = append(m.Trades, trade)
+	m.UpdateID++
+
+	if taker != nil {
+		e.settle(m, taker, trade, false)
+	}
+	if maker != nil {
+		e.settle(m, maker, trade, true)
+		if !maker.IsOpen() {
+			m.remove(maker)
+		}
+	}
+}
+
+// settle updates `o` and its account with its side of `trade`, less the
+// commission.
+func (e *Engine) settle(m *Market, o *Order, trade binance.Trade,
+	isMaker bool) {
+	base, quote := m.Info.BaseAsset, m.Info.QuoteAsset
+	acc := o.Account
+
+	rate := e.TakerCommission
+	if isMaker {
+		rate = e.MakerCommission
+	}
+
+	fill := binance.OrderFill{Price: trade.Price, Qty: trade.Qty}
+	if o.Side == binance.Buy {
+		// Limit orders reserved funds at their own price, which may be worse
+		// than the price traded at.
+		reserved := trade.QuoteQty
+		if !o.Price.IsZero() {
+			reserved = o.Price.Mul(trade.Qty)
+		}
+
+		if rate > 0 {
+			fill.Commission = trade.Qty.Mul(binance.NewDecimal(int64(rate), 4))
+		}
+		fill.CommissionAsset = base
+
+		acc.Spend(quote, trade.QuoteQty)
+		acc.Unlock(quote, reserved.Sub(trade.QuoteQty))
+		acc.Credit(base, trade.Qty.Sub(fill.Commission))
+		o.locked = o.locked.Sub(reserved)
+	} else {
+		if rate > 0 {
+			fill.Commission = trade.QuoteQty.Mul(
+				binance.NewDecimal(int64(rate), 4))
+		}
+		fill.CommissionAsset = quote
+
+		acc.Spend(base, trade.Qty)
+		acc.Credit(quote, trade.QuoteQty.Sub(fill.Commission))
+		o.locked = o.locked.Sub(trade.Qty)
+	}
+
+	o.Executed = o.Executed.Add(trade.Qty)
+	o.CumQuote = o.CumQuote.Add(trade.QuoteQty)
+	o.UpdateTime = trade.Time
+	o.Fills = append(o.Fills, fill)
+
+	acc.Trades = append(acc.Trades, binance.AccountTrade{
+		Commission:      fill.Commission,
+		CommissionAsset: fill.CommissionAsset,
+		ID:              trade.ID,
+		IsBestMatch:     true,
+		IsBuyer:         o.Side == binance.Buy,
+		IsMaker:         isMaker,
+		OrderID:         o.ID,
+		OrderListID:     o.ListID(),
+		Price:           trade.Price,
+		Qty:             trade.Qty,
+		QuoteQty:        trade.QuoteQty,
+		Symbol:          o.Symbol,
+		Time:            trade.Time,
+	})
+
+	switch {
+	case !o.QuoteOrderQty.IsZero():
+	case o.Remaining().IsZero():
+		e.finish(m, o, binance.OrderStatusFilled)
+	default:
+		o.Status = binance.OrderStatusPartiallyFilled
+	}
+
+	// Once one order in an OCO executes, the other is expired.
+	if sibling := o.List.Sibling(o); sibling != nil && sibling.IsOpen() {
+		m.remove(sibling)
+		e.finish(m, sibling, binance.OrderStatusExpired)
+	}
+}
+
+// finish ends `o` with `status`, returning any funds still reserved for it.
+func (e *Engine) finish(m *Market, o *Order, status binance.OrderStatus) {
+	o.Status = status
+	o.Working = false
+	o.UpdateTime = e.timestamp()
+	e.release(m, o)
+
+	if l := o.List; l != nil && !l.IsOpen() {
+		l.ListOrderStatus = binance.ListOrderStatusAllDone
+		l.ListStatusType = binance.ListStatusTypeAllDone
+	}
+}
+
+// Cancel removes `o` from `m`, along with the rest of its list.
+func (e *Engine) Cancel(m *Market, o *Order) {
+	orders := []*Order{o}
+	if o.List != nil {
+		orders = o.List.Orders
+	}
+
+	for _, lo := range orders {
+		if lo.IsOpen() {
+			m.remove(lo)
+			e.finish(m, lo, binance.OrderStatusCancelled)
+		}
+	}
+}
+
+// Trigger makes the stop order `o` work, executing it against `levels`.
+func (e *Engine) Trigger(m *Market, o *Order, levels []Level) {
+	m.remove(o)
+
+	// The stop order of an OCO shares its funds with the limit order, so the
+	// limit order is expired and the funds reserved again. Stop market buys
+	// can't know their cost until now.
+	lock := o.Side == binance.Buy && o.IsMarket()
+	if sibling := o.List.Sibling(o); sibling != nil {
+		if sibling.IsOpen() {
+			m.remove(sibling)
+			e.finish(m, sibling, binance.OrderStatusExpired)
+		}
+		lock = true
+	}
+
+	if lock {
+		if err := e.lockFor(m, o, levels); err != nil {
+			e.finish(m, o, binance.OrderStatusExpired)
+			return
+		}
+	}
+
+	e.execute(m, o, levels)
+}
@@ -0,0 +1,273 @@
+package engine
+
+import (
+	"github.com/nickcorin/binance"
+)
+
+// Order is an order placed on a Market.
+type Order struct {
+	Account       *Account
+	ClientOrderID string
+	CumQuote      binance.Decimal
+	Executed      binance.Decimal
+	Fills         []binance.OrderFill
+	IcebergQty    binance.Decimal
+	ID            int64
+	List          *List
+	OrigQty       binance.Decimal
+	Price         binance.Decimal
+	QuoteOrderQty binance.Decimal
+	Side          binance.OrderSide
+	Status        binance.OrderStatus
+	StopPrice     binance.Decimal
+	Symbol        string
+	Time          int64
+	TimeInForce   binance.TimeInForce
+	Type          binance.OrderType
+	UpdateTime    int64
+	Working       bool
+
+	// locked is the amount of the order's lock asset still reserved for it.
+	locked binance.Decimal
+}
+
+// NewOrder returns an order of `acc` for `r`, which has already been
+// checked. It is given an ID once it is placed.
+func NewOrder(acc *Account, r *binance.NewOrderRequest) *Order {
+	return &Order{
+		Account:       acc,
+		ClientOrderID: r.NewClientOrderID,
+		IcebergQty:    r.IcebergQty,
+		OrigQty:       r.Qty,
+		Price:         r.Price,
+		QuoteOrderQty: r.QuoteOrderQty,
+		Side:          r.Side,
+		Status:        binance.OrderStatusNew,
+		StopPrice:     r.StopPrice,
+		Symbol:        r.Symbol,
+		TimeInForce:   r.TimeInForce,
+		Type:          r.Type,
+	}
+}
+
+// Remaining returns the quantity which is yet to be executed.
+func (o *Order) Remaining() binance.Decimal {
+	return o.OrigQty.Sub(o.Executed)
+}
+
+// IsOpen returns whether the order may still be executed.
+func (o *Order) IsOpen() bool {
+	return o.Status == binance.OrderStatusNew ||
+		o.Status == binance.OrderStatusPartiallyFilled
+}
+
+// IsMarket returns whether the order executes at the market price once it is
+// working.
+func (o *Order) IsMarket() bool {
+	switch o.Type {
+	case binance.OrderTypeMarket, binance.OrderTypeStopLoss,
+		binance.OrderTypeTakeProfit:
+		return true
+	}
+	return false
+}
+
+// IsStop returns whether the order waits for the market to reach its stop
+// price before it is working.
+func (o *Order) IsStop() bool {
+	switch o.Type {
+	case binance.OrderTypeStopLoss, binance.OrderTypeStopLossLimit,
+		binance.OrderTypeTakeProfit, binance.OrderTypeTakeProfitLimit:
+		return true
+	}
+	return false
+}
+
+// TriggeredBy returns whether a stop order is triggered by the market
+// trading between `low` and `high`.
+func (o *Order) TriggeredBy(low, high binance.Decimal) bool {
+	if !o.IsStop() || low.IsZero() || high.IsZero() {
+		return false
+	}
+
+	stopLoss := o.Type == binance.OrderTypeStopLoss ||
+		o.Type == binance.OrderTypeStopLossLimit
+
+	// Stop losses buy as the price rises and sell as it falls, and take
+	// profits do the opposite.
+	if (o.Side == binance.Buy) == stopLoss {
+		return high.Cmp(o.StopPrice) >= 0
+	}
+	return low.Cmp(o.StopPrice) <= 0
+}
+
+// Crosses returns whether the order would trade with a resting order at
+// `price`.
+func (o *Order) Crosses(price binance.Decimal) bool {
+	if o.IsMarket() {
+		return true
+	}
+	if o.Side == binance.Buy {
+		return price.Cmp(o.Price) <= 0
+	}
+	return price.Cmp(o.Price) >= 0
+}
+
+// ListID returns the ID of the order's list, or -1 if it isn't in one.
+func (o *Order) ListID() int64 {
+	if o.List == nil {
+		return -1
+	}
+	return o.List.ID
+}
+
+// Response returns the response to placing the order, with the fields of
+// `respType`.
+func (o *Order) Response(
+	respType binance.OrderResponseType) *binance.NewOrderResponse {
+	res := binance.NewOrderResponse{
+		ClientOrderID: o.ClientOrderID,
+		OrderID:       o.ID,
+		OrderListID:   o.ListID(),
+		Symbol:        o.Symbol,
+		TransactTime:  o.Time,
+	}
+	if respType == binance.OrderResponseTypeAck {
+		return &res
+	}
+
+	res.CummulativeQuoteQty = o.CumQuote
+	res.ExecutedQty = o.Executed
+	res.OriginalQty = o.OrigQty
+	res.Price = o.Price
+	res.Side = o.Side
+	res.Status = o.Status
+	res.TimeInForce = o.TimeInForce
+	res.Type = o.Type
+	if respType == binance.OrderResponseTypeFull {
+		res.Fills = append([]binance.OrderFill{}, o.Fills...)
+	}
+	return &res
+}
+
+// QueryResponse returns the state of the order.
+func (o *Order) QueryResponse() binance.QueryOrderResponse {
+	return binance.QueryOrderResponse{
+		ClientOrderID:         o.ClientOrderID,
+		CummulativeQuoteQty:   o.CumQuote,
+		ExecutedQty:           o.Executed,
+		IcebergQty:            o.IcebergQty,
+		IsWorking:             o.Working,
+		OrderID:               o.ID,
+		OrderListID:           o.ListID(),
+		OriginalQuoteOrderQty: o.QuoteOrderQty,
+		OriginalQty:           o.OrigQty,
+		Price:                 o.Price,
+		Side:                  o.Side,
+		Status:                o.Status,
+		StopPrice:             o.StopPrice,
+		Symbol:                o.Symbol,
+		Time:                  o.Time,
+		TimeInForce:           o.TimeInForce,
+		Type:                  o.Type,
+		UpdateTime:            o.UpdateTime,
+	}
+}
+
+// CancelResponse returns the response to cancelling the order.
+func (o *Order) CancelResponse() binance.CancelOrderResponse {
+	return binance.CancelOrderResponse{
+		ClientOrderID:       o.ClientOrderID,
+		CummulativeQuoteQty: o.CumQuote,
+		ExecutedQty:         o.Executed,
+		OrderID:             o.ID,
+		OrderListID:         o.ListID(),
+		OriginalQty:         o.OrigQty,
+		Price:               o.Price,
+		Side:                o.Side,
+		Status:              o.Status,
+		Symbol:              o.Symbol,
+		TimeInForce:         o.TimeInForce,
+		Type:                o.Type,
+	}
+}
+
+// List is an OCO order list.
+type List struct {
+	Account         *Account
+	ClientID        string
+	ID              int64
+	ListOrderStatus binance.ListOrderStatus
+	ListStatusType  binance.ListStatusType
+	Orders          []*Order
+	Symbol          string
+	Time            int64
+}
+
+// IsOpen returns whether any of the list's orders may still be executed.
+func (l *List) IsOpen() bool {
+	for _, o := range l.Orders {
+		if o.IsOpen() {
+			return true
+		}
+	}
+	return false
+}
+
+// Sibling returns the other order in the list of `o`, or nil if `l` is nil.
+func (l *List) Sibling(o *Order) *Order {
+	if l == nil {
+		return nil
+	}
+	for _, other := range l.Orders {
+		if other != o {
+			return other
+		}
+	}
+	return nil
+}
+
+// Response returns the state of the list, with a report of each order if
+// `reports` is set.
+func (l *List) Response(reports bool) binance.OrderList {
+	res := binance.OrderList{
+		ContingencyType:   binance.ContingencyTypeOCO,
+		ListClientOrderID: l.ClientID,
+		ListOrderStatus:   l.ListOrderStatus,
+		ListStatusType:    l.ListStatusType,
+		OrderListID:       l.ID,
+		Symbol:            l.Symbol,
+		TransactionTime:   l.Time,
+	}
+
+	for _, o := range l.Orders {
+		res.Orders = append(res.Orders, binance.OrderListEntry{
+			ClientOrderID: o.ClientOrderID,
+			OrderID:       o.ID,
+			Symbol:        o.Symbol,
+		})
+
+		if !reports {
+			continue
+		}
+
+		res.OrderReports = append(res.OrderReports, binance.OrderReport{
+			ClientOrderID:       o.ClientOrderID,
+			CummulativeQuoteQty: o.CumQuote,
+			ExecutedQty:         o.Executed,
+			IcebergQty:          o.IcebergQty,
+			OrderID:             o.ID,
+			OrderListID:         l.ID,
+			OriginalQty:         o.OrigQty,
+			Price:               o.Price,
+			Side:                o.Side,
+			Status:              o.Status,
+			StopPrice:           o.StopPrice,
+			Symbol:              o.Symbol,
+			TimeInForce:         o.TimeInForce,
+			TransactTime:        o.UpdateTime,
+			Type:                o.Type,
+		})
+	}
+	return res
+}
@@ -7,6 +7,7 @@ import (
 	"net/url"
 
 	"github.com/luno/jettison/errors"
+	"github.com/luno/jettison/j"
 )
 
 // KlineInterval represents the time interval aggregated per candlestick.
@@ -64,89 +65,433 @@ type KlinesRequest struct {
 
 // Kline contains kline / candlestick data.
 type Kline struct {
-	Close             string
-	CloseTime         int64
-	High              string
-	OpenTime          int64
-	Open              string
-	Low               string
-	QuoteassertVolume string
-	TradeCount        int64
-	Volume            string
+	Close            Decimal
+	CloseTime        int64
+	High             Decimal
+	OpenTime         int64
+	Open             Decimal
+	Low              Decimal
+	QuoteAssetVolume Decimal
+	TradeCount       int64
+	Volume           Decimal
 }
 
 // UnmarshalJSON satisfies the json.Unmarshaler interface for the Kline type.
 func (k *Kline) UnmarshalJSON(data []byte) error {
-	raw := make([]interface{}, 0)
+	raw := make([]json.RawMessage, 0, 12)
 	if err := json.Unmarshal(data, &raw); err != nil {
 		return err
 	}
 
-	var ok bool
-	if ot, ok := raw[0].(float64); ok {
-		k.OpenTime = int64(ot)
-	} else {
-		return fmt.Errorf("failed to assert %T to %T", raw[0], k.OpenTime)
+	fields := []interface{}{&k.OpenTime, &k.Open, &k.High, &k.Low, &k.Close,
+		&k.Volume, &k.CloseTime, &k.QuoteAssetVolume, &k.TradeCount}
+	if len(raw) < len(fields) {
+		return fmt.Errorf("expected at least %d fields in kline, got %d",
+			len(fields), len(raw))
 	}
 
-	if k.Open, ok = raw[1].(string); !ok {
-		return fmt.Errorf("failed to assert %T to %T", raw[0], k.Open)
+	for i, field := range fields {
+		if err := json.Unmarshal(raw[i], field); err != nil {
+			return fmt.Errorf("failed to parse kline field %d: %v", i, err)
+		}
 	}
 
-	if k.High, ok = raw[2].(string); !ok {
-		return fmt.Errorf("failed to assert %T to %T", raw[0], k.High)
-	}
+	return nil
+}
 
-	if k.Low, ok = raw[3].(string); !ok {
-		return fmt.Errorf("failed to assert %T to %T", raw[0], k.Low)
-	}
+// OrderBookRequest contains the parameters to query the order book of a
+// market.
+type OrderBookRequest struct {
+	// Limit represents the number of price levels to return on each side of
+	// the book. Valid limits are 5, 10, 20, 50, 100, 500, 1000 and 5000.
+	//
+	// Default: 100.
+	Limit int `schema:"limit,omitempty"`
 
-	if k.Close, ok = raw[4].(string); !ok {
-		return fmt.Errorf("failed to assert %T to %T", raw[0], k.Close)
-	}
+	// Symbol represents the market to query.
+	//
+	// Required.
+	Symbol string `schema:"symbol"`
+}
+
+// validOrderBookLimits contains the limits accepted by the order book
+// endpoint.
+var validOrderBookLimits = map[int]bool{
+	5: true, 10: true, 20: true, 50: true, 100: true, 500: true, 1000: true,
+	5000: true,
+}
 
-	if k.Volume, ok = raw[5].(string); !ok {
-		return fmt.Errorf("failed to assert %T to %T", raw[0], k.Volume)
+// Validate returns an error if the request would be rejected by the API.
+func (r *OrderBookRequest) Validate() error {
+	if r.Symbol == "" {
+		return errors.New("order book symbol is required")
 	}
 
-	if ct, ok := raw[6].(float64); ok {
-		k.CloseTime = int64(ct)
-	} else {
-		return fmt.Errorf("failed to assert %T to %T", raw[0], k.CloseTime)
+	if r.Limit != 0 && !validOrderBookLimits[r.Limit] {
+		return errors.New("invalid order book limit", j.KV("limit", r.Limit))
 	}
 
-	if k.QuoteassertVolume, ok = raw[7].(string); !ok {
-		return fmt.Errorf("failed to assert %T to %T", raw[0],
-			k.QuoteassertVolume)
+	return nil
+}
+
+// OrderBook contains the bids and asks of a market.
+type OrderBook struct {
+	// Asks contains the ask price levels, ordered from lowest to highest
+	// price.
+	Asks []PriceLevel `json:"asks"`
+
+	// Bids contains the bid price levels, ordered from highest to lowest
+	// price.
+	Bids []PriceLevel `json:"bids"`
+
+	// LastUpdateID represents the ID of the last update applied to the book.
+	LastUpdateID int64 `json:"lastUpdateId"`
+}
+
+// PriceLevel contains the total quantity available at a price in an order
+// book.
+type PriceLevel struct {
+	Price Decimal
+	Qty   Decimal
+}
+
+// UnmarshalJSON satisfies the json.Unmarshaler interface for the PriceLevel
+// type.
+func (l *PriceLevel) UnmarshalJSON(data []byte) error {
+	raw := make([]Decimal, 0, 2)
+	if err := json.Unmarshal(data, &raw); err != nil {
+		return err
 	}
 
-	if tc, ok := raw[8].(float64); ok {
-		k.TradeCount = int64(tc)
-	} else {
-		return fmt.Errorf("failed to assert %T to %T", raw[0], k.TradeCount)
+	if len(raw) != 2 {
+		return fmt.Errorf("expected 2 fields in price level, got %d", len(raw))
 	}
 
+	l.Price, l.Qty = raw[0], raw[1]
 	return nil
 }
 
+// MarshalJSON satisfies the json.Marshaler interface for the PriceLevel type,
+// encoding it as the API does.
+func (l PriceLevel) MarshalJSON() ([]byte, error) {
+	return json.Marshal([]Decimal{l.Price, l.Qty})
+}
+
+// RecentTradesRequest contains the parameters to query the most recent trades
+// of a market.
+type RecentTradesRequest struct {
+	// Limit represents the maximum amount of trades to query.
+	//
+	// Default: 500.
+	// Max: 1000.
+	Limit int64 `schema:"limit,omitempty"`
+
+	// Symbol represents the market to query.
+	//
+	// Required.
+	Symbol string `schema:"symbol"`
+}
+
+// HistoricalTradesRequest contains the parameters to query older trades of a
+// market.
+type HistoricalTradesRequest struct {
+	// FromID represents the trade ID to query from.
+	//
+	// Default: Returns the most recent trades.
+	FromID int64 `schema:"fromId,omitempty"`
+
+	// Limit represents the maximum amount of trades to query.
+	//
+	// Default: 500.
+	// Max: 1000.
+	Limit int64 `schema:"limit,omitempty"`
+
+	// Symbol represents the market to query.
+	//
+	// Required.
+	Symbol string `schema:"symbol"`
+}
+
+// Trade contains information about a single trade executed on a market.
+type Trade struct {
+	// ID represents the unique identifier of the trade.
+	ID int64 `json:"id"`
+
+	// IsBestMatch represents whether the trade was executed at the best
+	// price available.
+	IsBestMatch bool `json:"isBestMatch"`
+
+	// IsBuyerMaker represents whether the buyer's order was resting on the
+	// book when the trade was executed.
+	IsBuyerMaker bool `json:"isBuyerMaker"`
+
+	// Price represents the price the trade was executed at.
+	Price Decimal `json:"price"`
+
+	// Qty represents the quantity of the base asset traded.
+	Qty Decimal `json:"qty"`
+
+	// QuoteQty represents the quantity of the quote asset traded.
+	QuoteQty Decimal `json:"quoteQty"`
+
+	// Time represents the unix timestamp in milliseconds at which the trade
+	// was executed.
+	Time int64 `json:"time"`
+}
+
+// AggregateTradesRequest contains the parameters to query aggregated trades
+// of a market.
+type AggregateTradesRequest struct {
+	// EndTime represents the unix timestamp in milliseconds to query until.
+	//
+	// If StartTime and EndTime are both sent, they must be less than one
+	// hour apart.
+	EndTime int64 `schema:"endTime,omitempty"`
+
+	// FromID represents the aggregate trade ID to query from.
+	FromID int64 `schema:"fromId,omitempty"`
+
+	// Limit represents the maximum amount of aggregate trades to query.
+	//
+	// Default: 500.
+	// Max: 1000.
+	Limit int64 `schema:"limit,omitempty"`
+
+	// StartTime represents the unix timestamp in milliseconds to query from.
+	StartTime int64 `schema:"startTime,omitempty"`
+
+	// Symbol represents the market to query.
+	//
+	// Required.
+	Symbol string `schema:"symbol"`
+}
+
+// AggregateTrade contains trades that were filled at the same time, from the
+// same order and at the same price.
+type AggregateTrade struct {
+	// FirstTradeID represents the ID of the first trade in the aggregate.
+	FirstTradeID int64 `json:"f"`
+
+	// ID represents the unique identifier of the aggregate trade.
+	ID int64 `json:"a"`
+
+	// IsBestMatch represents whether the trades were executed at the best
+	// price available.
+	IsBestMatch bool `json:"M"`
+
+	// IsBuyerMaker represents whether the buyer's order was resting on the
+	// book when the trades were executed.
+	IsBuyerMaker bool `json:"m"`
+
+	// LastTradeID represents the ID of the last trade in the aggregate.
+	LastTradeID int64 `json:"l"`
+
+	// Price represents the price the trades were executed at.
+	Price Decimal `json:"p"`
+
+	// Qty represents the total quantity traded.
+	Qty Decimal `json:"q"`
+
+	// Time represents the unix timestamp in milliseconds at which the trades
+	// were executed.
+	Time int64 `json:"T"`
+}
+
 // OrderBookTicker contains the best price and quantity on an order book.
 type OrderBookTicker struct {
 	// AskPrice represents the lowest ask price in the order book.
-	AskPrice string `json:"askPrice"`
+	AskPrice Decimal `json:"askPrice"`
 
 	// AskQty represents the volume at the current ask price.
-	AskQty string `json:"askQty"`
+	AskQty Decimal `json:"askQty"`
 
 	// BidPrice represents the highest bid in the order book.
-	BidPrice string `json:"bidPrice"`
+	BidPrice Decimal `json:"bidPrice"`
 
 	// BidQty represents the volume at the current bid price.
-	BidQty string `json:"bidQty"`
+	BidQty Decimal `json:"bidQty"`
+
+	// Symbol represents the market queried.
+	Symbol string `json:"symbol"`
+}
+
+// TickerStats contains price change statistics of a market over a rolling 24
+// hour window.
+type TickerStats struct {
+	AskPrice           Decimal `json:"askPrice"`
+	AskQty             Decimal `json:"askQty"`
+	BidPrice           Decimal `json:"bidPrice"`
+	BidQty             Decimal `json:"bidQty"`
+	CloseTime          int64   `json:"closeTime"`
+	Count              int64   `json:"count"`
+	FirstID            int64   `json:"firstId"`
+	HighPrice          Decimal `json:"highPrice"`
+	LastID             int64   `json:"lastId"`
+	LastPrice          Decimal `json:"lastPrice"`
+	LastQty            Decimal `json:"lastQty"`
+	LowPrice           Decimal `json:"lowPrice"`
+	OpenPrice          Decimal `json:"openPrice"`
+	OpenTime           int64   `json:"openTime"`
+	PrevClosePrice     Decimal `json:"prevClosePrice"`
+	PriceChange        Decimal `json:"priceChange"`
+	PriceChangePercent Decimal `json:"priceChangePercent"`
+	QuoteVolume        Decimal `json:"quoteVolume"`
+	Symbol             string  `json:"symbol"`
+	Volume             Decimal `json:"volume"`
+	WeightedAvgPrice   Decimal `json:"weightedAvgPrice"`
+}
+
+// PriceTicker contains the latest price of a market.
+type PriceTicker struct {
+	// Price represents the price of the last trade.
+	Price Decimal `json:"price"`
 
 	// Symbol represents the market queried.
 	Symbol string `json:"symbol"`
 }
 
+// AveragePrice contains the average price of a market over a number of
+// minutes.
+type AveragePrice struct {
+	// Mins represents the number of minutes the average is calculated over.
+	Mins int `json:"mins"`
+
+	// Price represents the average price.
+	Price Decimal `json:"price"`
+}
+
+// tickerParams returns the query parameters for a ticker endpoint. An empty
+// list of symbols queries every market.
+func tickerParams(symbols []string) (url.Values, error) {
+	params := make(url.Values)
+	if len(symbols) == 0 {
+		return params, nil
+	}
+
+	if len(symbols) == 1 {
+		params.Set("symbol", symbols[0])
+		return params, nil
+	}
+
+	encoded, err := encodeSymbols(symbols)
+	if err != nil {
+		return nil, err
+	}
+
+	params.Set("symbols", encoded)
+	return params, nil
+}
+
+// getTickers performs a ticker query and decodes the response into `v`.
+func (c *client) getTickers(ctx context.Context, path string,
+	symbols []string, v interface{}) error {
+	params, err := tickerParams(symbols)
+	if err != nil {
+		return errors.Wrap(err, "failed to encode symbols")
+	}
+
+	if len(params) > 0 {
+		path = fmt.Sprintf("%s?%s", path, params.Encode())
+	}
+
+	res, err := c.get(ctx, path)
+	if err != nil {
+		return err
+	}
+
+	// A single symbol is returned as an object rather than a list.
+	if len(symbols) == 1 {
+		res = append(append([]byte("["), res...), ']')
+	}
+
+	return json.Unmarshal(res, v)
+}
+
+// TickerStats queries the 24 hour price change statistics of a market.
+func (c *client) TickerStats(ctx context.Context, symbol string) (
+	*TickerStats, error) {
+	var stats []TickerStats
+	err := c.getTickers(ctx, "/ticker/24hr", []string{symbol}, &stats)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to query ticker stats")
+	}
+
+	return &stats[0], nil
+}
+
+// ListTickerStats queries the 24 hour price change statistics of a list of
+// markets, or every market if no symbols are given.
+//
+// Querying every market carries a much larger request weight than querying a
+// list of symbols.
+func (c *client) ListTickerStats(ctx context.Context, symbols ...string) (
+	[]TickerStats, error) {
+	var stats []TickerStats
+	err := c.getTickers(ctx, "/ticker/24hr", symbols, &stats)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to query ticker stats")
+	}
+
+	return stats, nil
+}
+
+// PriceTicker queries the latest price of a market.
+func (c *client) PriceTicker(ctx context.Context, symbol string) (
+	*PriceTicker, error) {
+	var tickers []PriceTicker
+	err := c.getTickers(ctx, "/ticker/price", []string{symbol}, &tickers)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to query price ticker")
+	}
+
+	return &tickers[0], nil
+}
+
+// ListPriceTickers queries the latest price of a list of markets, or every
+// market if no symbols are given.
+func (c *client) ListPriceTickers(ctx context.Context, symbols ...string) (
+	[]PriceTicker, error) {
+	var tickers []PriceTicker
+	err := c.getTickers(ctx, "/ticker/price", symbols, &tickers)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to query price tickers")
+	}
+
+	return tickers, nil
+}
+
+// AveragePrice queries the current average price of a market.
+func (c *client) AveragePrice(ctx context.Context, symbol string) (
+	*AveragePrice, error) {
+	params := make(url.Values)
+	params.Set("symbol", symbol)
+
+	res, err := c.get(ctx, fmt.Sprintf("/avgPrice?%s", params.Encode()))
+	if err != nil {
+		return nil, err
+	}
+
+	var avgPrice AveragePrice
+	if err = json.Unmarshal(res, &avgPrice); err != nil {
+		return nil, errors.Wrap(err, "failed to parse average price")
+	}
+
+	return &avgPrice, nil
+}
+
+// ListOrderBookTickers queries the best price and quantity on the order books
+// of a list of markets, or every market if no symbols are given.
+func (c *client) ListOrderBookTickers(ctx context.Context,
+	symbols ...string) ([]OrderBookTicker, error) {
+	var tickers []OrderBookTicker
+	err := c.getTickers(ctx, "/ticker/bookTicker", symbols, &tickers)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to query order book tickers")
+	}
+
+	return tickers, nil
+}
+
 // Klines queries candlestick data.
 func (c *client) Klines(ctx context.Context, r *KlinesRequest) ([]Kline,
 	error) {
@@ -168,6 +513,98 @@ func (c *client) Klines(ctx context.Context, r *KlinesRequest) ([]Kline,
 	return klines, nil
 }
 
+// RecentTrades queries the most recent trades of a market.
+func (c *client) RecentTrades(ctx context.Context, r *RecentTradesRequest) (
+	[]Trade, error) {
+	params := make(url.Values)
+	if err := c.encoder.Encode(r, params); err != nil {
+		return nil, errors.Wrap(err, "failed to encode recent trades request")
+	}
+
+	res, err := c.get(ctx, fmt.Sprintf("/trades?%s", params.Encode()))
+	if err != nil {
+		return nil, err
+	}
+
+	var trades []Trade
+	if err = json.Unmarshal(res, &trades); err != nil {
+		return nil, errors.Wrap(err, "failed to parse recent trades")
+	}
+
+	return trades, nil
+}
+
+// HistoricalTrades queries older trades of a market.
+func (c *client) HistoricalTrades(ctx context.Context,
+	r *HistoricalTradesRequest) ([]Trade, error) {
+	params := make(url.Values)
+	if err := c.encoder.Encode(r, params); err != nil {
+		return nil, errors.Wrap(err,
+			"failed to encode historical trades request")
+	}
+
+	res, err := c.get(ctx, fmt.Sprintf("/historicalTrades?%s",
+		params.Encode()))
+	if err != nil {
+		return nil, err
+	}
+
+	var trades []Trade
+	if err = json.Unmarshal(res, &trades); err != nil {
+		return nil, errors.Wrap(err, "failed to parse historical trades")
+	}
+
+	return trades, nil
+}
+
+// AggregateTrades queries aggregated trades of a market.
+func (c *client) AggregateTrades(ctx context.Context,
+	r *AggregateTradesRequest) ([]AggregateTrade, error) {
+	params := make(url.Values)
+	if err := c.encoder.Encode(r, params); err != nil {
+		return nil, errors.Wrap(err,
+			"failed to encode aggregate trades request")
+	}
+
+	res, err := c.get(ctx, fmt.Sprintf("/aggTrades?%s", params.Encode()))
+	if err != nil {
+		return nil, err
+	}
+
+	var trades []AggregateTrade
+	if err = json.Unmarshal(res, &trades); err != nil {
+		return nil, errors.Wrap(err, "failed to parse aggregate trades")
+	}
+
+	return trades, nil
+}
+
+// OrderBook queries the bids and asks of a market.
+func (c *client) OrderBook(ctx context.Context, r *OrderBookRequest) (
+	*OrderBook, error) {
+	if err := r.Validate(); err != nil {
+		return nil, err
+	}
+
+	params := make(url.Values)
+	if err := c.encoder.Encode(r, params); err != nil {
+		return nil, errors.Wrap(err, "failed to encode order book request")
+	}
+
+	res, err := c.get(ctx, fmt.Sprintf("/depth?%s", params.Encode()))
+	if err != nil {
+		return nil, err
+	}
+
+	var book OrderBook
+	if err = json.Unmarshal(res, &book); err != nil {
+		return nil, errors.Wrap(err, "failed to parse order book")
+	}
+
+	return &book, nil
+}
+
+// OrderBookTicker queries the best price and quantity on an order book.
 func (c *client) OrderBookTicker(ctx context.Context, symbol string) (
 	*OrderBookTicker, error) {
 	params := make(url.Values)
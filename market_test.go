@@ -0,0 +1,254 @@
+package binance
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/url"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestOrderBook_OK(t *testing.T) {
+	srv, err := createTestServer(t, http.StatusOK)
+	require.NoError(t, err)
+	defer srv.Close()
+
+	c := NewClient(WithBaseURL(srv.URL))
+	book, err := c.OrderBook(context.Background(), &OrderBookRequest{
+		Symbol: "LTCBTC",
+		Limit:  5,
+	})
+	require.NoError(t, err)
+	require.Equal(t, int64(1027024), book.LastUpdateID)
+	require.Equal(t, []PriceLevel{{Price: MustParseDecimal("4.00000000"), Qty: MustParseDecimal("431.00000000")}},
+		book.Bids)
+	require.Equal(t, []PriceLevel{{Price: MustParseDecimal("4.00000200"), Qty: MustParseDecimal("12.00000000")}},
+		book.Asks)
+}
+
+func TestOrderBookRequestValidate(t *testing.T) {
+	tests := []struct {
+		req   OrderBookRequest
+		valid bool
+	}{
+		{OrderBookRequest{Symbol: "LTCBTC"}, true},
+		{OrderBookRequest{Symbol: "LTCBTC", Limit: 5}, true},
+		{OrderBookRequest{Symbol: "LTCBTC", Limit: 5000}, true},
+		{OrderBookRequest{Symbol: "LTCBTC", Limit: 7}, false},
+		{OrderBookRequest{Symbol: "LTCBTC", Limit: 10000}, false},
+		{OrderBookRequest{Limit: 5}, false},
+	}
+
+	for i, test := range tests {
+		t.Run(fmt.Sprintf("%d", i), func(t *testing.T) {
+			err := test.req.Validate()
+			if test.valid {
+				require.NoError(t, err)
+			} else {
+				require.Error(t, err)
+			}
+		})
+	}
+}
+
+func TestRecentTrades_OK(t *testing.T) {
+	srv, err := createTestServer(t, http.StatusOK)
+	require.NoError(t, err)
+	defer srv.Close()
+
+	c := NewClient(WithBaseURL(srv.URL))
+	trades, err := c.RecentTrades(context.Background(), &RecentTradesRequest{
+		Symbol: "LTCBTC",
+	})
+	require.NoError(t, err)
+	require.Equal(t, []Trade{{
+		ID:           28457,
+		IsBestMatch:  true,
+		IsBuyerMaker: true,
+		Price:        MustParseDecimal("4.00000100"),
+		Qty:          MustParseDecimal("12.00000000"),
+		QuoteQty:     MustParseDecimal("48.000012"),
+		Time:         1499865549590,
+	}}, trades)
+}
+
+func TestHistoricalTrades_OK(t *testing.T) {
+	testHistoricalTrades(t, &HistoricalTradesRequest{Symbol: "LTCBTC"},
+		url.Values{"symbol": {"LTCBTC"}})
+}
+
+func TestHistoricalTradesFrom_OK(t *testing.T) {
+	testHistoricalTrades(t, &HistoricalTradesRequest{
+		Symbol: "LTCBTC",
+		FromID: 28457,
+	}, url.Values{"symbol": {"LTCBTC"}, "fromId": {"28457"}})
+}
+
+func testHistoricalTrades(t *testing.T, r *HistoricalTradesRequest,
+	expectedQuery url.Values) {
+	var query url.Values
+	srv, err := createQueryTestServer(t, http.StatusOK, &query)
+	require.NoError(t, err)
+	defer srv.Close()
+
+	c := NewClient(WithBaseURL(srv.URL))
+	trades, err := c.HistoricalTrades(context.Background(), r)
+	require.NoError(t, err)
+	require.Equal(t, expectedQuery, query)
+	require.Len(t, trades, 1)
+	require.Equal(t, int64(28457), trades[0].ID)
+	require.Equal(t, "48.000012", trades[0].QuoteQty.String())
+}
+
+func TestAggregateTrades_OK(t *testing.T) {
+	testAggregateTrades(t, &AggregateTradesRequest{Symbol: "LTCBTC"},
+		url.Values{"symbol": {"LTCBTC"}})
+}
+
+func TestAggregateTradesFrom_OK(t *testing.T) {
+	testAggregateTrades(t, &AggregateTradesRequest{
+		Symbol: "LTCBTC",
+		FromID: 26129,
+	}, url.Values{"symbol": {"LTCBTC"}, "fromId": {"26129"}})
+}
+
+func TestAggregateTradesAfter_OK(t *testing.T) {
+	testAggregateTrades(t, &AggregateTradesRequest{
+		Symbol:    "LTCBTC",
+		StartTime: 1498793709000,
+		Limit:     1,
+	}, url.Values{"symbol": {"LTCBTC"}, "startTime": {"1498793709000"},
+		"limit": {"1"}})
+}
+
+func TestAggregateTradesBetween_OK(t *testing.T) {
+	testAggregateTrades(t, &AggregateTradesRequest{
+		Symbol:    "LTCBTC",
+		StartTime: 1498793709000,
+		EndTime:   1498793710000,
+	}, url.Values{"symbol": {"LTCBTC"}, "startTime": {"1498793709000"},
+		"endTime": {"1498793710000"}})
+}
+
+func testAggregateTrades(t *testing.T, r *AggregateTradesRequest,
+	expectedQuery url.Values) {
+	var query url.Values
+	srv, err := createQueryTestServer(t, http.StatusOK, &query)
+	require.NoError(t, err)
+	defer srv.Close()
+
+	c := NewClient(WithBaseURL(srv.URL))
+	trades, err := c.AggregateTrades(context.Background(), r)
+	require.NoError(t, err)
+	require.Equal(t, expectedQuery, query)
+	require.Equal(t, []AggregateTrade{{
+		FirstTradeID: 27781,
+		ID:           26129,
+		IsBestMatch:  true,
+		IsBuyerMaker: true,
+		LastTradeID:  27781,
+		Price:        MustParseDecimal("0.01633102"),
+		Qty:          MustParseDecimal("4.70443515"),
+		Time:         1498793709153,
+	}}, trades)
+}
+
+func TestTickerStats_OK(t *testing.T) {
+	srv, err := createTestServer(t, http.StatusOK)
+	require.NoError(t, err)
+	defer srv.Close()
+
+	c := NewClient(WithBaseURL(srv.URL))
+	stats, err := c.TickerStats(context.Background(), "BNBBTC")
+	require.NoError(t, err)
+	require.Equal(t, "BNBBTC", stats.Symbol)
+	require.Equal(t, "-95.960", stats.PriceChangePercent.String())
+	require.Equal(t, int64(76), stats.Count)
+}
+
+func TestListTickerStats_OK(t *testing.T) {
+	srv, err := createTestServer(t, http.StatusOK)
+	require.NoError(t, err)
+	defer srv.Close()
+
+	c := NewClient(WithBaseURL(srv.URL))
+	stats, err := c.ListTickerStats(context.Background())
+	require.NoError(t, err)
+	require.Len(t, stats, 1)
+	require.Equal(t, "BNBBTC", stats[0].Symbol)
+}
+
+func TestPriceTicker_OK(t *testing.T) {
+	srv, err := createTestServer(t, http.StatusOK)
+	require.NoError(t, err)
+	defer srv.Close()
+
+	c := NewClient(WithBaseURL(srv.URL))
+	ticker, err := c.PriceTicker(context.Background(), "LTCBTC")
+	require.NoError(t, err)
+	require.Equal(t, &PriceTicker{Price: MustParseDecimal("4.00000200"), Symbol: "LTCBTC"},
+		ticker)
+}
+
+func TestListPriceTickers_OK(t *testing.T) {
+	srv, err := createTestServer(t, http.StatusOK)
+	require.NoError(t, err)
+	defer srv.Close()
+
+	c := NewClient(WithBaseURL(srv.URL))
+	tickers, err := c.ListPriceTickers(context.Background(), "LTCBTC",
+		"ETHBTC")
+	require.NoError(t, err)
+	require.Equal(t, []PriceTicker{
+		{Price: MustParseDecimal("4.00000200"), Symbol: "LTCBTC"},
+		{Price: MustParseDecimal("0.07946600"), Symbol: "ETHBTC"},
+	}, tickers)
+}
+
+func TestAveragePrice_OK(t *testing.T) {
+	srv, err := createTestServer(t, http.StatusOK)
+	require.NoError(t, err)
+	defer srv.Close()
+
+	c := NewClient(WithBaseURL(srv.URL))
+	avgPrice, err := c.AveragePrice(context.Background(), "LTCBTC")
+	require.NoError(t, err)
+	require.Equal(t, &AveragePrice{Mins: 5, Price: MustParseDecimal("9.35751834")}, avgPrice)
+}
+
+func TestListOrderBookTickers_OK(t *testing.T) {
+	srv, err := createTestServer(t, http.StatusOK)
+	require.NoError(t, err)
+	defer srv.Close()
+
+	c := NewClient(WithBaseURL(srv.URL))
+	tickers, err := c.ListOrderBookTickers(context.Background())
+	require.NoError(t, err)
+	require.Len(t, tickers, 2)
+	require.Equal(t, "ETHBTC", tickers[1].Symbol)
+	require.Equal(t, "100000.00000000", tickers[1].AskPrice.String())
+}
+
+func TestTickerParams(t *testing.T) {
+	tests := []struct {
+		symbols []string
+		query   string
+	}{
+		{nil, ""},
+		{[]string{"LTCBTC"}, "symbol=LTCBTC"},
+		{
+			[]string{"LTCBTC", "ETHBTC"},
+			"symbols=%5B%22LTCBTC%22%2C%22ETHBTC%22%5D",
+		},
+	}
+
+	for i, test := range tests {
+		t.Run(fmt.Sprintf("%d", i), func(t *testing.T) {
+			params, err := tickerParams(test.symbols)
+			require.NoError(t, err)
+			require.Equal(t, test.query, params.Encode())
+		})
+	}
+}
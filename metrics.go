@@ -1,32 +1,201 @@
 package binance
 
-import "github.com/prometheus/client_golang/prometheus"
+import (
+	"fmt"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
 
-func init() {
-	prometheus.MustRegister(httpRequestLatencyHist)
-	prometheus.MustRegister(httpResponseCodesCounter)
-	prometheus.MustRegister(clientErrorsCounter)
+	"github.com/luno/jettison/errors"
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+// metrics contains the collectors a Client records its activity with.
+type metrics struct {
+	errors         *prometheus.CounterVec
+	orderCount     *prometheus.GaugeVec
+	orders         *prometheus.CounterVec
+	requestLatency *prometheus.HistogramVec
+	responseCodes  *prometheus.CounterVec
+	retries        *prometheus.CounterVec
+	usedWeight     *prometheus.GaugeVec
+}
+
+// newMetrics returns the collectors of a Client, registered with `reg` unless
+// it is nil. Collectors which are already registered, for example by another
+// Client, are shared. If a collector conflicts with one registered by other
+// code, an error is returned along with metrics that are still safe to use
+// but are not exported.
+func newMetrics(reg prometheus.Registerer) (*metrics, error) {
+	m := metrics{
+		errors: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: "binance",
+			Subsystem: "client",
+			Name:      "errors_count",
+			Help:      "Client errors counter, by API error code and category",
+		}, []string{"code", "category"}),
+
+		orderCount: prometheus.NewGaugeVec(prometheus.GaugeOpts{
+			Namespace: "binance",
+			Subsystem: "client",
+			Name:      "order_count",
+			Help:      "Orders placed in the current interval, as reported by the exchange",
+		}, []string{"interval"}),
+
+		orders: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: "binance",
+			Subsystem: "client",
+			Name:      "orders_count",
+			Help:      "Placed and cancelled orders counter, by order status",
+		}, []string{"status"}),
+
+		requestLatency: prometheus.NewHistogramVec(prometheus.HistogramOpts{
+			Namespace: "binance",
+			Subsystem: "http",
+			Name:      "request_latency",
+			Help:      "HTTP request latency in seconds",
+		}, []string{"path"}),
+
+		responseCodes: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: "binance",
+			Subsystem: "http",
+			Name:      "response_codes_count",
+			Help:      "HTTP response code counter",
+		}, []string{"path", "code"}),
+
+		retries: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: "binance",
+			Subsystem: "client",
+			Name:      "retries_count",
+			Help:      "Retried requests counter",
+		}, []string{"path"}),
+
+		usedWeight: prometheus.NewGaugeVec(prometheus.GaugeOpts{
+			Namespace: "binance",
+			Subsystem: "client",
+			Name:      "used_weight",
+			Help:      "Request weight used in the current interval, as reported by the exchange",
+		}, []string{"interval"}),
+	}
+
+	if reg == nil {
+		return &m, nil
+	}
+
+	var firstErr error
+	register := func(c prometheus.Collector) prometheus.Collector {
+		err := reg.Register(c)
+		if err == nil {
+			return c
+		}
+
+		var are prometheus.AlreadyRegisteredError
+		if errors.As(err, &are) {
+			return are.ExistingCollector
+		}
+
+		if firstErr == nil {
+			firstErr = errors.Wrap(err, "failed to register metrics")
+		}
+		return c
+	}
+
+	// A collector registered by another Client has the same type, unless it
+	// was registered by a different version of this package, in which case
+	// the new collector is kept.
+	if c, ok := register(m.errors).(*prometheus.CounterVec); ok {
+		m.errors = c
+	}
+	if c, ok := register(m.orderCount).(*prometheus.GaugeVec); ok {
+		m.orderCount = c
+	}
+	if c, ok := register(m.orders).(*prometheus.CounterVec); ok {
+		m.orders = c
+	}
+	if c, ok := register(m.requestLatency).(*prometheus.HistogramVec); ok {
+		m.requestLatency = c
+	}
+	if c, ok := register(m.responseCodes).(*prometheus.CounterVec); ok {
+		m.responseCodes = c
+	}
+	if c, ok := register(m.retries).(*prometheus.CounterVec); ok {
+		m.retries = c
+	}
+	if c, ok := register(m.usedWeight).(*prometheus.GaugeVec); ok {
+		m.usedWeight = c
+	}
+
+	return &m, firstErr
+}
+
+// observeResponse records the latency, status code and reported usage of a
+// response to a request for `path`.
+func (m *metrics) observeResponse(path string, res *http.Response,
+	latencySeconds float64) {
+	m.requestLatency.WithLabelValues(path).Observe(latencySeconds)
+	m.responseCodes.WithLabelValues(path, fmt.Sprintf("%d",
+		res.StatusCode)).Inc()
+
+	for key, values := range res.Header {
+		var gauge *prometheus.GaugeVec
+		switch {
+		case strings.HasPrefix(key, headerUsedWeight):
+			gauge = m.usedWeight
+		case strings.HasPrefix(key, headerOrderCount):
+			gauge = m.orderCount
+		default:
+			continue
+		}
+
+		used, err := strconv.ParseFloat(values[0], 64)
+		if err != nil {
+			continue
+		}
+
+		// Header keys are canonicalized, so "1M" arrives as "1m".
+		interval := strings.ToUpper(key[strings.LastIndex(key, "-")+1:])
+		gauge.WithLabelValues(interval).Set(used)
+	}
 }
 
-var (
-	httpRequestLatencyHist = prometheus.NewHistogramVec(prometheus.HistogramOpts{
-		Namespace: "binance",
-		Subsystem: "http",
-		Name:      "request_latency",
-		Help:      "HTTP request latency in seconds",
-	}, []string{"path"})
-
-	httpResponseCodesCounter = prometheus.NewCounterVec(prometheus.CounterOpts{
-		Namespace: "binance",
-		Subsystem: "http",
-		Name:      "response_codes_count",
-		Help:      "HTTP response code counter",
-	}, []string{"path", "code"})
-
-	clientErrorsCounter = prometheus.NewCounterVec(prometheus.CounterOpts{
-		Namespace: "binance",
-		Subsystem: "client",
-		Name:      "errors_count",
-		Help:      "Client errors counter",
-	}, []string{"error"})
+// observeError records a failed request.
+func (m *metrics) observeError(err error) {
+	code, category := errorLabels(err)
+	m.errors.WithLabelValues(code, category).Inc()
+}
+
+// observeOrder records the outcome of placing or cancelling an order. Orders
+// placed with an ACK response have no status, and aren't recorded.
+func (m *metrics) observeOrder(status OrderStatus) {
+	if status != "" {
+		m.orders.WithLabelValues(string(status)).Inc()
+	}
+}
+
+// Categories of errors that aren't returned by the API, used to label the
+// error metric.
+const (
+	errorCategoryClient    = "client"
+	errorCategoryRateLimit = "rate_limit"
+	errorCategoryTransport = "transport"
 )
+
+// errorLabels returns the code and category that `err` is counted under.
+// Errors that weren't returned by the API have an empty code.
+func errorLabels(err error) (code, category string) {
+	var apiErr Error
+	if errors.As(err, &apiErr) {
+		return fmt.Sprintf("%d", apiErr.Code), string(apiErr.Code.Category())
+	}
+
+	var urlErr *url.Error
+	switch {
+	case errors.Is(err, ErrRateLimitExceeded):
+		return "", errorCategoryRateLimit
+	case errors.As(err, &urlErr):
+		return "", errorCategoryTransport
+	default:
+		return "", errorCategoryClient
+	}
+}
@@ -0,0 +1,183 @@
+package binance
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+	"time"
+
+	"github.com/luno/jettison/errors"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/stretchr/testify/require"
+)
+
+func TestMetrics(t *testing.T) {
+	h := func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("X-MBX-USED-WEIGHT-1M", "42")
+		w.Header().Set("X-MBX-ORDER-COUNT-10S", "3")
+
+		switch r.URL.Path {
+		case "/order":
+			if r.Method == http.MethodDelete {
+				w.Write([]byte(`{"status":"CANCELED"}`))
+				return
+			}
+			if r.FormValue("symbol") == "BTCUSDT" {
+				w.Write([]byte(`{"status":"FILLED"}`))
+				return
+			}
+			w.WriteHeader(http.StatusBadRequest)
+			w.Write([]byte(`{"code":-2010,"msg":"Order rejected."}`))
+		case "/time":
+			w.WriteHeader(http.StatusBadGateway)
+		default:
+			w.WriteHeader(http.StatusBadRequest)
+			w.Write([]byte(`{"code":-1121,"msg":"Invalid symbol."}`))
+		}
+	}
+
+	srv := httptest.NewServer(http.HandlerFunc(h))
+	defer srv.Close()
+
+	reg := prometheus.NewRegistry()
+	opts := []ClientOption{
+		WithBaseURL(srv.URL),
+		WithMetrics(reg),
+		WithRetryPolicy(RetryPolicy{MaxAttempts: 2}),
+	}
+
+	// Clients sharing a registry share their metrics.
+	NewClient(opts...)
+	c := NewClient(opts...)
+	m := c.(*client).metrics
+	ctx := context.Background()
+
+	_, err := c.NewOrder(ctx, &NewOrderRequest{Symbol: "BTCUSDT"})
+	require.NoError(t, err)
+
+	_, err = c.NewOrder(ctx, &NewOrderRequest{Symbol: "ETHBTC"})
+	require.True(t, IsError(err, ErrNewOrderRejected))
+
+	_, err = c.CancelOrder(ctx, &CancelOrderRequest{Symbol: "BTCUSDT"})
+	require.NoError(t, err)
+
+	_, err = c.PriceTicker(ctx, "HSDBTC")
+	require.True(t, IsError(err, ErrBadSymbol))
+
+	_, err = c.ServerTime(ctx)
+	require.Error(t, err)
+
+	for status, count := range map[OrderStatus]float64{
+		OrderStatusCancelled: 1,
+		OrderStatusFilled:    1,
+		OrderStatusRejected:  1,
+	} {
+		require.Equal(t, count, testutil.ToFloat64(
+			m.orders.WithLabelValues(string(status))), status)
+	}
+
+	require.Equal(t, float64(1), testutil.ToFloat64(
+		m.errors.WithLabelValues("-2010", "processing")))
+	require.Equal(t, float64(1), testutil.ToFloat64(
+		m.errors.WithLabelValues("-1121", "request")))
+	require.Equal(t, float64(1), testutil.ToFloat64(
+		m.errors.WithLabelValues("", "client")))
+
+	require.Equal(t, float64(1), testutil.ToFloat64(
+		m.retries.WithLabelValues("/time")))
+	require.Equal(t, float64(42), testutil.ToFloat64(
+		m.usedWeight.WithLabelValues("1M")))
+	require.Equal(t, float64(3), testutil.ToFloat64(
+		m.orderCount.WithLabelValues("10S")))
+
+	families, err := reg.Gather()
+	require.NoError(t, err)
+	require.NotEmpty(t, families)
+}
+
+func TestMetrics_Conflict(t *testing.T) {
+	reg := prometheus.NewRegistry()
+	reg.MustRegister(prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: "binance",
+		Subsystem: "client",
+		Name:      "errors_count",
+		Help:      "Client errors counter",
+	}, []string{"error"}))
+
+	m, err := newMetrics(reg)
+	require.Error(t, err)
+
+	// The conflicting collector still records, but isn't exported.
+	m.observeError(Error{Code: ErrBadSymbol})
+	require.Equal(t, float64(1), testutil.ToFloat64(
+		m.errors.WithLabelValues("-1121", "request")))
+
+	_, err = newMetrics(nil)
+	require.NoError(t, err)
+}
+
+func TestErrorLabels(t *testing.T) {
+	tests := []struct {
+		err      error
+		code     string
+		category string
+	}{
+		{
+			err:      Error{Code: ErrTimeout},
+			code:     "-1007",
+			category: "server",
+		},
+		{
+			err:      errors.Wrap(Error{Code: ErrInvalidTIF}, "wrapped"),
+			code:     "-1115",
+			category: "request",
+		},
+		{
+			err:      Error{Code: ErrNoSuchOrder},
+			code:     "-2013",
+			category: "processing",
+		},
+		{
+			err:      Error{Code: -9000},
+			code:     "-9000",
+			category: "unknown",
+		},
+		{
+			err:      errors.Wrap(ErrRateLimitExceeded, "wait"),
+			category: "rate_limit",
+		},
+		{
+			err: errors.Wrap(&url.Error{Op: "Get", URL: "/",
+				Err: context.DeadlineExceeded}, "failed"),
+			category: "transport",
+		},
+		{
+			err:      errors.New("failed to parse"),
+			category: "client",
+		},
+	}
+
+	for i, test := range tests {
+		t.Run(fmt.Sprintf("%d", i), func(t *testing.T) {
+			code, category := errorLabels(test.err)
+			require.Equal(t, test.code, code)
+			require.Equal(t, test.category, category)
+		})
+	}
+}
+
+func TestMetrics_InvalidUsageHeader(t *testing.T) {
+	reg := prometheus.NewRegistry()
+	m, err := newMetrics(reg)
+	require.NoError(t, err)
+
+	res := &http.Response{StatusCode: http.StatusOK, Header: http.Header{
+		"X-Mbx-Used-Weight-1m": []string{"not a number"},
+	}}
+	m.observeResponse("/api/v3/ping", res, time.Millisecond.Seconds())
+	require.Equal(t, 0, testutil.CollectAndCount(m.usedWeight))
+}
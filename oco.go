@@ -0,0 +1,407 @@
+package binance
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/url"
+
+	"github.com/luno/jettison/errors"
+)
+
+// ContingencyType describes how the orders in an order list relate to each
+// other.
+type ContingencyType string
+
+// Enumerated types for ContingencyType.
+const (
+	// ContingencyTypeOCO indicates a one-cancels-the-other order list.
+	ContingencyTypeOCO ContingencyType = "OCO"
+)
+
+// ListStatusType describes the status of an order list as a whole.
+type ListStatusType string
+
+const (
+	// ListStatusTypeResponse is used when the order list is a response to a
+	// failed action, for example a rejected placement or cancel.
+	ListStatusTypeResponse ListStatusType = "RESPONSE"
+
+	// ListStatusTypeExecStarted indicates an order list that has been placed
+	// or updated.
+	ListStatusTypeExecStarted ListStatusType = "EXEC_STARTED"
+
+	// ListStatusTypeAllDone indicates an order list that has finished
+	// executing and is no longer active.
+	ListStatusTypeAllDone ListStatusType = "ALL_DONE"
+)
+
+// ListOrderStatus describes the status of the orders in an order list.
+type ListOrderStatus string
+
+const (
+	// ListOrderStatusExecuting indicates that the order list has been placed
+	// or updated.
+	ListOrderStatusExecuting ListOrderStatus = "EXECUTING"
+
+	// ListOrderStatusAllDone indicates that the order list has finished
+	// executing and is no longer active.
+	ListOrderStatusAllDone ListOrderStatus = "ALL_DONE"
+
+	// ListOrderStatusReject indicates that the order list was rejected,
+	// either on placement or cancellation.
+	ListOrderStatusReject ListOrderStatus = "REJECT"
+)
+
+// NewOCORequest contains the parameters for placing a one-cancels-the-other
+// order list. An OCO pairs a LIMIT_MAKER order with a STOP_LOSS or
+// STOP_LOSS_LIMIT order, and cancels the other when either one executes.
+type NewOCORequest struct {
+	// LimitClientOrderID represents a unique identifier for the limit order.
+	//
+	// Optional.
+	LimitClientOrderID string `schema:"limitClientOrderId,omitempty"`
+
+	// LimitIcebergQty makes the limit order an iceberg order.
+	//
+	// Optional.
+	LimitIcebergQty Decimal `schema:"limitIcebergQty,omitempty"`
+
+	// ListClientOrderID represents a unique identifier for the order list.
+	//
+	// Optional.
+	ListClientOrderID string `schema:"listClientOrderId,omitempty"`
+
+	// Price represents the price of the limit order.
+	//
+	// Required.
+	Price Decimal `schema:"price"`
+
+	// Qty represents the quantity of both orders.
+	//
+	// Required.
+	Qty Decimal `schema:"quantity"`
+
+	// ReceiveWindow represents the duration of validity in ms of the request.
+	//
+	// Optional.
+	// Default: 5000ms. Maximum: 60000ms.
+	ReceiveWindow int64 `schema:"recvWindow,omitempty"`
+
+	// ResponseType represents the kind of response you want to receive back.
+	//
+	// Optional.
+	ResponseType OrderResponseType `schema:"newOrderRespType,omitempty"`
+
+	// Side represents whether the orders are buys or sells.
+	//
+	// Required.
+	Side OrderSide `schema:"side"`
+
+	// StopClientOrderID represents a unique identifier for the stop order.
+	//
+	// Optional.
+	StopClientOrderID string `schema:"stopClientOrderId,omitempty"`
+
+	// StopIcebergQty makes the stop order an iceberg order. Requires
+	// StopLimitPrice to be set.
+	//
+	// Optional.
+	StopIcebergQty Decimal `schema:"stopIcebergQty,omitempty"`
+
+	// StopLimitPrice makes the stop order a STOP_LOSS_LIMIT order placed at
+	// this price. If not sent, the stop order is a STOP_LOSS order.
+	//
+	// Optional.
+	StopLimitPrice Decimal `schema:"stopLimitPrice,omitempty"`
+
+	// StopLimitTimeInForce represents the duration of validity of the stop
+	// limit order.
+	//
+	// Required if StopLimitPrice is sent.
+	StopLimitTimeInForce TimeInForce `schema:"stopLimitTimeInForce,omitempty"`
+
+	// StopPrice represents the price the market needs to reach before the
+	// stop order is triggered.
+	//
+	// Required.
+	StopPrice Decimal `schema:"stopPrice"`
+
+	// Symbol represents the market to place the orders on.
+	//
+	// Required.
+	Symbol string `schema:"symbol"`
+}
+
+// CancelOCORequest contains the parameters for cancelling an entire order
+// list.
+type CancelOCORequest struct {
+	// ListClientOrderID is the unique identifier provided by the client on
+	// order list creation.
+	//
+	// Either OrderListID or ListClientOrderID must be sent.
+	ListClientOrderID string `schema:"listClientOrderId,omitempty"`
+
+	// NewClientOrderID represents the unique identifier for this cancel.
+	// Randomly generated string if not provided.
+	NewClientOrderID string `schema:"newClientOrderId,omitempty"`
+
+	// OrderListID represents the unique identifier provided by Binance on
+	// order list creation.
+	//
+	// Either OrderListID or ListClientOrderID must be sent.
+	OrderListID int64 `schema:"orderListId,omitempty"`
+
+	// Symbol represents the market the order list was placed on.
+	//
+	// Required.
+	Symbol string `schema:"symbol"`
+}
+
+// QueryOCORequest contains the parameters for querying an existing order
+// list.
+type QueryOCORequest struct {
+	// OrderListID represents the unique identifier provided by Binance on
+	// order list creation.
+	//
+	// Either OrderListID or OrigClientOrderID must be sent.
+	OrderListID int64 `schema:"orderListId,omitempty"`
+
+	// OrigClientOrderID is the unique identifier provided by the client on
+	// order list creation.
+	//
+	// Either OrderListID or OrigClientOrderID must be sent.
+	OrigClientOrderID string `schema:"origClientOrderId,omitempty"`
+}
+
+// AllOCORequest contains the parameters for querying the history of order
+// lists.
+type AllOCORequest struct {
+	// EndTime represents the unix timestamp in milliseconds to query until.
+	EndTime int64 `schema:"endTime,omitempty"`
+
+	// FromID represents the order list ID to query from. Cannot be sent with
+	// StartTime or EndTime.
+	FromID int64 `schema:"fromId,omitempty"`
+
+	// Limit represents the maximum amount of order lists to query.
+	//
+	// Default: 500.
+	// Max: 1000.
+	Limit int64 `schema:"limit,omitempty"`
+
+	// StartTime represents the unix timestamp in milliseconds to query from.
+	StartTime int64 `schema:"startTime,omitempty"`
+}
+
+// OrderList contains information about an order list such as an OCO.
+type OrderList struct {
+	// ContingencyType represents how the orders in the list relate.
+	ContingencyType ContingencyType `json:"contingencyType"`
+
+	// ListClientOrderID represents the unique identifier provided by the
+	// client on order list creation.
+	ListClientOrderID string `json:"listClientOrderId"`
+
+	// ListOrderStatus represents the status of the orders in the list.
+	ListOrderStatus ListOrderStatus `json:"listOrderStatus"`
+
+	// ListStatusType represents the status of the list as a whole.
+	ListStatusType ListStatusType `json:"listStatusType"`
+
+	// OrderListID represents the unique identifier provided by Binance on
+	// order list creation.
+	OrderListID int64 `json:"orderListId"`
+
+	// OrderReports contains the state of each order in the list.
+	//
+	// Only returned when placing or cancelling an order list.
+	OrderReports []OrderReport `json:"orderReports,omitempty"`
+
+	// Orders contains the identifiers of each order in the list.
+	Orders []OrderListEntry `json:"orders"`
+
+	// Symbol represents the market the order list was placed on.
+	Symbol string `json:"symbol"`
+
+	// TransactionTime represents the unix timestamp in milliseconds of the
+	// last update to the order list.
+	TransactionTime int64 `json:"transactionTime"`
+}
+
+// OrderListEntry identifies an order that is part of an order list.
+type OrderListEntry struct {
+	ClientOrderID string `json:"clientOrderId"`
+	OrderID       int64  `json:"orderId"`
+	Symbol        string `json:"symbol"`
+}
+
+// OrderReport contains the state of an order that is part of an order list.
+type OrderReport struct {
+	// ClientOrderID represents the unique identifier provided by the client on
+	// order creation.
+	ClientOrderID       string  `json:"clientOrderId"`
+	CummulativeQuoteQty Decimal `json:"cummulativeQuoteQty"`
+
+	// ExecutedQty represents how much of the original quantity has been
+	// executed.
+	ExecutedQty Decimal `json:"executedQty"`
+
+	// IcebergQty represents the maximum amount per sub-order until the total
+	// quantity of the order has been filled.
+	IcebergQty Decimal `json:"icebergQty,omitempty"`
+
+	// OrderID represents the unique identifier provided by Binance on order
+	// creation.
+	OrderID int64 `json:"orderId"`
+
+	// OrderListID represents the order list the order is part of.
+	OrderListID int64 `json:"orderListId"`
+
+	// OrigClientOrderID represents the client order ID of the order before
+	// it was cancelled.
+	//
+	// Only returned when cancelling an order list.
+	OrigClientOrderID string `json:"origClientOrderId,omitempty"`
+
+	// OriginalQty represents the original amount the order was placed for.
+	OriginalQty Decimal `json:"origQty"`
+
+	// Price represents the price that the order was placed at.
+	Price Decimal `json:"price"`
+
+	// Side represents whether the order was a buy or sell.
+	Side OrderSide `json:"side"`
+
+	// Status represents the current status of the order.
+	Status OrderStatus `json:"status"`
+
+	// StopPrice represents the price the market needs to reach before the
+	// order is triggered.
+	StopPrice Decimal `json:"stopPrice,omitempty"`
+
+	// Symbol represents the market the order was placed on.
+	Symbol string `json:"symbol"`
+
+	// TimeInForce represents the duration of validity of the order.
+	TimeInForce TimeInForce `json:"timeInForce"`
+
+	// TransactTime represents the unix timestamp in milliseconds of the
+	// last update to the order.
+	TransactTime int64 `json:"transactTime"`
+
+	// Type represents the type of the order.
+	Type OrderType `json:"type"`
+}
+
+// NewOCO places a new one-cancels-the-other order list on the exchange.
+func (c *client) NewOCO(ctx context.Context, r *NewOCORequest) (*OrderList,
+	error) {
+	params := make(url.Values)
+	if err := c.encoder.Encode(r, params); err != nil {
+		return nil, errors.Wrap(err, "failed to encode new oco request")
+	}
+
+	res, err := c.post(ctx, "/order/oco", []byte(params.Encode()))
+	if err != nil {
+		return nil, err
+	}
+
+	var orderList OrderList
+	if err = json.Unmarshal(res, &orderList); err != nil {
+		return nil, errors.Wrap(err, "failed to parse new oco response")
+	}
+
+	for _, o := range orderList.OrderReports {
+		c.metrics.observeOrder(o.Status)
+	}
+
+	return &orderList, nil
+}
+
+// CancelOCO cancels an entire order list.
+func (c *client) CancelOCO(ctx context.Context, r *CancelOCORequest) (
+	*OrderList, error) {
+	params := make(url.Values)
+	if err := c.encoder.Encode(r, params); err != nil {
+		return nil, errors.Wrap(err, "failed to encode cancel oco request")
+	}
+
+	res, err := c.delete(ctx, "/orderList", []byte(params.Encode()))
+	if err != nil {
+		return nil, err
+	}
+
+	var orderList OrderList
+	if err = json.Unmarshal(res, &orderList); err != nil {
+		return nil, errors.Wrap(err, "failed to parse cancel oco response")
+	}
+
+	for _, o := range orderList.OrderReports {
+		c.metrics.observeOrder(o.Status)
+	}
+
+	return &orderList, nil
+}
+
+// QueryOCO searches for an order list and returns it.
+func (c *client) QueryOCO(ctx context.Context, r *QueryOCORequest) (
+	*OrderList, error) {
+	params := make(url.Values)
+	if err := c.encoder.Encode(r, params); err != nil {
+		return nil, errors.Wrap(err, "failed to encode query oco request")
+	}
+
+	res, err := c.get(ctx, fmt.Sprintf("/orderList?%s", params.Encode()))
+	if err != nil {
+		return nil, err
+	}
+
+	var orderList OrderList
+	if err = json.Unmarshal(res, &orderList); err != nil {
+		return nil, errors.Wrap(err, "failed to parse query oco response")
+	}
+
+	return &orderList, nil
+}
+
+// AllOCO queries the history of order lists.
+func (c *client) AllOCO(ctx context.Context, r *AllOCORequest) ([]OrderList,
+	error) {
+	params := make(url.Values)
+	if err := c.encoder.Encode(r, params); err != nil {
+		return nil, errors.Wrap(err, "failed to encode all oco request")
+	}
+
+	path := "/allOrderList"
+	if len(params) > 0 {
+		path = fmt.Sprintf("%s?%s", path, params.Encode())
+	}
+
+	res, err := c.get(ctx, path)
+	if err != nil {
+		return nil, err
+	}
+
+	var orderLists []OrderList
+	if err = json.Unmarshal(res, &orderLists); err != nil {
+		return nil, errors.Wrap(err, "failed to parse all oco response")
+	}
+
+	return orderLists, nil
+}
+
+// OpenOCO queries all open order lists.
+func (c *client) OpenOCO(ctx context.Context) ([]OrderList, error) {
+	res, err := c.get(ctx, "/openOrderList")
+	if err != nil {
+		return nil, err
+	}
+
+	var orderLists []OrderList
+	if err = json.Unmarshal(res, &orderLists); err != nil {
+		return nil, errors.Wrap(err, "failed to parse open oco response")
+	}
+
+	return orderLists, nil
+}
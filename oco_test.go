@@ -0,0 +1,91 @@
+package binance
+
+import (
+	"context"
+	"net/http"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestNewOCO_OK(t *testing.T) {
+	srv, err := createTestServer(t, http.StatusOK)
+	require.NoError(t, err)
+	defer srv.Close()
+
+	c := NewClient(WithBaseURL(srv.URL))
+	orderList, err := c.NewOCO(context.Background(), &NewOCORequest{
+		Price:     MustParseDecimal("0.036435"),
+		Qty:       MustParseDecimal("0.624363"),
+		Side:      Buy,
+		StopPrice: MustParseDecimal("0.960664"),
+		Symbol:    "LTCBTC",
+	})
+	require.NoError(t, err)
+	require.Equal(t, ContingencyTypeOCO, orderList.ContingencyType)
+	require.Equal(t, ListStatusTypeExecStarted, orderList.ListStatusType)
+	require.Equal(t, ListOrderStatusExecuting, orderList.ListOrderStatus)
+	require.Len(t, orderList.Orders, 2)
+	require.Len(t, orderList.OrderReports, 2)
+	require.Equal(t, OrderTypeStopLoss, orderList.OrderReports[0].Type)
+	require.Equal(t, "0.960664", orderList.OrderReports[0].StopPrice.String())
+	require.Equal(t, OrderTypeLimitMaker, orderList.OrderReports[1].Type)
+}
+
+func TestCancelOCO_OK(t *testing.T) {
+	srv, err := createTestServer(t, http.StatusOK)
+	require.NoError(t, err)
+	defer srv.Close()
+
+	c := NewClient(WithBaseURL(srv.URL))
+	orderList, err := c.CancelOCO(context.Background(), &CancelOCORequest{
+		OrderListID: 0,
+		Symbol:      "LTCBTC",
+	})
+	require.NoError(t, err)
+	require.Equal(t, ListStatusTypeAllDone, orderList.ListStatusType)
+	require.Equal(t, ListOrderStatusAllDone, orderList.ListOrderStatus)
+	for _, report := range orderList.OrderReports {
+		require.Equal(t, OrderStatusCancelled, report.Status)
+		require.NotEmpty(t, report.OrigClientOrderID)
+	}
+}
+
+func TestQueryOCO_OK(t *testing.T) {
+	srv, err := createTestServer(t, http.StatusOK)
+	require.NoError(t, err)
+	defer srv.Close()
+
+	c := NewClient(WithBaseURL(srv.URL))
+	orderList, err := c.QueryOCO(context.Background(), &QueryOCORequest{
+		OrderListID: 27,
+	})
+	require.NoError(t, err)
+	require.Equal(t, int64(27), orderList.OrderListID)
+	require.Empty(t, orderList.OrderReports)
+	require.Equal(t, int64(5), orderList.Orders[1].OrderID)
+}
+
+func TestAllOCO_OK(t *testing.T) {
+	srv, err := createTestServer(t, http.StatusOK)
+	require.NoError(t, err)
+	defer srv.Close()
+
+	c := NewClient(WithBaseURL(srv.URL))
+	orderLists, err := c.AllOCO(context.Background(), &AllOCORequest{})
+	require.NoError(t, err)
+	require.Len(t, orderLists, 2)
+	require.Equal(t, ListOrderStatusAllDone, orderLists[1].ListOrderStatus)
+}
+
+func TestOpenOCO_OK(t *testing.T) {
+	srv, err := createTestServer(t, http.StatusOK)
+	require.NoError(t, err)
+	defer srv.Close()
+
+	c := NewClient(WithBaseURL(srv.URL))
+	orderLists, err := c.OpenOCO(context.Background())
+	require.NoError(t, err)
+	require.Len(t, orderLists, 1)
+	require.Equal(t, "wuB13fmulKj3YjdqWEcsnp", orderLists[0].ListClientOrderID)
+}
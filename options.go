@@ -2,21 +2,41 @@ package binance
 
 import (
 	"net/http"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"go.opentelemetry.io/otel/trace"
 )
 
 var defaultOptions = ClientOptions{
-	baseURL:   "https://api.binance.com/api/v3",
-	logLevel:  LogLevelNone,
-	transport: http.DefaultClient,
+	baseURL:           Production.RESTURL,
+	clock:             time.Now,
+	logLevel:          LogLevelNone,
+	reconcileAttempts: 3,
+	reconcileTimeout:  10 * time.Second,
+	reconcileWait:     time.Second,
+	registerer:        prometheus.DefaultRegisterer,
+	transport:         http.DefaultClient,
 }
 
 // ClientOptions provides configurable fields for Client.
 type ClientOptions struct {
-	apiKey    string
-	baseURL   string
-	logLevel  LogLevel
-	secretKey string
-	transport *http.Client
+	apiKey            string
+	baseURL           string
+	clock             func() time.Time
+	logLevel          LogLevel
+	rateLimiter       *RateLimiter
+	reconcileAttempts int
+	reconcileTimeout  time.Duration
+	reconcileWait     time.Duration
+	recvWindow        time.Duration
+	registerer        prometheus.Registerer
+	retryPolicy       RetryPolicy
+	secretKey         string
+	signer            Signer
+	timeSync          time.Duration
+	tracerProvider    trace.TracerProvider
+	transport         *http.Client
 }
 
 // ClientOption is a func-to-ClientOption adapter.
@@ -61,6 +81,24 @@ func WithBaseURL(url string) ClientOption {
 	}
 }
 
+// WithClock returns a ClientOption to set the func a Client uses to read the
+// local time when timestamping signed requests. This is useful for testing.
+// Defaults to time.Now.
+func WithClock(now func() time.Time) ClientOption {
+	return func(opts *ClientOptions) {
+		opts.clock = now
+	}
+}
+
+// WithEnvironment returns a ClientOption to send requests to the REST API of
+// `env`. Pass the same Environment to stream.WithEnvironment to connect
+// streams to the same deployment. Defaults to Production.
+func WithEnvironment(env Environment) ClientOption {
+	return func(opts *ClientOptions) {
+		opts.baseURL = env.RESTURL
+	}
+}
+
 // WithLogLevel returns a ClientOption to set the verbosity of a Client's logs.
 // Defaults to `LogLevelNone`.
 func WithLogLevel(level LogLevel) ClientOption {
@@ -73,15 +111,118 @@ func WithLogLevel(level LogLevel) ClientOption {
 	}
 }
 
+// WithMetrics returns a ClientOption to set the registry a Client registers
+// its Prometheus metrics with. Clients sharing a registry share their
+// metrics. A nil Registerer disables exporting them. Defaults to
+// prometheus.DefaultRegisterer.
+func WithMetrics(reg prometheus.Registerer) ClientOption {
+	return func(opts *ClientOptions) {
+		opts.registerer = reg
+	}
+}
+
+// WithOrderReconciliation returns a ClientOption to set how NewOrder finds
+// out whether an order was placed after an ambiguous failure. The order is
+// queried `wait` apart, at least `attempts` times and until the request's
+// recvWindow has passed, before it is considered not placed. Non-positive
+// attempts and negative waits are ignored. Defaults to three attempts, one
+// second apart.
+func WithOrderReconciliation(attempts int, wait time.Duration) ClientOption {
+	return func(opts *ClientOptions) {
+		if attempts > 0 {
+			opts.reconcileAttempts = attempts
+		}
+		if wait >= 0 {
+			opts.reconcileWait = wait
+		}
+	}
+}
+
+// WithOrderReconciliationTimeout returns a ClientOption to set how long
+// NewOrder may spend finding out whether an order was placed when the
+// context of the request has already ended. If the request's recvWindow
+// hasn't passed by then, ErrOrderStatusUnknown is returned. Non-positive
+// timeouts are ignored. Defaults to 10 seconds.
+func WithOrderReconciliationTimeout(timeout time.Duration) ClientOption {
+	return func(opts *ClientOptions) {
+		if timeout > 0 {
+			opts.reconcileTimeout = timeout
+		}
+	}
+}
+
+// WithRateLimiter returns a ClientOption to set a RateLimiter which every
+// request is counted against before it is sent. Requests are not rate limited
+// by default.
+func WithRateLimiter(limiter *RateLimiter) ClientOption {
+	return func(opts *ClientOptions) {
+		opts.rateLimiter = limiter
+	}
+}
+
+// WithRecvWindow returns a ClientOption to set how long after its timestamp a
+// signed request remains valid. It is sent with every signed request which
+// doesn't set its own ReceiveWindow. The exchange defaults to 5 seconds, and
+// allows at most 60 seconds.
+func WithRecvWindow(window time.Duration) ClientOption {
+	return func(opts *ClientOptions) {
+		opts.recvWindow = window
+	}
+}
+
+// WithRetryPolicy returns a ClientOption to set which failed requests are
+// retried, and how. Requests are not retried by default. See
+// DefaultRetryPolicy.
+func WithRetryPolicy(policy RetryPolicy) ClientOption {
+	return func(opts *ClientOptions) {
+		opts.retryPolicy = policy
+	}
+}
+
 // WithSecretKey returns a ClientOption to set the secret key a Client uses
-// to generate request signatures. Not using this option will cause all
-// signed requests to fail.
+// to generate HMAC request signatures. Not using this option or WithSigner
+// will cause all signed requests to fail.
 func WithSecretKey(key string) ClientOption {
 	return func(opts *ClientOptions) {
 		opts.secretKey = key
 	}
 }
 
+// WithSigner returns a ClientOption to set the Signer a Client uses to sign
+// requests, for API keys other than HMAC keys. It takes precedence over
+// WithSecretKey. See NewRSASigner and NewEd25519Signer.
+func WithSigner(signer Signer) ClientOption {
+	return func(opts *ClientOptions) {
+		opts.signer = signer
+	}
+}
+
+// WithTimeSync returns a ClientOption to timestamp signed requests in server
+// time rather than local time. The offset between the clocks is measured
+// using ServerTime before the first signed request, re-measured every
+// `interval`, and re-measured after a request is rejected with
+// ErrInvalidTimestamp. A non-positive interval uses DefaultTimeSyncInterval.
+func WithTimeSync(interval time.Duration) ClientOption {
+	return func(opts *ClientOptions) {
+		if interval <= 0 {
+			interval = DefaultTimeSyncInterval
+		}
+		opts.timeSync = interval
+	}
+}
+
+// WithTracerProvider returns a ClientOption to trace requests with
+// OpenTelemetry. Each request is recorded as a span which is a child of any
+// span in the context it is made with. The span's context is injected into
+// the request's headers by the global propagator, otel.GetTextMapPropagator,
+// which injects nothing unless one is set. Pass otel.GetTracerProvider() to
+// use the global provider. Requests are not traced by default.
+func WithTracerProvider(provider trace.TracerProvider) ClientOption {
+	return func(opts *ClientOptions) {
+		opts.tracerProvider = provider
+	}
+}
+
 // WithTransport returns a client option to set the underlying HTTP Client used
 // for requests. Defaults to the DefaultClient.
 func WithTransport(transport *http.Client) ClientOption {
@@ -2,13 +2,44 @@ package binance
 
 import (
 	"context"
+	"crypto/rand"
+	"encoding/hex"
 	"encoding/json"
 	"fmt"
+	"net/http"
 	"net/url"
+	"time"
 
 	"github.com/luno/jettison/errors"
+	"github.com/luno/jettison/j"
 )
 
+// AllOrdersRequest contains the parameters for querying all orders on a
+// market, whether active, cancelled or filled.
+type AllOrdersRequest struct {
+	// EndTime represents the unix timestamp in milliseconds to query until.
+	EndTime int64 `schema:"endTime,omitempty"`
+
+	// Limit represents the maximum amount of orders to query.
+	//
+	// Default: 500.
+	// Max: 1000.
+	Limit int64 `schema:"limit,omitempty"`
+
+	// OrderID represents the order ID to query from. Orders with an ID
+	// greater than or equal to OrderID are returned, otherwise the most
+	// recent orders are returned.
+	OrderID int64 `schema:"orderId,omitempty"`
+
+	// StartTime represents the unix timestamp in milliseconds to query from.
+	StartTime int64 `schema:"startTime,omitempty"`
+
+	// Symbol represents the market the orders were placed on.
+	//
+	// Required.
+	Symbol string `schema:"symbol"`
+}
+
 // CancelOrderRequest contains the parameters for cancelling an open order.
 type CancelOrderRequest struct {
 	// NewClientOrderID represents the unique identifier for this cancel.
@@ -36,12 +67,12 @@ type CancelOrderRequest struct {
 type CancelOrderResponse struct {
 	// ClientOrderID represents the unique identifier provided by the client on
 	// order creation.
-	ClientOrderID       string `json:"clientOrderId"`
-	CummulativeQuoteQty string `json:"cummulativeQuoteQty"`
+	ClientOrderID       string  `json:"clientOrderId"`
+	CummulativeQuoteQty Decimal `json:"cummulativeQuoteQty"`
 
 	// ExecutedQty represents how much of the original quantity has been
 	// executed.
-	ExecutedQty string `json:"executedQty"`
+	ExecutedQty Decimal `json:"executedQty"`
 
 	// OrderID represents the unique identifier provided by Binance on order
 	// creation.
@@ -51,9 +82,9 @@ type CancelOrderResponse struct {
 	OrderListID int64 `json:"orderListId"`
 
 	// OriginalQty represents the original amount the order was placed for.
-	OriginalQty string `json:"origQty"`
+	OriginalQty Decimal `json:"origQty"`
 	// Price represents the price that the order was placed at.
-	Price string `json:"price"`
+	Price Decimal `json:"price"`
 
 	// Side represents whether the order was a buy or sell.
 	Side OrderSide `json:"side"`
@@ -106,8 +137,10 @@ const (
 	// OrderStatusFilled indicates a completed order.
 	OrderStatusFilled OrderStatus = "FILLED"
 
-	// OrderStatusCancelled indicates an order that has been cancelled.
-	OrderStatusCancelled OrderStatus = "CANCELLED"
+	// OrderStatusCancelled indicates an order that has been cancelled. The
+	// exchange spells it "CANCELED"; earlier versions of this package used
+	// "CANCELLED", which never matched a response.
+	OrderStatusCancelled OrderStatus = "CANCELED"
 
 	// OrderStatusPendingCancel is currently unused.
 	OrderStatusPendingCancel OrderStatus = "PENDING_CANCEL"
@@ -179,28 +212,29 @@ type NewOrderRequest struct {
 	// GTC.
 	//
 	// Optional.
-	IcebergQty float64 `schema:"icebergQty,omitempty"`
+	IcebergQty Decimal `schema:"icebergQty,omitempty"`
 
 	// Price represents the price at which to place the order.
 	//
 	// Required for orders of type LIMIT, STOP_LOSS_LIMIT and TAKE_PROFIT_LIMIT.
-	Price float64 `schema:"price,omitempty"`
+	Price Decimal `schema:"price,omitempty"`
 
 	// NewClientOrderID represents a unique identifier for the order, supplied
 	// by the client.
 	//
 	// Optional.
-	// Default is a randomly generated string.
+	// Default is a randomly generated string, which NewOrder uses to find
+	// the order if placing it fails ambiguously.
 	NewClientOrderID string `schema:"newClientOrderId,omitempty"`
 
 	// Qty represents the quantity to buy or sell.
 	//
 	// Required for orders of type MARKET, STOP_LOSS, TAKE_PROFIT and
 	// LIMIT_MAKER.
-	Qty float64 `schema:"quantity,omitempty"`
+	Qty Decimal `schema:"quantity,omitempty"`
 
 	// Required for order of type MARKET if Qty is not set.
-	QuoteOrderQty float64 `schema:"quoteOrderQty,omitempty"`
+	QuoteOrderQty Decimal `schema:"quoteOrderQty,omitempty"`
 
 	// Side represents whether this order is a buy or sell.
 	//
@@ -212,7 +246,7 @@ type NewOrderRequest struct {
 	//
 	// Required for orders of type STOP_LOSS, STOP_LOSS_LIMIT, TAKE_PROFIT and
 	// TAKE_PROFIT_LIMIT.
-	StopPrice float64 `schema:"stopPrice,omitempty"`
+	StopPrice Decimal `schema:"stopPrice,omitempty"`
 
 	// Symbol represents the market to place the order on.
 	//
@@ -235,14 +269,14 @@ type NewOrderResponse struct {
 	// ClientOrderID represents the unique identifier for the order, sent by
 	// the client on creation. If NewClientOrderID was empty in the request,
 	// this will be a randomly generated string.
-	ClientOrderID       string `json:"clientOrderId"`
-	CummulativeQuoteQty string `json:"cummulativeQuoteQty,omitempty"`
+	ClientOrderID       string  `json:"clientOrderId"`
+	CummulativeQuoteQty Decimal `json:"cummulativeQuoteQty,omitempty"`
 
 	// ExecutedQty represents how much of the original quantity has been
 	// executed.
 	//
 	// Returned with response types RESULT and FULL.
-	ExecutedQty string `json:"executedQty,omitempty"`
+	ExecutedQty Decimal `json:"executedQty,omitempty"`
 
 	// Fills contains sub-orders executed in order to fully execute an order.
 	//
@@ -259,12 +293,12 @@ type NewOrderResponse struct {
 	// OriginalQty represents the original quantity the order was placed for.
 	//
 	// Returned with response types RESULT and FULL.
-	OriginalQty string `json:"origQty,omitempty"`
+	OriginalQty Decimal `json:"origQty,omitempty"`
 
 	// Price represents the price at which the order was placed.
 	//
 	// Returned with response types RESULT and FULL.
-	Price string `json:"price,omitempty"`
+	Price Decimal `json:"price,omitempty"`
 
 	// Side represents whether the order was a buy or sell.
 	//
@@ -299,16 +333,16 @@ type NewOrderResponse struct {
 // OrderFill represents a sub-order executed as part of a larger order.
 type OrderFill struct {
 	// Commission represents the amount of commission earned by a sub-order.
-	Commission string `schema:"commission"`
+	Commission Decimal `json:"commission"`
 
 	// CommissionAsset represents the asset that commission is paid out in.
-	CommissionAsset string `schema:"commissionAsset"`
+	CommissionAsset string `json:"commissionAsset"`
 
 	// Price represents the price at which a sub-order was executed.
-	Price string `schema:"price"`
+	Price Decimal `json:"price"`
 
 	// Qty represents the quantity of the sub-order.
-	Qty string `schema:"qty"`
+	Qty Decimal `json:"qty"`
 }
 
 // TimeInForce sets the duration that an order should be valid.
@@ -354,15 +388,15 @@ type QueryOrderResponse struct {
 	// order creation.
 	ClientOrderID string `json:"clientOrderId"`
 
-	CummulativeQuoteQty string `json:"cummulativeQuoteQty"`
+	CummulativeQuoteQty Decimal `json:"cummulativeQuoteQty"`
 
 	// ExecutedQty represents how much of the original quantity has been
 	// executed.
-	ExecutedQty string `json:"executedQty"`
+	ExecutedQty Decimal `json:"executedQty"`
 
 	// IcebergQty represents the maximum amount per sub-order until the total
 	// quantity of the order has been filled.
-	IcebergQty string `json:"icebergQty"`
+	IcebergQty Decimal `json:"icebergQty"`
 
 	// IsWorking represents whether the order is still currently being filled.
 	IsWorking bool `json:"isWorking"`
@@ -372,14 +406,14 @@ type QueryOrderResponse struct {
 	OrderID int64 `json:"orderId"`
 
 	// OrderListID will always be -1 if the order was not an OCO order.
-	OrderListID           int64  `json:"orderListId"`
-	OriginalQuoteOrderQty string `json:"origQuoteOrderQty"`
+	OrderListID           int64   `json:"orderListId"`
+	OriginalQuoteOrderQty Decimal `json:"origQuoteOrderQty"`
 
 	// OriginalQty represents the original amount the order was placed for.
-	OriginalQty string `json:"origQty"`
+	OriginalQty Decimal `json:"origQty"`
 
 	// Price represents the price that the order was placed at.
-	Price string `json:"price"`
+	Price Decimal `json:"price"`
 
 	// Side represents whether the order was a buy or sell.
 	Side OrderSide `json:"side"`
@@ -389,7 +423,7 @@ type QueryOrderResponse struct {
 
 	// StopPrice represents the price the market needs to reach before placing
 	// the order as a market order.
-	StopPrice string `json:"stopPrice"`
+	StopPrice Decimal `json:"stopPrice"`
 
 	// Symbol represents the market the order was placed on.
 	Symbol string `json:"symbol"`
@@ -411,6 +445,79 @@ type QueryOrderResponse struct {
 	UpdateTime int64 `json:"updateTime"`
 }
 
+// AllOrders queries all orders on a market, whether active, cancelled or
+// filled.
+func (c *client) AllOrders(ctx context.Context, r *AllOrdersRequest) (
+	[]QueryOrderResponse, error) {
+	params := make(url.Values)
+	if err := c.encoder.Encode(r, params); err != nil {
+		return nil, errors.Wrap(err, "failed to encode all orders request")
+	}
+
+	res, err := c.get(ctx, fmt.Sprintf("/allOrders?%s", params.Encode()))
+	if err != nil {
+		return nil, err
+	}
+
+	var orders []QueryOrderResponse
+	if err = json.Unmarshal(res, &orders); err != nil {
+		return nil, errors.Wrap(err, "failed to parse all orders response")
+	}
+
+	return orders, nil
+}
+
+// CancelAllOpenOrders cancels all open orders on a market, including orders
+// that are part of an order list. Each order in a list is returned
+// individually.
+func (c *client) CancelAllOpenOrders(ctx context.Context, symbol string) (
+	[]CancelOrderResponse, error) {
+	params := make(url.Values)
+	params.Set("symbol", symbol)
+
+	res, err := c.delete(ctx, "/openOrders", []byte(params.Encode()))
+	if err != nil {
+		return nil, err
+	}
+
+	var entries []json.RawMessage
+	if err = json.Unmarshal(res, &entries); err != nil {
+		return nil, errors.Wrap(err,
+			"failed to parse cancel open orders response")
+	}
+
+	cancelled := make([]CancelOrderResponse, 0, len(entries))
+	for _, e := range entries {
+		// Order lists are returned as a whole, with a report of each of
+		// their orders.
+		var list struct {
+			OrderReports []CancelOrderResponse `json:"orderReports"`
+		}
+		if err = json.Unmarshal(e, &list); err != nil {
+			return nil, errors.Wrap(err,
+				"failed to parse cancel open orders response")
+		}
+
+		if len(list.OrderReports) > 0 {
+			cancelled = append(cancelled, list.OrderReports...)
+			continue
+		}
+
+		var o CancelOrderResponse
+		if err = json.Unmarshal(e, &o); err != nil {
+			return nil, errors.Wrap(err,
+				"failed to parse cancel open orders response")
+		}
+		cancelled = append(cancelled, o)
+	}
+
+	for _, o := range cancelled {
+		c.metrics.observeOrder(o.Status)
+	}
+
+	return cancelled, nil
+}
+
 // CancelOrder cancels an open order.
 func (c *client) CancelOrder(ctx context.Context, r *CancelOrderRequest) (
 	*CancelOrderResponse, error) {
@@ -419,7 +526,7 @@ func (c *client) CancelOrder(ctx context.Context, r *CancelOrderRequest) (
 		return nil, errors.Wrap(err, "failed to encode cancel order request")
 	}
 
-	res, err := c.delete(ctx, "order", []byte(params.Encode()))
+	res, err := c.delete(ctx, "/order", []byte(params.Encode()))
 	if err != nil {
 		return nil, err
 	}
@@ -429,21 +536,66 @@ func (c *client) CancelOrder(ctx context.Context, r *CancelOrderRequest) (
 		return nil, errors.Wrap(err, "failed to parse cancel order response")
 	}
 
+	c.metrics.observeOrder(cancelOrder.Status)
 	return &cancelOrder, nil
 }
 
+// ErrOrderNotPlaced is returned by NewOrder when a request failed in a way
+// that left it unclear whether the order was placed, and the exchange has
+// since confirmed that it wasn't.
+var ErrOrderNotPlaced = errors.New("order was not placed",
+	j.C("ERR_ORDER_NOT_PLACED"))
+
+// ErrOrderStatusUnknown is returned by NewOrder when a request failed in a way
+// that left it unclear whether the order was placed, and the exchange could
+// not be asked. The order should be queried using the client_order_id in the
+// error before it is placed again.
+var ErrOrderStatusUnknown = errors.New("order status unknown",
+	j.C("ERR_ORDER_STATUS_UNKNOWN"))
+
 // NewOrder places a new order on the exchange.
+//
+// A NewClientOrderID is generated if the request doesn't have one. If the
+// request fails without a definite outcome, such as with a timeout, a server
+// error, ErrTimeout or ErrUnexpectedResponse, the order is queried by its
+// client order ID to find out whether it was placed. If it was, its response
+// is built from the query, and so has no Fills. If it wasn't, and its
+// recvWindow has passed so that it can no longer be, ErrOrderNotPlaced is
+// returned. Otherwise ErrOrderStatusUnknown is returned.
 func (c *client) NewOrder(ctx context.Context, r *NewOrderRequest) (
 	*NewOrderResponse, error) {
+	req := *r
+	if req.NewClientOrderID == "" {
+		id, err := newClientOrderID()
+		if err != nil {
+			return nil, err
+		}
+		req.NewClientOrderID = id
+	}
+
 	params := make(url.Values)
-	err := c.encoder.Encode(r, params)
+	err := c.encoder.Encode(&req, params)
 	if err != nil {
 		return nil, errors.Wrap(err, "failed to encode new order request")
 	}
 
-	res, err := c.post(ctx, "/order", []byte(params.Encode()))
-	if err != nil {
+	res, httpRes, err := c.send(ctx, http.MethodPost, "/order",
+		[]byte(params.Encode()))
+	if IsError(err, ErrNewOrderRejected) {
+		c.metrics.observeOrder(OrderStatusRejected)
 		return nil, err
+	} else if err != nil {
+		if !isAmbiguous(httpRes, err) {
+			return nil, err
+		}
+
+		orderResponse, err := c.reconcileOrder(ctx, &req, err)
+		if err != nil {
+			return nil, err
+		}
+
+		c.metrics.observeOrder(orderResponse.Status)
+		return orderResponse, nil
 	}
 
 	var orderResponse NewOrderResponse
@@ -451,9 +603,127 @@ func (c *client) NewOrder(ctx context.Context, r *NewOrderRequest) (
 		return nil, errors.Wrap(err, "failed to parse new order response")
 	}
 
+	c.metrics.observeOrder(orderResponse.Status)
 	return &orderResponse, nil
 }
 
+// newClientOrderID returns a random identifier which is valid as a client
+// order ID.
+func newClientOrderID() (string, error) {
+	b := make([]byte, 16)
+	if _, err := rand.Read(b); err != nil {
+		return "", errors.Wrap(err, "failed to generate client order id")
+	}
+	return hex.EncodeToString(b), nil
+}
+
+// isAmbiguous returns whether a failed request may still have been acted on by
+// the exchange. `res` is nil if no response was received.
+func isAmbiguous(res *http.Response, err error) bool {
+	if res == nil {
+		return failedInTransport(err)
+	}
+
+	return res.StatusCode >= http.StatusInternalServerError ||
+		IsError(err, ErrTimeout) || IsError(err, ErrUnexpectedResponse)
+}
+
+// defaultRecvWindow is how long after its timestamp the exchange accepts a
+// signed request which doesn't set a recvWindow.
+const defaultRecvWindow = 5 * time.Second
+
+// reconcileMargin is added to the recvWindow of an ambiguous request before
+// it is considered expired, to allow for the exchange's clock being ahead.
+const reconcileMargin = time.Second
+
+// recvWindow returns how long after its timestamp the exchange accepts the
+// signed request `r`.
+func (c *client) recvWindow(r *NewOrderRequest) time.Duration {
+	switch {
+	case r.ReceiveWindow > 0:
+		return time.Duration(r.ReceiveWindow) * time.Millisecond
+	case c.options.recvWindow > 0:
+		return c.options.recvWindow
+	default:
+		return defaultRecvWindow
+	}
+}
+
+// reconcileOrder queries an order whose placement failed with `cause` to find
+// out whether it was placed. The exchange may accept the request until its
+// recvWindow has passed, so the order is queried until then, and at least the
+// configured number of times, before concluding that it wasn't placed.
+func (c *client) reconcileOrder(ctx context.Context, r *NewOrderRequest,
+	cause error) (*NewOrderResponse, error) {
+	// The request was timestamped before now, so it can't be accepted after
+	// its recvWindow from now.
+	expiry := c.options.clock().Add(c.recvWindow(r) + reconcileMargin)
+
+	kvs := j.MKV{"client_order_id": r.NewClientOrderID, "symbol": r.Symbol,
+		"cause": cause.Error()}
+
+	// The request may have failed because ctx ended, but we still need an
+	// answer.
+	if ctx.Err() != nil {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(context.Background(),
+			c.options.reconcileTimeout)
+		defer cancel()
+	}
+
+	c.debug(ctx, "Reconciling ambiguous new order", kvs)
+
+	for attempt := 1; ; attempt++ {
+		order, err := c.QueryOrder(ctx, &QueryOrderRequest{
+			OrigClientOrderID: r.NewClientOrderID,
+			Symbol:            r.Symbol,
+		})
+		if err == nil {
+			return newOrderResponseFromQuery(order), nil
+		}
+
+		if !IsError(err, ErrNoSuchOrder) {
+			return nil, errors.Wrap(ErrOrderStatusUnknown,
+				"failed to query order", kvs, j.KV("query_error", err.Error()))
+		}
+
+		if attempt >= c.options.reconcileAttempts &&
+			!c.options.clock().Before(expiry) {
+			return nil, errors.Wrap(ErrOrderNotPlaced,
+				"order not found after ambiguous failure", kvs)
+		}
+
+		t := time.NewTimer(c.options.reconcileWait)
+		select {
+		case <-ctx.Done():
+			t.Stop()
+			return nil, errors.Wrap(ErrOrderStatusUnknown,
+				"context ended while querying order", kvs)
+		case <-t.C:
+		}
+	}
+}
+
+// newOrderResponseFromQuery converts a queried order into the response that
+// would have been received when placing it.
+func newOrderResponseFromQuery(o *QueryOrderResponse) *NewOrderResponse {
+	return &NewOrderResponse{
+		ClientOrderID:       o.ClientOrderID,
+		CummulativeQuoteQty: o.CummulativeQuoteQty,
+		ExecutedQty:         o.ExecutedQty,
+		OrderID:             o.OrderID,
+		OrderListID:         o.OrderListID,
+		OriginalQty:         o.OriginalQty,
+		Price:               o.Price,
+		Side:                o.Side,
+		Status:              o.Status,
+		Symbol:              o.Symbol,
+		TimeInForce:         o.TimeInForce,
+		TransactTime:        o.Time,
+		Type:                o.Type,
+	}
+}
+
 // NewOrderTest creates and validates a new order on the exchange, but does
 // not send it to the matching engine.
 func (c *client) NewOrderTest(ctx context.Context, r *NewOrderRequest) error {
@@ -471,6 +741,33 @@ func (c *client) NewOrderTest(ctx context.Context, r *NewOrderRequest) error {
 	return nil
 }
 
+// OpenOrders queries all open orders on a market, or on every market if
+// `symbol` is empty.
+//
+// Querying every market carries a much larger request weight than querying a
+// single symbol.
+func (c *client) OpenOrders(ctx context.Context, symbol string) (
+	[]QueryOrderResponse, error) {
+	path := "/openOrders"
+	if symbol != "" {
+		params := make(url.Values)
+		params.Set("symbol", symbol)
+		path = fmt.Sprintf("%s?%s", path, params.Encode())
+	}
+
+	res, err := c.get(ctx, path)
+	if err != nil {
+		return nil, err
+	}
+
+	var orders []QueryOrderResponse
+	if err = json.Unmarshal(res, &orders); err != nil {
+		return nil, errors.Wrap(err, "failed to parse open orders response")
+	}
+
+	return orders, nil
+}
+
 // QueryOrder searches for an order and returns it.
 func (c *client) QueryOrder(ctx context.Context, r *QueryOrderRequest) (
 	*QueryOrderResponse, error) {
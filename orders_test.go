@@ -0,0 +1,374 @@
+package binance
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/luno/jettison/errors"
+	"github.com/stretchr/testify/require"
+)
+
+func TestOrderStatus_Cancelled(t *testing.T) {
+	var res CancelOrderResponse
+	err := json.Unmarshal([]byte(`{"status":"CANCELED"}`), &res)
+	require.NoError(t, err)
+	require.Equal(t, OrderStatusCancelled, res.Status)
+}
+
+func TestOpenOrders_OK(t *testing.T) {
+	srv, err := createTestServer(t, http.StatusOK)
+	require.NoError(t, err)
+	defer srv.Close()
+
+	c := NewClient(WithBaseURL(srv.URL))
+	orders, err := c.OpenOrders(context.Background(), "")
+	require.NoError(t, err)
+	require.Len(t, orders, 1)
+	require.Equal(t, "myOrder1", orders[0].ClientOrderID)
+	require.Equal(t, OrderStatusNew, orders[0].Status)
+}
+
+func TestAllOrders_OK(t *testing.T) {
+	srv, err := createTestServer(t, http.StatusOK)
+	require.NoError(t, err)
+	defer srv.Close()
+
+	c := NewClient(WithBaseURL(srv.URL))
+	orders, err := c.AllOrders(context.Background(), &AllOrdersRequest{
+		Symbol: "LTCBTC",
+		Limit:  2,
+	})
+	require.NoError(t, err)
+	require.Len(t, orders, 2)
+	require.Equal(t, OrderStatusCancelled, orders[0].Status)
+	require.Equal(t, OrderStatusFilled, orders[1].Status)
+	require.Equal(t, Sell, orders[1].Side)
+}
+
+func TestCancelAllOpenOrders_OK(t *testing.T) {
+	srv, err := createTestServer(t, http.StatusOK)
+	require.NoError(t, err)
+	defer srv.Close()
+
+	c := NewClient(WithBaseURL(srv.URL))
+	cancelled, err := c.CancelAllOpenOrders(context.Background(), "BTCUSDT")
+	require.NoError(t, err)
+	require.Len(t, cancelled, 3)
+	require.Equal(t, int64(11), cancelled[0].OrderID)
+	require.Equal(t, OrderStatusCancelled, cancelled[0].Status)
+
+	// The orders of a list are unpacked from its reports.
+	for i, id := range []int64{20, 21} {
+		require.Equal(t, id, cancelled[i+1].OrderID)
+		require.Equal(t, int64(1929), cancelled[i+1].OrderListID)
+		require.Equal(t, OrderStatusCancelled, cancelled[i+1].Status)
+	}
+	require.Equal(t, OrderTypeLimitMaker, cancelled[2].Type)
+}
+
+func TestCancelOrder_OK(t *testing.T) {
+	var method, path, apiKey, body string
+	h := func(w http.ResponseWriter, r *http.Request) {
+		b, _ := ioutil.ReadAll(r.Body)
+		method, path, body = r.Method, r.URL.Path, string(b)
+		apiKey = r.Header.Get(HeaderAPIKey)
+		w.Write([]byte(`{"symbol":"LTCBTC","origClientOrderId":"myOrder1",
+			"orderId":4,"orderListId":-1,"status":"CANCELED"}`))
+	}
+
+	srv := httptest.NewServer(http.HandlerFunc(h))
+	defer srv.Close()
+
+	c := NewClient(WithBaseURL(srv.URL+"/api/v3"), WithAPIKey("key"),
+		WithSecretKey("secret"))
+	res, err := c.CancelOrder(context.Background(), &CancelOrderRequest{
+		OrderID: 4,
+		Symbol:  "LTCBTC",
+	})
+	require.NoError(t, err)
+	require.Equal(t, OrderStatusCancelled, res.Status)
+
+	require.Equal(t, http.MethodDelete, method)
+	require.Equal(t, "/api/v3/order", path)
+	require.Equal(t, "key", apiKey)
+	require.Equal(t, "orderId=4&symbol=LTCBTC", body)
+}
+
+// orderServer fails every new order with `placeResponse`, and answers order
+// queries with `queryResponses` in turn, calling `onQuery` if it is set. It
+// records the client order IDs it receives.
+type orderServer struct {
+	onQuery        func()
+	placeDelay     time.Duration
+	placeResponse  testResponse
+	queryResponses []testResponse
+
+	mu         sync.Mutex
+	placedIDs  []string
+	queriedIDs []string
+}
+
+func (s *orderServer) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	r.ParseForm()
+
+	s.mu.Lock()
+	var res testResponse
+	if r.Method == http.MethodPost {
+		s.placedIDs = append(s.placedIDs, r.PostForm.Get("newClientOrderId"))
+		res = s.placeResponse
+	} else {
+		s.queriedIDs = append(s.queriedIDs, r.Form.Get("origClientOrderId"))
+		n := len(s.queriedIDs)
+		if n > len(s.queryResponses) {
+			n = len(s.queryResponses)
+		}
+		res = s.queryResponses[n-1]
+		if s.onQuery != nil {
+			s.onQuery()
+		}
+	}
+	s.mu.Unlock()
+
+	if r.Method == http.MethodPost {
+		time.Sleep(s.placeDelay)
+	}
+
+	w.WriteHeader(res.status)
+	w.Write([]byte(res.body))
+}
+
+var okQueriedOrder = testResponse{status: http.StatusOK, body: queriedOrder}
+
+const queriedOrder = `{"symbol":"LTCBTC","orderId":1,"orderListId":-1,
+	"clientOrderId":"myOrder1","price":"0.1","origQty":"1.0",
+	"executedQty":"0.0","cummulativeQuoteQty":"0.0","status":"NEW",
+	"timeInForce":"GTC","type":"LIMIT","side":"BUY","time":1499827319559}`
+
+func TestNewOrder_Reconcile(t *testing.T) {
+	noSuchOrder := testResponse{status: http.StatusBadRequest,
+		body: `{"code":-2013,"msg":"Order does not exist."}`}
+
+	tests := []struct {
+		name           string
+		timeout        time.Duration
+		placeDelay     time.Duration
+		recvWindow     int64
+		reconcileTime  time.Duration
+		placeResponse  testResponse
+		queryResponses []testResponse
+		queries        int
+		expectedErr    error
+		expectedCode   ErrorCode
+	}{
+		{
+			name:           "server error and order placed",
+			placeResponse:  serverError,
+			queryResponses: []testResponse{noSuchOrder, okQueriedOrder},
+			queries:        2,
+		},
+		{
+			name: "timeout and order placed",
+			placeResponse: testResponse{status: http.StatusBadRequest,
+				body: `{"code":-1007,"msg":"Timeout waiting for response."}`},
+			queryResponses: []testResponse{okQueriedOrder},
+			queries:        1,
+		},
+		{
+			name:           "request timeout and order placed",
+			timeout:        50 * time.Millisecond,
+			placeDelay:     200 * time.Millisecond,
+			placeResponse:  okQueriedOrder,
+			queryResponses: []testResponse{okQueriedOrder},
+			queries:        1,
+		},
+		{
+			name: "unexpected response and order placed",
+			placeResponse: testResponse{status: http.StatusBadRequest,
+				body: `{"code":-1006,"msg":"An unexpected response was received."}`},
+			queryResponses: []testResponse{okQueriedOrder},
+			queries:        1,
+		},
+		{
+			// The default recvWindow of 5s, and the margin, pass after six
+			// queries.
+			name:           "server error and order not placed",
+			placeResponse:  serverError,
+			queryResponses: []testResponse{noSuchOrder},
+			queries:        6,
+			expectedErr:    ErrOrderNotPlaced,
+		},
+		{
+			name:           "server error and order not placed in recvWindow",
+			recvWindow:     1000,
+			placeResponse:  serverError,
+			queryResponses: []testResponse{noSuchOrder},
+			queries:        3,
+			expectedErr:    ErrOrderNotPlaced,
+		},
+		{
+			name:           "request timeout and order not found in time",
+			timeout:        50 * time.Millisecond,
+			placeDelay:     200 * time.Millisecond,
+			recvWindow:     60000,
+			reconcileTime:  50 * time.Millisecond,
+			placeResponse:  serverError,
+			queryResponses: []testResponse{noSuchOrder},
+			expectedErr:    ErrOrderStatusUnknown,
+		},
+		{
+			name:           "server error and query failed",
+			placeResponse:  serverError,
+			queryResponses: []testResponse{serverError},
+			queries:        1,
+			expectedErr:    ErrOrderStatusUnknown,
+		},
+		{
+			name: "rejected",
+			placeResponse: testResponse{status: http.StatusBadRequest,
+				body: `{"code":-2010,"msg":"Account has insufficient balance."}`},
+			expectedCode: ErrNewOrderRejected,
+		},
+	}
+
+	for i, test := range tests {
+		t.Run(fmt.Sprintf("%d", i), func(t *testing.T) {
+			s := &orderServer{
+				placeDelay:     test.placeDelay,
+				placeResponse:  test.placeResponse,
+				queryResponses: test.queryResponses,
+			}
+			srv := httptest.NewServer(s)
+			defer srv.Close()
+
+			ctx := context.Background()
+			if test.timeout > 0 {
+				var cancel context.CancelFunc
+				ctx, cancel = context.WithTimeout(ctx, test.timeout)
+				defer cancel()
+			}
+
+			// Every query takes a second.
+			var (
+				mu  sync.Mutex
+				now time.Time
+			)
+			s.onQuery = func() {
+				mu.Lock()
+				defer mu.Unlock()
+				now = now.Add(time.Second)
+			}
+			clock := func() time.Time {
+				mu.Lock()
+				defer mu.Unlock()
+				return now
+			}
+
+			c := NewClient(WithBaseURL(srv.URL), WithClock(clock),
+				WithOrderReconciliation(3, 10*time.Millisecond),
+				WithOrderReconciliationTimeout(test.reconcileTime))
+			req := &NewOrderRequest{Symbol: "LTCBTC", Side: Buy,
+				Type: OrderTypeLimit, Price: MustParseDecimal("0.1"),
+				Qty: NewDecimalFromInt(1), ReceiveWindow: test.recvWindow}
+			res, err := c.NewOrder(ctx, req)
+
+			// The caller's request is left untouched.
+			require.Empty(t, req.NewClientOrderID, test.name)
+
+			s.mu.Lock()
+			defer s.mu.Unlock()
+			require.Len(t, s.placedIDs, 1, test.name)
+			require.Len(t, s.placedIDs[0], 32, test.name)
+			if test.reconcileTime == 0 {
+				require.Len(t, s.queriedIDs, test.queries, test.name)
+			}
+			for _, id := range s.queriedIDs {
+				require.Equal(t, s.placedIDs[0], id, test.name)
+			}
+
+			switch {
+			case test.expectedErr != nil:
+				require.True(t, errors.Is(err, test.expectedErr), test.name)
+			case test.expectedCode != 0:
+				require.True(t, IsError(err, test.expectedCode), test.name)
+			default:
+				require.NoError(t, err, test.name)
+				require.Equal(t, int64(1), res.OrderID, test.name)
+				require.Equal(t, OrderStatusNew, res.Status, test.name)
+				require.Equal(t, "0.1", res.Price.String(), test.name)
+			}
+		})
+	}
+}
+
+func TestWithOrderReconciliation(t *testing.T) {
+	var opts ClientOptions
+	WithOrderReconciliation(2, time.Millisecond)(&opts)
+	WithOrderReconciliation(0, -time.Millisecond)(&opts)
+	WithOrderReconciliationTimeout(time.Minute)(&opts)
+	WithOrderReconciliationTimeout(-time.Minute)(&opts)
+
+	require.Equal(t, 2, opts.reconcileAttempts)
+	require.Equal(t, time.Millisecond, opts.reconcileWait)
+	require.Equal(t, time.Minute, opts.reconcileTimeout)
+}
+
+func TestNewOrder_ClientOrderID(t *testing.T) {
+	s := &orderServer{placeResponse: okQueriedOrder}
+	srv := httptest.NewServer(s)
+	defer srv.Close()
+
+	c := NewClient(WithBaseURL(srv.URL))
+	_, err := c.NewOrder(context.Background(), &NewOrderRequest{
+		NewClientOrderID: "myOrder1",
+		Symbol:           "LTCBTC",
+	})
+	require.NoError(t, err)
+	require.Equal(t, []string{"myOrder1"}, s.placedIDs)
+}
+
+func TestNewOrder_TimeSyncFailed(t *testing.T) {
+	var (
+		mu    sync.Mutex
+		posts int
+	)
+	srv := httptest.NewServer(http.HandlerFunc(
+		func(w http.ResponseWriter, r *http.Request) {
+			if r.URL.Path == "/api/v3/time" {
+				// Drop the connection, so that the sync fails in transport.
+				conn, _, err := w.(http.Hijacker).Hijack()
+				require.NoError(t, err)
+				conn.Close()
+				return
+			}
+
+			mu.Lock()
+			defer mu.Unlock()
+			if r.Method == http.MethodPost {
+				posts++
+			}
+			w.Write([]byte(queriedOrder))
+		}))
+	defer srv.Close()
+
+	c := NewClient(WithBaseURL(srv.URL+"/api/v3"), WithTimeSync(time.Minute),
+		WithRetryPolicy(RetryPolicy{}))
+	_, err := c.NewOrder(context.Background(), &NewOrderRequest{
+		Symbol: "LTCBTC",
+	})
+
+	// The order was never sent, so it isn't reconciled.
+	require.Error(t, err)
+	require.False(t, errors.Is(err, ErrOrderStatusUnknown))
+	require.False(t, errors.Is(err, ErrOrderNotPlaced))
+
+	mu.Lock()
+	defer mu.Unlock()
+	require.Equal(t, 0, posts)
+}
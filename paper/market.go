@@ -0,0 +1,97 @@
+package paper
+
+import (
+	"context"
+	"time"
+
+	"github.com/nickcorin/binance"
+)
+
+// Ping pings the exchange.
+func (p *Client) Ping(ctx context.Context) error {
+	return p.exchange.Ping(ctx)
+}
+
+// ServerTime returns the exchange's time.
+func (p *Client) ServerTime(ctx context.Context) (time.Time, error) {
+	return p.exchange.ServerTime(ctx)
+}
+
+// ExchangeInfo returns the exchange's trading rules.
+func (p *Client) ExchangeInfo(ctx context.Context) (*binance.ExchangeInfo,
+	error) {
+	return p.exchange.ExchangeInfo(ctx)
+}
+
+// OrderBook returns a market's order book on the exchange. Simulated orders
+// don't appear in it.
+func (p *Client) OrderBook(ctx context.Context,
+	r *binance.OrderBookRequest) (*binance.OrderBook, error) {
+	return p.exchange.OrderBook(ctx, r)
+}
+
+// RecentTrades returns a market's recent trades on the exchange.
+func (p *Client) RecentTrades(ctx context.Context,
+	r *binance.RecentTradesRequest) ([]binance.Trade, error) {
+	return p.exchange.RecentTrades(ctx, r)
+}
+
+// HistoricalTrades returns a market's older trades on the exchange.
+func (p *Client) HistoricalTrades(ctx context.Context,
+	r *binance.HistoricalTradesRequest) ([]binance.Trade, error) {
+	return p.exchange.HistoricalTrades(ctx, r)
+}
+
+// AggregateTrades returns a market's aggregate trades on the exchange.
+func (p *Client) AggregateTrades(ctx context.Context,
+	r *binance.AggregateTradesRequest) ([]binance.AggregateTrade, error) {
+	return p.exchange.AggregateTrades(ctx, r)
+}
+
+// Klines returns a market's klines on the exchange.
+func (p *Client) Klines(ctx context.Context, r *binance.KlinesRequest) (
+	[]binance.Kline, error) {
+	return p.exchange.Klines(ctx, r)
+}
+
+// AveragePrice returns a market's average price on the exchange.
+func (p *Client) AveragePrice(ctx context.Context, symbol string) (
+	*binance.AveragePrice, error) {
+	return p.exchange.AveragePrice(ctx, symbol)
+}
+
+// TickerStats returns a market's 24 hour statistics on the exchange.
+func (p *Client) TickerStats(ctx context.Context, symbol string) (
+	*binance.TickerStats, error) {
+	return p.exchange.TickerStats(ctx, symbol)
+}
+
+// ListTickerStats returns the 24 hour statistics of markets on the exchange.
+func (p *Client) ListTickerStats(ctx context.Context,
+	symbols ...string) ([]binance.TickerStats, error) {
+	return p.exchange.ListTickerStats(ctx, symbols...)
+}
+
+// PriceTicker returns a market's last price on the exchange.
+func (p *Client) PriceTicker(ctx context.Context, symbol string) (
+	*binance.PriceTicker, error) {
+	return p.exchange.PriceTicker(ctx, symbol)
+}
+
+// ListPriceTickers returns the last prices of markets on the exchange.
+func (p *Client) ListPriceTickers(ctx context.Context,
+	symbols ...string) ([]binance.PriceTicker, error) {
+	return p.exchange.ListPriceTickers(ctx, symbols...)
+}
+
+// OrderBookTicker returns a market's best prices on the exchange.
+func (p *Client) OrderBookTicker(ctx context.Context, symbol string) (
+	*binance.OrderBookTicker, error) {
+	return p.exchange.OrderBookTicker(ctx, symbol)
+}
+
+// ListOrderBookTickers returns the best prices of markets on the exchange.
+func (p *Client) ListOrderBookTickers(ctx context.Context,
+	symbols ...string) ([]binance.OrderBookTicker, error) {
+	return p.exchange.ListOrderBookTickers(ctx, symbols...)
+}
@@ -0,0 +1,153 @@
+package paper
+
+import (
+	"context"
+
+	"github.com/nickcorin/binance"
+	"github.com/nickcorin/binance/internal/engine"
+)
+
+var (
+	errNoSuchList = binance.Error{Code: binance.ErrNoSuchOrder,
+		Message: "Order list does not exist."}
+	errUnknownList = binance.Error{Code: binance.ErrCancelRejected,
+		Message: "Unknown order list sent."}
+)
+
+// NewOCO simulates placing a one-cancels-the-other order list. The limit
+// order reserves the funds of the list, which the stop order reserves again
+// once it is triggered.
+func (p *Client) NewOCO(ctx context.Context, r *binance.NewOCORequest) (
+	*binance.OrderList, error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	m, err := p.market(ctx, r.Symbol)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := p.sync(ctx, m); err != nil {
+		return nil, err
+	}
+
+	limitReq, stopReq, err := engine.OCOOrders(r)
+	if err != nil {
+		return nil, err
+	}
+
+	avg, err := p.averagePrice(ctx, r.Symbol)
+	if err != nil {
+		return nil, err
+	}
+
+	err = engine.CheckOCO(m, p.account, limitReq, stopReq, avg)
+	if err != nil {
+		return nil, err
+	}
+
+	levels, err := p.levels(ctx, r.Symbol, r.Side)
+	if err != nil {
+		return nil, err
+	}
+
+	last, err := p.lastPrice(ctx, r.Symbol)
+	if err != nil {
+		return nil, err
+	}
+
+	l, err := p.engine.PlaceOCO(m, p.account, r.ListClientOrderID,
+		engine.NewOrder(p.account, limitReq),
+		engine.NewOrder(p.account, stopReq), levels, last)
+	if err != nil {
+		return nil, err
+	}
+
+	res := l.Response(true)
+	return &res, nil
+}
+
+// CancelOCO cancels a simulated order list.
+func (p *Client) CancelOCO(ctx context.Context, r *binance.CancelOCORequest) (
+	*binance.OrderList, error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	m, err := p.market(ctx, r.Symbol)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := p.sync(ctx, m); err != nil {
+		return nil, err
+	}
+
+	l := p.engine.LookupList(p.account, r.OrderListID, r.ListClientOrderID)
+	if l == nil || l.Symbol != r.Symbol || !l.IsOpen() {
+		return nil, errUnknownList
+	}
+
+	p.engine.Cancel(m, l.Orders[0])
+	res := l.Response(true)
+	return &res, nil
+}
+
+// QueryOCO returns a simulated order list.
+func (p *Client) QueryOCO(ctx context.Context, r *binance.QueryOCORequest) (
+	*binance.OrderList, error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	l := p.engine.LookupList(p.account, r.OrderListID, r.OrigClientOrderID)
+	if l == nil {
+		return nil, errNoSuchList
+	}
+
+	if err := p.sync(ctx, p.markets[l.Symbol]); err != nil {
+		return nil, err
+	}
+
+	res := l.Response(false)
+	return &res, nil
+}
+
+// AllOCO returns the simulated order lists.
+func (p *Client) AllOCO(ctx context.Context, r *binance.AllOCORequest) (
+	[]binance.OrderList, error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	if err := p.syncAll(ctx); err != nil {
+		return nil, err
+	}
+
+	var res []binance.OrderList
+	for _, l := range p.engine.Lists {
+		if l.ID < r.FromID || l.Time < r.StartTime ||
+			r.EndTime != 0 && l.Time > r.EndTime {
+			continue
+		}
+		res = append(res, l.Response(false))
+	}
+
+	i, j := limitRange(len(res), r.Limit, r.FromID == 0 && r.StartTime == 0)
+	return res[i:j], nil
+}
+
+// OpenOCO returns the open simulated order lists.
+func (p *Client) OpenOCO(ctx context.Context) ([]binance.OrderList, error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	if err := p.syncAll(ctx); err != nil {
+		return nil, err
+	}
+
+	var res []binance.OrderList
+	for _, l := range p.engine.Lists {
+		if l.IsOpen() {
+			res = append(res, l.Response(false))
+		}
+	}
+	return res, nil
+}
@@ -0,0 +1,717 @@
+// Package paper provides a binance.Client which simulates trading against a
+// virtual balance sheet, while reading market data from the exchange.
+//
+// Orders which can execute when they are placed are filled against the order
+// book as a taker. Resting orders are filled as a maker once the market trades
+// through their price, and stop orders are triggered once it trades at their
+// stop price. Both are checked with the market's trades and book ticker
+// before the account or its orders are queried, and before orders are placed
+// or cancelled. Only trades made after an order was placed count towards it.
+package paper
+
+import (
+	"context"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/luno/jettison/errors"
+	"github.com/luno/jettison/j"
+	"github.com/nickcorin/binance"
+	"github.com/nickcorin/binance/internal/engine"
+)
+
+// DefaultCommission is the maker and taker commission charged by a Client
+// unless configured otherwise, in hundredths of a percent as reported by
+// AccountInfo.
+const DefaultCommission = 10
+
+// depthLimit is the number of price levels that a Client fetches to fill
+// orders which take from the book.
+const depthLimit = 100
+
+// tradeLimit is the most klines or aggregate trades that a Client fetches at
+// once when syncing orders, and klineInterval is the length of a kline in
+// milliseconds.
+const (
+	klineInterval = 60000
+	tradeLimit    = 1000
+)
+
+// ErrUnsupported is returned by a Client for requests which can't be
+// simulated, such as user data streams.
+var ErrUnsupported = errors.New("not supported by paper client",
+	j.C("ERR_PAPER_UNSUPPORTED"))
+
+// Errors returned by a Client, in the form that the exchange returns them.
+var (
+	errBadSymbol = binance.Error{Code: binance.ErrBadSymbol,
+		Message: "Invalid symbol."}
+	errNoSuchOrder = binance.Error{Code: binance.ErrNoSuchOrder,
+		Message: "Order does not exist."}
+	errUnknownOrder = binance.Error{Code: binance.ErrCancelRejected,
+		Message: "Unknown order sent."}
+)
+
+// Client is a binance.Client which simulates trading. Market data is queried
+// from the binance.Client it wraps, while orders, balances and trades are
+// kept in memory and never sent to the exchange.
+//
+// A Client is safe for concurrent use.
+type Client struct {
+	exchange        binance.Client
+	makerCommission int
+	now             func() time.Time
+	symbols         *binance.SymbolRegistry
+	takerCommission int
+
+	// mu guards the account's state, and is held while the market is
+	// queried so that orders are filled in a consistent order.
+	mu       sync.Mutex
+	account  *engine.Account
+	engine   *engine.Engine
+	lastSync map[string]int64
+	markets  map[string]*engine.Market
+}
+
+// Client must implement every method of binance.Client itself, so that a
+// method added to binance.Client can't be passed through to the exchange
+// unnoticed.
+var _ binance.Client = (*Client)(nil)
+
+// Option is a func-to-Client adapter.
+type Option func(*Client)
+
+// WithBalance returns an Option to add `amount` of `asset` to the account's
+// starting balance.
+func WithBalance(asset string, amount binance.Decimal) Option {
+	return func(p *Client) {
+		p.account.Credit(asset, amount)
+	}
+}
+
+// WithClock returns an Option to set the func used to read the current time,
+// which timestamps orders and trades. This is useful for testing. Defaults to
+// time.Now.
+func WithClock(now func() time.Time) Option {
+	return func(p *Client) {
+		p.now = now
+	}
+}
+
+// WithCommission returns an Option to set the commission charged on trades,
+// in hundredths of a percent. Defaults to DefaultCommission.
+func WithCommission(maker, taker int) Option {
+	return func(p *Client) {
+		p.makerCommission = maker
+		p.takerCommission = taker
+	}
+}
+
+// WithSymbols returns an Option to set the registry which orders are
+// validated against. By default a registry is loaded from the wrapped
+// binance.Client when the first order is placed, and never refreshed.
+func WithSymbols(symbols *binance.SymbolRegistry) Option {
+	return func(p *Client) {
+		p.symbols = symbols
+	}
+}
+
+// NewClient returns a Client which queries market data from `exchange`. The
+// account starts without any balances unless WithBalance is used.
+func NewClient(exchange binance.Client, opts ...Option) *Client {
+	p := Client{
+		account:         engine.NewAccount(),
+		exchange:        exchange,
+		lastSync:        make(map[string]int64),
+		makerCommission: DefaultCommission,
+		markets:         make(map[string]*engine.Market),
+		now:             time.Now,
+		takerCommission: DefaultCommission,
+	}
+
+	for _, o := range opts {
+		o(&p)
+	}
+
+	if p.symbols == nil {
+		p.symbols = binance.NewSymbolRegistry(exchange)
+	}
+
+	p.engine = &engine.Engine{
+		ClientIDPrefix:  "paper",
+		MakerCommission: p.makerCommission,
+		Now:             p.now,
+		TakerCommission: p.takerCommission,
+	}
+
+	return &p
+}
+
+func (p *Client) timestamp() int64 {
+	return p.now().UnixNano() / 1e6
+}
+
+// market returns the market of `symbol`, loading the registry if it is
+// empty.
+func (p *Client) market(ctx context.Context, symbol string) (*engine.Market,
+	error) {
+	if m, ok := p.markets[symbol]; ok {
+		return m, nil
+	}
+
+	if p.symbols.Refreshed().IsZero() {
+		if err := p.symbols.Refresh(ctx); err != nil {
+			return nil, err
+		}
+	}
+
+	info, ok := p.symbols.Lookup(symbol)
+	if !ok {
+		return nil, errBadSymbol
+	}
+
+	m := engine.NewMarket(*info)
+	p.markets[symbol] = m
+	return m, nil
+}
+
+// averagePrice returns the market's average price, which orders are checked
+// against.
+func (p *Client) averagePrice(ctx context.Context, symbol string) (
+	binance.Decimal, error) {
+	avg, err := p.exchange.AveragePrice(ctx, symbol)
+	if err != nil {
+		return binance.Decimal{}, errors.Wrap(err,
+			"failed to query average price")
+	}
+	return avg.Price, nil
+}
+
+// checkOrder returns the error that the exchange would reject `r` with, if
+// any.
+func (p *Client) checkOrder(ctx context.Context, m *engine.Market,
+	r *binance.NewOrderRequest) error {
+	avg, err := p.averagePrice(ctx, r.Symbol)
+	if err != nil {
+		return err
+	}
+
+	return engine.CheckOrder(m, p.account, r, avg)
+}
+
+// lastPrice returns the market's last traded price.
+func (p *Client) lastPrice(ctx context.Context, symbol string) (
+	binance.Decimal, error) {
+	ticker, err := p.exchange.PriceTicker(ctx, symbol)
+	if err != nil {
+		return binance.Decimal{}, errors.Wrap(err,
+			"failed to query price ticker")
+	}
+	return ticker.Price, nil
+}
+
+// levels returns the side of the order book that orders on `side` take from.
+func (p *Client) levels(ctx context.Context, symbol string,
+	side binance.OrderSide) ([]engine.Level, error) {
+	book, err := p.exchange.OrderBook(ctx, &binance.OrderBookRequest{
+		Limit:  depthLimit,
+		Symbol: symbol,
+	})
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to query order book")
+	}
+
+	if side == binance.Buy {
+		return engine.BookLevels(book.Asks), nil
+	}
+	return engine.BookLevels(book.Bids), nil
+}
+
+// sync fills and triggers the open orders on `m` according to how the
+// market has moved since it was last synced. If it fails, the market is
+// synced from the same point the next time.
+func (p *Client) sync(ctx context.Context, m *engine.Market) error {
+	symbol := m.Info.Symbol
+	open := m.OpenOrders(p.account)
+	now := p.timestamp()
+
+	since, ok := p.lastSync[symbol]
+	if len(open) == 0 || !ok {
+		p.lastSync[symbol] = now
+		return nil
+	}
+
+	traded, err := p.tradedSince(ctx, symbol, since, now)
+	if err != nil {
+		return err
+	}
+
+	ticker, err := p.exchange.OrderBookTicker(ctx, symbol)
+	if err != nil {
+		return errors.Wrap(err, "failed to query order book ticker")
+	}
+
+	for _, o := range open {
+		switch {
+		case !o.IsOpen():
+		case !o.Working:
+			if !o.TriggeredBy(traded.low, traded.high) {
+				continue
+			}
+
+			levels, err := p.levels(ctx, symbol, o.Side)
+			if err != nil {
+				return err
+			}
+			p.engine.Trigger(m, o, levels)
+		case reached(o, traded, ticker):
+			p.engine.Fill(m, nil, o, o.Price, o.Remaining())
+		}
+	}
+
+	p.lastSync[symbol] = now
+	return nil
+}
+
+// priceRange is the range of prices that a market traded in. Both bounds
+// are zero if it didn't trade.
+type priceRange struct {
+	low, high binance.Decimal
+}
+
+func (r *priceRange) add(low, high binance.Decimal) {
+	if r.low.IsZero() || low.LessThan(r.low) {
+		r.low = low
+	}
+	if high.GreaterThan(r.high) {
+		r.high = high
+	}
+}
+
+// tradedSince returns the range that `symbol` traded in from `since` until
+// `now`. The kline of the minute containing `since` includes trades from
+// before it, so that partial minute is read from aggregate trades instead.
+// The whole minutes after it are read from one minute klines.
+func (p *Client) tradedSince(ctx context.Context, symbol string, since,
+	now int64) (priceRange, error) {
+	var res priceRange
+
+	start := since
+	if rem := since % klineInterval; rem != 0 {
+		start += klineInterval - rem
+
+		end := start - 1
+		if now < end {
+			end = now
+		}
+
+		err := p.addAggregateTrades(ctx, &res, symbol, since, end)
+		if err != nil {
+			return priceRange{}, err
+		}
+	}
+
+	for start <= now {
+		klines, err := p.exchange.Klines(ctx, &binance.KlinesRequest{
+			Interval:  binance.OneMinute,
+			Limit:     tradeLimit,
+			StartTime: start,
+			Symbol:    symbol,
+		})
+		if err != nil {
+			return priceRange{}, errors.Wrap(err, "failed to query klines")
+		}
+
+		for _, k := range klines {
+			res.add(k.Low, k.High)
+		}
+		if len(klines) < tradeLimit {
+			break
+		}
+		start = klines[len(klines)-1].OpenTime + klineInterval
+	}
+	return res, nil
+}
+
+// addAggregateTrades adds the prices that `symbol` traded at from `start`
+// until `end` to `r`, paging through the trades if there are more than can
+// be queried at once.
+func (p *Client) addAggregateTrades(ctx context.Context, r *priceRange,
+	symbol string, start, end int64) error {
+	req := binance.AggregateTradesRequest{
+		EndTime:   end,
+		Limit:     tradeLimit,
+		StartTime: start,
+		Symbol:    symbol,
+	}
+
+	for {
+		trades, err := p.exchange.AggregateTrades(ctx, &req)
+		if err != nil {
+			return errors.Wrap(err, "failed to query aggregate trades")
+		}
+
+		for _, t := range trades {
+			if t.Time > end {
+				return nil
+			}
+			r.add(t.Price, t.Price)
+		}
+		if len(trades) < tradeLimit {
+			return nil
+		}
+
+		// Later pages are queried by ID, which can't be combined with a
+		// time range.
+		req = binance.AggregateTradesRequest{
+			FromID: trades[len(trades)-1].ID + 1,
+			Limit:  tradeLimit,
+			Symbol: symbol,
+		}
+	}
+}
+
+// reached returns whether the market has traded through the price of the
+// resting order `o`, or the book has moved through it. Trading at the price
+// isn't enough, since the order would be queued behind others at that level.
+func reached(o *engine.Order, traded priceRange,
+	ticker *binance.OrderBookTicker) bool {
+	if o.Side == binance.Buy {
+		return !traded.low.IsZero() && traded.low.LessThan(o.Price) ||
+			!ticker.AskPrice.IsZero() && ticker.AskPrice.LessThan(o.Price)
+	}
+	return traded.high.GreaterThan(o.Price) ||
+		ticker.BidPrice.GreaterThan(o.Price)
+}
+
+// syncAll syncs every market with open orders.
+func (p *Client) syncAll(ctx context.Context) error {
+	symbols := make([]string, 0, len(p.markets))
+	for symbol := range p.markets {
+		symbols = append(symbols, symbol)
+	}
+	sort.Strings(symbols)
+
+	for _, symbol := range symbols {
+		if err := p.sync(ctx, p.markets[symbol]); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// place checks and places a new order.
+func (p *Client) place(ctx context.Context, r *binance.NewOrderRequest) (
+	*engine.Order, error) {
+	m, err := p.market(ctx, r.Symbol)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := p.sync(ctx, m); err != nil {
+		return nil, err
+	}
+
+	if err := p.checkOrder(ctx, m, r); err != nil {
+		return nil, err
+	}
+
+	// Stop orders are checked against the last price, and other orders
+	// against the book.
+	o := engine.NewOrder(p.account, r)
+	var (
+		levels []engine.Level
+		last   binance.Decimal
+	)
+	if o.IsStop() {
+		last, err = p.lastPrice(ctx, r.Symbol)
+	} else {
+		levels, err = p.levels(ctx, r.Symbol, r.Side)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	if err := p.engine.Place(m, o, levels, last); err != nil {
+		return nil, err
+	}
+	return o, nil
+}
+
+// NewOrder simulates placing an order.
+func (p *Client) NewOrder(ctx context.Context, r *binance.NewOrderRequest) (
+	*binance.NewOrderResponse, error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	respType, err := engine.ResponseType(r)
+	if err != nil {
+		return nil, err
+	}
+
+	o, err := p.place(ctx, r)
+	if err != nil {
+		return nil, err
+	}
+
+	return o.Response(respType), nil
+}
+
+// NewOrderTest checks an order as NewOrder would, without placing it.
+func (p *Client) NewOrderTest(ctx context.Context,
+	r *binance.NewOrderRequest) error {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	if _, err := engine.ResponseType(r); err != nil {
+		return err
+	}
+
+	m, err := p.market(ctx, r.Symbol)
+	if err != nil {
+		return err
+	}
+
+	return p.checkOrder(ctx, m, r)
+}
+
+// QueryOrder returns a simulated order.
+func (p *Client) QueryOrder(ctx context.Context,
+	r *binance.QueryOrderRequest) (*binance.QueryOrderResponse, error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	m, err := p.market(ctx, r.Symbol)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := p.sync(ctx, m); err != nil {
+		return nil, err
+	}
+
+	o := m.Lookup(p.account, r.OrderID, r.OrigClientOrderID)
+	if o == nil {
+		return nil, errNoSuchOrder
+	}
+
+	res := o.QueryResponse()
+	return &res, nil
+}
+
+// CancelOrder cancels a simulated order. Cancelling an order in an OCO
+// cancels the whole list.
+func (p *Client) CancelOrder(ctx context.Context,
+	r *binance.CancelOrderRequest) (*binance.CancelOrderResponse, error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	m, err := p.market(ctx, r.Symbol)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := p.sync(ctx, m); err != nil {
+		return nil, err
+	}
+
+	o := m.Lookup(p.account, r.OrderID, r.OrigClientOrderID)
+	if o == nil || !o.IsOpen() {
+		return nil, errUnknownOrder
+	}
+
+	p.engine.Cancel(m, o)
+	res := o.CancelResponse()
+	return &res, nil
+}
+
+// CancelAllOpenOrders cancels every open simulated order on a market.
+func (p *Client) CancelAllOpenOrders(ctx context.Context,
+	symbol string) ([]binance.CancelOrderResponse, error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	m, err := p.market(ctx, symbol)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := p.sync(ctx, m); err != nil {
+		return nil, err
+	}
+
+	// Orders in a list are cancelled with their list, and returned together
+	// like the exchange returns them.
+	var res []binance.CancelOrderResponse
+	for _, o := range m.OpenOrders(p.account) {
+		if o.List != nil && !o.IsOpen() {
+			continue
+		}
+
+		p.engine.Cancel(m, o)
+		if o.List == nil {
+			res = append(res, o.CancelResponse())
+			continue
+		}
+		for _, lo := range o.List.Orders {
+			res = append(res, lo.CancelResponse())
+		}
+	}
+
+	if len(res) == 0 {
+		return nil, errUnknownOrder
+	}
+	return res, nil
+}
+
+// OpenOrders returns the open simulated orders on a market, or on every
+// market if `symbol` is empty.
+func (p *Client) OpenOrders(ctx context.Context, symbol string) (
+	[]binance.QueryOrderResponse, error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	markets := make([]*engine.Market, 0, len(p.markets))
+	if symbol != "" {
+		m, err := p.market(ctx, symbol)
+		if err != nil {
+			return nil, err
+		}
+		if err := p.sync(ctx, m); err != nil {
+			return nil, err
+		}
+		markets = append(markets, m)
+	} else {
+		if err := p.syncAll(ctx); err != nil {
+			return nil, err
+		}
+		for _, m := range p.markets {
+			markets = append(markets, m)
+		}
+	}
+
+	var res []binance.QueryOrderResponse
+	for _, m := range markets {
+		for _, o := range m.OpenOrders(p.account) {
+			res = append(res, o.QueryResponse())
+		}
+	}
+
+	// Orders are listed in order of placement, across markets.
+	sort.Slice(res, func(i, j int) bool {
+		return res[i].OrderID < res[j].OrderID
+	})
+	return res, nil
+}
+
+// AllOrders returns the simulated orders on a market.
+func (p *Client) AllOrders(ctx context.Context, r *binance.AllOrdersRequest) (
+	[]binance.QueryOrderResponse, error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	m, err := p.market(ctx, r.Symbol)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := p.sync(ctx, m); err != nil {
+		return nil, err
+	}
+
+	var res []binance.QueryOrderResponse
+	for _, o := range m.Orders {
+		if o.ID < r.OrderID || o.Time < r.StartTime ||
+			r.EndTime != 0 && o.Time > r.EndTime {
+			continue
+		}
+		res = append(res, o.QueryResponse())
+	}
+
+	i, j := limitRange(len(res), r.Limit, r.OrderID == 0 && r.StartTime == 0)
+	return res[i:j], nil
+}
+
+// limitRange returns the range of a list of `n` results to keep, given the
+// request's `limit`, which defaults to 500. The most recent results are kept
+// if `latest` is set, otherwise the earliest are.
+func limitRange(n int, limit int64, latest bool) (int, int) {
+	if limit <= 0 {
+		limit = 500
+	}
+
+	switch {
+	case int64(n) <= limit:
+		return 0, n
+	case latest:
+		return n - int(limit), n
+	default:
+		return 0, int(limit)
+	}
+}
+
+// AccountInfo returns the simulated account's balances.
+func (p *Client) AccountInfo(ctx context.Context) (*binance.AccountInfo,
+	error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	if err := p.syncAll(ctx); err != nil {
+		return nil, err
+	}
+
+	return &binance.AccountInfo{
+		AccountType:     "SPOT",
+		Balances:        p.account.Balances(),
+		CanTrade:        true,
+		MakerCommission: p.makerCommission,
+		TakerCommission: p.takerCommission,
+		UpdateTime:      p.timestamp(),
+	}, nil
+}
+
+// AccountTrades returns the simulated trades on a market.
+func (p *Client) AccountTrades(ctx context.Context,
+	r *binance.AccountTradesRequest) ([]binance.AccountTrade, error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	m, err := p.market(ctx, r.Symbol)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := p.sync(ctx, m); err != nil {
+		return nil, err
+	}
+
+	var res []binance.AccountTrade
+	for _, t := range p.account.Trades {
+		if t.Symbol != r.Symbol || r.OrderID != 0 && t.OrderID != r.OrderID ||
+			t.ID < r.FromID || t.Time < r.StartTime ||
+			r.EndTime != 0 && t.Time > r.EndTime {
+			continue
+		}
+		res = append(res, t)
+	}
+
+	i, j := limitRange(len(res), r.Limit, r.FromID == 0 && r.StartTime == 0)
+	return res[i:j], nil
+}
+
+// StartUserDataStream returns ErrUnsupported, since simulated orders don't
+// produce user data events.
+func (p *Client) StartUserDataStream(context.Context) (string, error) {
+	return "", ErrUnsupported
+}
+
+// KeepAliveUserDataStream returns ErrUnsupported.
+func (p *Client) KeepAliveUserDataStream(context.Context, string) error {
+	return ErrUnsupported
+}
+
+// CloseUserDataStream returns ErrUnsupported.
+func (p *Client) CloseUserDataStream(context.Context, string) error {
+	return ErrUnsupported
+}
@@ -0,0 +1,680 @@
+package paper
+
+import (
+	"context"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/luno/jettison/errors"
+	"github.com/nickcorin/binance"
+	"github.com/stretchr/testify/require"
+)
+
+var dec = binance.MustParseDecimal
+
+// paperMarket is a binance.Client which serves fixed market data to a Client.
+type paperMarket struct {
+	binance.Client
+
+	asks   []binance.PriceLevel
+	bids   []binance.PriceLevel
+	klines []binance.Kline
+	last   binance.Decimal
+	trades []binance.AggregateTrade
+
+	// klinesErr is returned by Klines if it is set.
+	klinesErr error
+}
+
+func (m *paperMarket) ExchangeInfo(context.Context) (*binance.ExchangeInfo,
+	error) {
+	return &binance.ExchangeInfo{Symbols: []binance.SymbolInfo{{
+		BaseAsset:          "BTC",
+		BaseAssetPrecision: 8,
+		Filters: binance.Filters{
+			&binance.PriceFilter{TickSize: dec("0.01")},
+			&binance.LotSizeFilter{
+				MaxQty:   dec("1000"),
+				MinQty:   dec("0.001"),
+				StepSize: dec("0.001"),
+			},
+		},
+		OCOAllowed: true,
+		OrderTypes: []binance.OrderType{binance.OrderTypeLimit,
+			binance.OrderTypeLimitMaker, binance.OrderTypeMarket,
+			binance.OrderTypeStopLoss, binance.OrderTypeStopLossLimit,
+			binance.OrderTypeTakeProfit, binance.OrderTypeTakeProfitLimit},
+		QuoteOrderQtyMarketAllowed: true,
+		QuoteAsset:                 "USDT",
+		QuoteAssetPrecision:        8,
+		Status:                     binance.SymbolStatusTrading,
+		Symbol:                     "BTCUSDT",
+	}}}, nil
+}
+
+func (m *paperMarket) AveragePrice(context.Context, string) (
+	*binance.AveragePrice, error) {
+	return &binance.AveragePrice{Mins: 5, Price: m.last}, nil
+}
+
+func (m *paperMarket) AggregateTrades(_ context.Context,
+	r *binance.AggregateTradesRequest) ([]binance.AggregateTrade, error) {
+	var res []binance.AggregateTrade
+	for _, t := range m.trades {
+		if t.ID < r.FromID || t.Time < r.StartTime ||
+			r.EndTime != 0 && t.Time > r.EndTime {
+			continue
+		}
+		if int64(len(res)) == r.Limit {
+			break
+		}
+		res = append(res, t)
+	}
+	return res, nil
+}
+
+// trade records a trade at `price` at time `at`.
+func (m *paperMarket) trade(at time.Time, price string) {
+	m.trades = append(m.trades, binance.AggregateTrade{
+		ID:    int64(len(m.trades) + 1),
+		Price: dec(price),
+		Time:  at.UnixNano() / 1e6,
+	})
+}
+
+func (m *paperMarket) Klines(_ context.Context, r *binance.KlinesRequest) (
+	[]binance.Kline, error) {
+	if m.klinesErr != nil {
+		return nil, m.klinesErr
+	}
+
+	var res []binance.Kline
+	for _, k := range m.klines {
+		if k.OpenTime >= r.StartTime && int64(len(res)) < r.Limit {
+			res = append(res, k)
+		}
+	}
+	return res, nil
+}
+
+func (m *paperMarket) OrderBook(context.Context, *binance.OrderBookRequest) (
+	*binance.OrderBook, error) {
+	return &binance.OrderBook{Asks: m.asks, Bids: m.bids}, nil
+}
+
+func (m *paperMarket) OrderBookTicker(context.Context, string) (
+	*binance.OrderBookTicker, error) {
+	var t binance.OrderBookTicker
+	if len(m.asks) > 0 {
+		t.AskPrice, t.AskQty = m.asks[0].Price, m.asks[0].Qty
+	}
+	if len(m.bids) > 0 {
+		t.BidPrice, t.BidQty = m.bids[0].Price, m.bids[0].Qty
+	}
+	return &t, nil
+}
+
+func (m *paperMarket) PriceTicker(_ context.Context, symbol string) (
+	*binance.PriceTicker, error) {
+	return &binance.PriceTicker{Price: m.last, Symbol: symbol}, nil
+}
+
+func level(price, qty string) binance.PriceLevel {
+	return binance.PriceLevel{Price: dec(price),
+		Qty: dec(qty)}
+}
+
+// paperClock is a clock which is advanced by tests.
+type paperClock struct {
+	now time.Time
+}
+
+func (c *paperClock) Now() time.Time {
+	return c.now
+}
+
+// advance moves the clock forward a minute, and returns the kline of the
+// minute that passed, which traded between `low` and `high`.
+func (c *paperClock) advance(low, high string) binance.Kline {
+	k := binance.Kline{
+		High:     dec(high),
+		Low:      dec(low),
+		OpenTime: c.now.UnixNano() / 1e6,
+	}
+	c.now = c.now.Add(time.Minute)
+	return k
+}
+
+func newTestClient(opts ...Option) (*Client, *paperMarket, *paperClock) {
+	m := paperMarket{
+		asks: []binance.PriceLevel{level("100", "1"), level("101", "2")},
+		bids: []binance.PriceLevel{level("99", "1"), level("98", "5")},
+		last: dec("100"),
+	}
+	clock := paperClock{now: time.Unix(1599999960, 0)}
+
+	opts = append([]Option{WithClock(clock.Now)}, opts...)
+	return NewClient(&m, opts...), &m, &clock
+}
+
+func requireBalance(t *testing.T, p *Client, asset, free, locked string) {
+	info, err := p.AccountInfo(context.Background())
+	require.NoError(t, err)
+
+	for _, b := range info.Balances {
+		if b.Asset == asset {
+			require.True(t, dec(free).Equal(b.Free),
+				"free %s: %s", asset, b.Free)
+			require.True(t, dec(locked).Equal(b.Locked),
+				"locked %s: %s", asset, b.Locked)
+			return
+		}
+	}
+	require.Fail(t, "no balance", asset)
+}
+
+func TestClient_MarketOrder(t *testing.T) {
+	ctx := context.Background()
+	p, _, _ := newTestClient(
+		WithBalance("USDT", dec("10000")))
+
+	res, err := p.NewOrder(ctx, &binance.NewOrderRequest{
+		Qty:    dec("2"),
+		Side:   binance.Buy,
+		Symbol: "BTCUSDT",
+		Type:   binance.OrderTypeMarket,
+	})
+	require.NoError(t, err)
+	require.Equal(t, binance.OrderStatusFilled, res.Status)
+	require.Len(t, res.Fills, 2)
+	require.True(t, dec("201").Equal(res.CummulativeQuoteQty))
+	require.True(t, dec("0.001").Equal(res.Fills[0].Commission))
+	require.Equal(t, "BTC", res.Fills[0].CommissionAsset)
+
+	requireBalance(t, p, "BTC", "1.998", "0")
+	requireBalance(t, p, "USDT", "9799", "0")
+
+	// Selling by quote quantity rounds each fill down to the lot size.
+	res, err = p.NewOrder(ctx, &binance.NewOrderRequest{
+		QuoteOrderQty: dec("150"),
+		Side:          binance.Sell,
+		Symbol:        "BTCUSDT",
+		Type:          binance.OrderTypeMarket,
+	})
+	require.NoError(t, err)
+	require.Equal(t, binance.OrderStatusFilled, res.Status)
+	require.True(t, dec("1.52").Equal(res.ExecutedQty))
+	require.True(t, dec("149.96").Equal(
+		res.CummulativeQuoteQty))
+
+	requireBalance(t, p, "BTC", "0.478", "0")
+	requireBalance(t, p, "USDT", "9948.81004", "0")
+
+	trades, err := p.AccountTrades(ctx, &binance.AccountTradesRequest{
+		Symbol: "BTCUSDT",
+	})
+	require.NoError(t, err)
+	require.Len(t, trades, 4)
+	require.True(t, trades[0].IsBuyer)
+	require.False(t, trades[0].IsMaker)
+	require.Equal(t, "USDT", trades[3].CommissionAsset)
+
+	info, err := p.AccountInfo(ctx)
+	require.NoError(t, err)
+	require.Equal(t, DefaultCommission, info.MakerCommission)
+	require.Equal(t, DefaultCommission, info.TakerCommission)
+}
+
+func TestClient_LimitOrder(t *testing.T) {
+	ctx := context.Background()
+	p, m, clock := newTestClient(WithCommission(5, 10),
+		WithBalance("USDT", dec("1000")))
+
+	// Part of the order executes against the book, and the rest rests.
+	res, err := p.NewOrder(ctx, &binance.NewOrderRequest{
+		NewClientOrderID: "buy",
+		Price:            dec("100"),
+		Qty:              dec("3"),
+		Side:             binance.Buy,
+		Symbol:           "BTCUSDT",
+		TimeInForce:      binance.GoodUntilCancelled,
+		Type:             binance.OrderTypeLimit,
+	})
+	require.NoError(t, err)
+	require.Equal(t, binance.OrderStatusPartiallyFilled, res.Status)
+	requireBalance(t, p, "BTC", "0.999", "0")
+	requireBalance(t, p, "USDT", "700", "200")
+
+	// Nothing happens until the market trades through the price.
+	m.klines = append(m.klines, clock.advance("100", "102"))
+	open, err := p.OpenOrders(ctx, "BTCUSDT")
+	require.NoError(t, err)
+	require.Len(t, open, 1)
+
+	m.klines = append(m.klines, clock.advance("99.5", "101"))
+	order, err := p.QueryOrder(ctx, &binance.QueryOrderRequest{
+		OrigClientOrderID: "buy",
+		Symbol:            "BTCUSDT",
+	})
+	require.NoError(t, err)
+	require.Equal(t, binance.OrderStatusFilled, order.Status)
+	require.True(t, dec("300").Equal(order.CummulativeQuoteQty))
+	requireBalance(t, p, "BTC", "2.998", "0")
+	requireBalance(t, p, "USDT", "700", "0")
+
+	trades, err := p.AccountTrades(ctx, &binance.AccountTradesRequest{
+		OrderID: order.OrderID,
+		Symbol:  "BTCUSDT",
+	})
+	require.NoError(t, err)
+	require.Len(t, trades, 2)
+	require.True(t, trades[1].IsMaker)
+
+	_, err = p.CancelOrder(ctx, &binance.CancelOrderRequest{
+		OrderID: order.OrderID,
+		Symbol:  "BTCUSDT",
+	})
+	require.True(t, binance.IsError(err, binance.ErrCancelRejected))
+
+	// Cancelling a resting order returns its funds.
+	res, err = p.NewOrder(ctx, &binance.NewOrderRequest{
+		Price:       dec("110"),
+		Qty:         dec("2"),
+		Side:        binance.Sell,
+		Symbol:      "BTCUSDT",
+		TimeInForce: binance.GoodUntilCancelled,
+		Type:        binance.OrderTypeLimit,
+	})
+	require.NoError(t, err)
+	require.Equal(t, binance.OrderStatusNew, res.Status)
+	requireBalance(t, p, "BTC", "0.998", "2")
+
+	cancelled, err := p.CancelOrder(ctx, &binance.CancelOrderRequest{
+		OrderID: res.OrderID,
+		Symbol:  "BTCUSDT",
+	})
+	require.NoError(t, err)
+	require.Equal(t, binance.OrderStatusCancelled, cancelled.Status)
+	requireBalance(t, p, "BTC", "2.998", "0")
+
+	all, err := p.AllOrders(ctx, &binance.AllOrdersRequest{Symbol: "BTCUSDT"})
+	require.NoError(t, err)
+	require.Len(t, all, 2)
+}
+
+func TestClient_TimeInForce(t *testing.T) {
+	ctx := context.Background()
+	p, _, _ := newTestClient(
+		WithBalance("USDT", dec("1000")))
+
+	res, err := p.NewOrder(ctx, &binance.NewOrderRequest{
+		Price:       dec("100"),
+		Qty:         dec("2"),
+		Side:        binance.Buy,
+		Symbol:      "BTCUSDT",
+		TimeInForce: binance.FillOrKill,
+		Type:        binance.OrderTypeLimit,
+	})
+	require.NoError(t, err)
+	require.Equal(t, binance.OrderStatusExpired, res.Status)
+	require.Empty(t, res.Fills)
+
+	res, err = p.NewOrder(ctx, &binance.NewOrderRequest{
+		Price:       dec("100"),
+		Qty:         dec("2"),
+		Side:        binance.Buy,
+		Symbol:      "BTCUSDT",
+		TimeInForce: binance.ImmediateOrCancel,
+		Type:        binance.OrderTypeLimit,
+	})
+	require.NoError(t, err)
+	require.Equal(t, binance.OrderStatusExpired, res.Status)
+	require.True(t, dec("1").Equal(res.ExecutedQty))
+
+	requireBalance(t, p, "USDT", "900", "0")
+}
+
+func TestClient_Rejections(t *testing.T) {
+	tests := []struct {
+		req  binance.NewOrderRequest
+		code binance.ErrorCode
+	}{
+		{
+			req: binance.NewOrderRequest{Qty: dec("1"), Side: binance.Buy,
+				Symbol: "ETHBTC", Type: binance.OrderTypeMarket},
+			code: binance.ErrBadSymbol,
+		},
+		{
+			req: binance.NewOrderRequest{Qty: dec("1"), Side: binance.Buy,
+				Symbol: "BTCUSDT", TimeInForce: binance.GoodUntilCancelled,
+				Type: binance.OrderTypeLimit},
+			code: binance.ErrMandatoryParamEmptyOrMalformed,
+		},
+		{
+			req: binance.NewOrderRequest{Price: dec("100.001"),
+				Qty: dec("1"), Side: binance.Buy, Symbol: "BTCUSDT",
+				TimeInForce: binance.GoodUntilCancelled, Type: binance.OrderTypeLimit},
+			code: binance.ErrInvalidMessage,
+		},
+		{
+			req: binance.NewOrderRequest{Qty: dec("0.000000001"),
+				Side: binance.Buy, Symbol: "BTCUSDT", Type: binance.OrderTypeMarket},
+			code: binance.ErrBadPrecision,
+		},
+		{
+			req: binance.NewOrderRequest{Price: dec("50"),
+				Qty: dec("30"), Side: binance.Buy, Symbol: "BTCUSDT",
+				TimeInForce: binance.GoodUntilCancelled, Type: binance.OrderTypeLimit},
+			code: binance.ErrNewOrderRejected,
+		},
+		{
+			req: binance.NewOrderRequest{Price: dec("100"),
+				Qty: dec("1"), Side: binance.Buy, Symbol: "BTCUSDT",
+				Type: binance.OrderTypeLimitMaker},
+			code: binance.ErrNewOrderRejected,
+		},
+		{
+			req: binance.NewOrderRequest{Qty: dec("1"), Side: binance.Sell,
+				StopPrice: dec("105"), Symbol: "BTCUSDT",
+				Type: binance.OrderTypeStopLoss},
+			code: binance.ErrNewOrderRejected,
+		},
+		{
+			req: binance.NewOrderRequest{Qty: dec("1"), Side: binance.Sell,
+				Symbol: "BTCUSDT", TimeInForce: binance.GoodUntilCancelled,
+				Type: binance.OrderTypeMarket},
+			code: binance.ErrTIFNotRequired,
+		},
+	}
+
+	for i, test := range tests {
+		t.Run(fmt.Sprintf("%d", i), func(t *testing.T) {
+			p, _, _ := newTestClient(
+				WithBalance("BTC", dec("1")),
+				WithBalance("USDT", dec("1000")))
+
+			_, err := p.NewOrder(context.Background(), &test.req)
+			require.True(t, binance.IsError(err, test.code), "%v", err)
+
+			orders, err := p.AllOrders(context.Background(),
+				&binance.AllOrdersRequest{Symbol: "BTCUSDT"})
+			require.NoError(t, err)
+			require.Empty(t, orders)
+
+			requireBalance(t, p, "BTC", "1", "0")
+			requireBalance(t, p, "USDT", "1000", "0")
+		})
+	}
+}
+
+func TestClient_Klines(t *testing.T) {
+	ctx := context.Background()
+	p, m, clock := newTestClient(
+		WithBalance("USDT", dec("1000")))
+	start := clock.now
+
+	// The order is placed part way through a minute, which traded through
+	// its price before it was placed.
+	m.trade(start.Add(10*time.Second), "98")
+	clock.now = start.Add(30 * time.Second)
+	res, err := p.NewOrder(ctx, &binance.NewOrderRequest{
+		Price:       dec("99"),
+		Qty:         dec("1"),
+		Side:        binance.Buy,
+		Symbol:      "BTCUSDT",
+		TimeInForce: binance.GoodUntilCancelled,
+		Type:        binance.OrderTypeLimit,
+	})
+	require.NoError(t, err)
+
+	query := &binance.QueryOrderRequest{
+		OrderID: res.OrderID,
+		Symbol:  "BTCUSDT",
+	}
+
+	// The minute's kline includes the earlier trade, so only the trades
+	// since the order was placed are used.
+	clock.now = start
+	m.klines = append(m.klines, clock.advance("98", "100"))
+	clock.now = start.Add(45 * time.Second)
+	m.trade(start.Add(40*time.Second), "100")
+	order, err := p.QueryOrder(ctx, query)
+	require.NoError(t, err)
+	require.Equal(t, binance.OrderStatusNew, order.Status)
+
+	// Trades are synced even if there are more than can be queried at once.
+	for i := 0; i < 1500; i++ {
+		m.trade(start.Add(46*time.Second), "100")
+	}
+	m.trade(start.Add(50*time.Second), "98.5")
+	clock.now = start.Add(time.Minute)
+	order, err = p.QueryOrder(ctx, query)
+	require.NoError(t, err)
+	require.Equal(t, binance.OrderStatusFilled, order.Status)
+
+	// Orders are synced with every kline since the last sync, even if there
+	// are more than can be queried at once.
+	res, err = p.NewOrder(ctx, &binance.NewOrderRequest{
+		Price:       dec("99"),
+		Qty:         dec("1"),
+		Side:        binance.Buy,
+		Symbol:      "BTCUSDT",
+		TimeInForce: binance.GoodUntilCancelled,
+		Type:        binance.OrderTypeLimit,
+	})
+	require.NoError(t, err)
+
+	for i := 0; i < 1500; i++ {
+		m.klines = append(m.klines, clock.advance("100", "102"))
+	}
+	m.klines = append(m.klines, clock.advance("98", "100"))
+
+	order, err = p.QueryOrder(ctx, &binance.QueryOrderRequest{
+		OrderID: res.OrderID,
+		Symbol:  "BTCUSDT",
+	})
+	require.NoError(t, err)
+	require.Equal(t, binance.OrderStatusFilled, order.Status)
+}
+
+func TestClient_SyncFailed(t *testing.T) {
+	ctx := context.Background()
+	p, m, clock := newTestClient(
+		WithBalance("USDT", dec("1000")))
+
+	res, err := p.NewOrder(ctx, &binance.NewOrderRequest{
+		Price:       dec("99"),
+		Qty:         dec("1"),
+		Side:        binance.Buy,
+		Symbol:      "BTCUSDT",
+		TimeInForce: binance.GoodUntilCancelled,
+		Type:        binance.OrderTypeLimit,
+	})
+	require.NoError(t, err)
+
+	query := &binance.QueryOrderRequest{
+		OrderID: res.OrderID,
+		Symbol:  "BTCUSDT",
+	}
+
+	// The market trades through the price while the klines can't be
+	// queried.
+	m.klinesErr = errors.New("klines unavailable")
+	m.klines = append(m.klines, clock.advance("97", "100"))
+	_, err = p.QueryOrder(ctx, query)
+	require.Error(t, err)
+
+	// The next sync still sees the move.
+	m.klinesErr = nil
+	m.klines = append(m.klines, clock.advance("100", "102"))
+	order, err := p.QueryOrder(ctx, query)
+	require.NoError(t, err)
+	require.Equal(t, binance.OrderStatusFilled, order.Status)
+}
+
+func TestClient_StopOrders(t *testing.T) {
+	ctx := context.Background()
+	p, m, clock := newTestClient(
+		WithBalance("BTC", dec("2")))
+
+	stop, err := p.NewOrder(ctx, &binance.NewOrderRequest{
+		Qty:       dec("1"),
+		Side:      binance.Sell,
+		StopPrice: dec("95"),
+		Symbol:    "BTCUSDT",
+		Type:      binance.OrderTypeStopLoss,
+	})
+	require.NoError(t, err)
+
+	profit, err := p.NewOrder(ctx, &binance.NewOrderRequest{
+		Price:       dec("119"),
+		Qty:         dec("1"),
+		Side:        binance.Sell,
+		StopPrice:   dec("120"),
+		Symbol:      "BTCUSDT",
+		TimeInForce: binance.GoodUntilCancelled,
+		Type:        binance.OrderTypeTakeProfitLimit,
+	})
+	require.NoError(t, err)
+	requireBalance(t, p, "BTC", "0", "2")
+
+	// The take profit triggers, but rests since the book is below its price.
+	m.klines = append(m.klines, clock.advance("99", "120"))
+	order, err := p.QueryOrder(ctx, &binance.QueryOrderRequest{
+		OrderID: profit.OrderID,
+		Symbol:  "BTCUSDT",
+	})
+	require.NoError(t, err)
+	require.Equal(t, binance.OrderStatusNew, order.Status)
+	require.True(t, order.IsWorking)
+
+	// The stop loss triggers and sells into the book.
+	m.klines = append(m.klines, clock.advance("94", "99"))
+	order, err = p.QueryOrder(ctx, &binance.QueryOrderRequest{
+		OrderID: stop.OrderID,
+		Symbol:  "BTCUSDT",
+	})
+	require.NoError(t, err)
+	require.Equal(t, binance.OrderStatusFilled, order.Status)
+	require.True(t, dec("99").Equal(order.CummulativeQuoteQty))
+
+	requireBalance(t, p, "BTC", "0", "1")
+	requireBalance(t, p, "USDT", "98.901", "0")
+}
+
+func TestClient_OCO(t *testing.T) {
+	ctx := context.Background()
+	p, m, clock := newTestClient(
+		WithBalance("BTC", dec("2")))
+
+	req := binance.NewOCORequest{
+		Price:                dec("110"),
+		Qty:                  dec("1"),
+		Side:                 binance.Sell,
+		StopLimitPrice:       dec("89"),
+		StopLimitTimeInForce: binance.GoodUntilCancelled,
+		StopPrice:            dec("90"),
+		Symbol:               "BTCUSDT",
+	}
+
+	list, err := p.NewOCO(ctx, &req)
+	require.NoError(t, err)
+	require.Len(t, list.OrderReports, 2)
+	require.Equal(t, binance.OrderTypeStopLossLimit, list.OrderReports[0].Type)
+	require.Equal(t, binance.OrderTypeLimitMaker, list.OrderReports[1].Type)
+	requireBalance(t, p, "BTC", "1", "1")
+
+	// When the limit order fills the stop order expires.
+	m.klines = append(m.klines, clock.advance("100", "111"))
+	list, err = p.QueryOCO(ctx, &binance.QueryOCORequest{
+		OrderListID: list.OrderListID,
+	})
+	require.NoError(t, err)
+	require.Equal(t, binance.ListStatusTypeAllDone, list.ListStatusType)
+
+	orders, err := p.AllOrders(ctx, &binance.AllOrdersRequest{Symbol: "BTCUSDT"})
+	require.NoError(t, err)
+	require.Equal(t, binance.OrderStatusExpired, orders[0].Status)
+	require.Equal(t, binance.OrderStatusFilled, orders[1].Status)
+	requireBalance(t, p, "BTC", "1", "0")
+
+	// Cancelling one order cancels the list.
+	list, err = p.NewOCO(ctx, &req)
+	require.NoError(t, err)
+
+	_, err = p.CancelOrder(ctx, &binance.CancelOrderRequest{
+		OrderID: list.Orders[0].OrderID,
+		Symbol:  "BTCUSDT",
+	})
+	require.NoError(t, err)
+
+	open, err := p.OpenOCO(ctx)
+	require.NoError(t, err)
+	require.Empty(t, open)
+	requireBalance(t, p, "BTC", "1", "0")
+
+	_, err = p.CancelOCO(ctx, &binance.CancelOCORequest{
+		OrderListID: list.OrderListID,
+		Symbol:      "BTCUSDT",
+	})
+	require.True(t, binance.IsError(err, binance.ErrCancelRejected))
+
+	all, err := p.AllOCO(ctx, &binance.AllOCORequest{})
+	require.NoError(t, err)
+	require.Len(t, all, 2)
+}
+
+func TestClient_CancelAllOpenOrders(t *testing.T) {
+	ctx := context.Background()
+	p, m, clock := newTestClient(
+		WithBalance("BTC", dec("2")))
+
+	list, err := p.NewOCO(ctx, &binance.NewOCORequest{
+		Price:                dec("110"),
+		Qty:                  dec("1"),
+		Side:                 binance.Sell,
+		StopLimitPrice:       dec("95"),
+		StopLimitTimeInForce: binance.GoodUntilCancelled,
+		StopPrice:            dec("90"),
+		Symbol:               "BTCUSDT",
+	})
+	require.NoError(t, err)
+
+	_, err = p.NewOrder(ctx, &binance.NewOrderRequest{
+		Price:       dec("120"),
+		Qty:         dec("1"),
+		Side:        binance.Sell,
+		Symbol:      "BTCUSDT",
+		TimeInForce: binance.GoodUntilCancelled,
+		Type:        binance.OrderTypeLimit,
+	})
+	require.NoError(t, err)
+	requireBalance(t, p, "BTC", "0", "2")
+
+	// The stop order is triggered and rests on the book, which expires the
+	// limit order.
+	m.bids = []binance.PriceLevel{level("90", "5")}
+	m.klines = append(m.klines, clock.advance("89", "92"))
+
+	// Every order of the list is returned, together.
+	cancelled, err := p.CancelAllOpenOrders(ctx, "BTCUSDT")
+	require.NoError(t, err)
+	require.Len(t, cancelled, 3)
+	for i, entry := range list.Orders {
+		require.Equal(t, entry.OrderID, cancelled[i].OrderID)
+		require.Equal(t, list.OrderListID, cancelled[i].OrderListID)
+	}
+	require.Equal(t, binance.OrderStatusCancelled, cancelled[0].Status)
+	require.Equal(t, binance.OrderStatusExpired, cancelled[1].Status)
+	require.Equal(t, binance.OrderStatusCancelled, cancelled[2].Status)
+	require.Equal(t, int64(-1), cancelled[2].OrderListID)
+	requireBalance(t, p, "BTC", "2", "0")
+}
+
+func TestClient_UserDataStream(t *testing.T) {
+	p, _, _ := newTestClient()
+
+	_, err := p.StartUserDataStream(context.Background())
+	require.True(t, errors.Is(err, ErrUnsupported))
+}
@@ -0,0 +1,539 @@
+package binance
+
+import (
+	"context"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/luno/jettison/errors"
+	"github.com/luno/jettison/j"
+)
+
+// ErrRateLimitExceeded is returned when a request is not sent because it
+// would exceed one of the exchange's rate limits, or because the exchange has
+// asked for requests to back off.
+var ErrRateLimitExceeded = errors.New("rate limit exceeded",
+	j.C("ERR_RATE_LIMIT_EXCEEDED"))
+
+// DefaultRateLimits are the limits a RateLimiter enforces until it is given
+// the limits reported by ExchangeInfo.
+var DefaultRateLimits = []RateLimit{
+	{
+		Interval:      RateLimitIntervalMinute,
+		IntervalNum:   1,
+		Limit:         1200,
+		RateLimitType: RateLimitTypeRequestWeight,
+	},
+	{
+		Interval:      RateLimitIntervalSecond,
+		IntervalNum:   10,
+		Limit:         50,
+		RateLimitType: RateLimitTypeOrders,
+	},
+	{
+		Interval:      RateLimitIntervalDay,
+		IntervalNum:   1,
+		Limit:         160000,
+		RateLimitType: RateLimitTypeOrders,
+	},
+	{
+		Interval:      RateLimitIntervalMinute,
+		IntervalNum:   5,
+		Limit:         6100,
+		RateLimitType: RateLimitTypeRawRequests,
+	},
+}
+
+// Duration returns the length of the window a RateLimit is measured over.
+func (l RateLimit) Duration() time.Duration {
+	var unit time.Duration
+	switch l.Interval {
+	case RateLimitIntervalSecond:
+		unit = time.Second
+	case RateLimitIntervalMinute:
+		unit = time.Minute
+	case RateLimitIntervalDay:
+		unit = 24 * time.Hour
+	}
+	return time.Duration(l.IntervalNum) * unit
+}
+
+// RateLimitUsage describes how much of a RateLimit has been used in its
+// current window.
+type RateLimitUsage struct {
+	RateLimit
+
+	// ResetTime represents when the current window ends and usage returns to
+	// zero.
+	ResetTime time.Time
+
+	// Used represents how much of the limit has been used in the current
+	// window, as reported by the exchange or counted locally, whichever is
+	// greater.
+	Used int
+}
+
+// rateWindow counts usage of a RateLimit within a fixed window. Windows are
+// aligned to the clock, as they are on the exchange.
+type rateWindow struct {
+	limit RateLimit
+	start time.Time
+	used  int
+}
+
+func (w *rateWindow) end() time.Time {
+	return w.start.Add(w.limit.Duration())
+}
+
+// roll starts a new window if the current one has ended.
+func (w *rateWindow) roll(now time.Time) {
+	if now.Before(w.end()) {
+		return
+	}
+	w.start = now.Truncate(w.limit.Duration())
+	w.used = 0
+}
+
+// requestCost describes how much of each limit a request uses.
+type requestCost struct {
+	orders int
+	weight int
+}
+
+// of returns how much of `limitType` a request uses.
+func (c requestCost) of(limitType RateLimitType) int {
+	switch limitType {
+	case RateLimitTypeRequestWeight:
+		return c.weight
+	case RateLimitTypeOrders:
+		return c.orders
+	case RateLimitTypeRawRequests:
+		return 1
+	default:
+		return 0
+	}
+}
+
+// RateLimiter keeps a Client within the exchange's rate limits. Each request
+// is counted against every limit before it is sent, and the usage reported by
+// the exchange in response headers is tracked, so that usage by other clients
+// on the same IP or account is accounted for.
+//
+// Request weight and raw request limits apply per IP, while order limits
+// apply per account. Share a RateLimiter between Clients accordingly.
+type RateLimiter struct {
+	failFast bool
+	now      func() time.Time
+
+	mu         sync.Mutex
+	retryAfter time.Time
+	windows    []*rateWindow
+}
+
+// RateLimiterOption is a func-to-RateLimiter adapter.
+type RateLimiterOption func(*RateLimiter)
+
+// WithFailFast returns a RateLimiterOption which causes requests that would
+// exceed a limit to fail with ErrRateLimitExceeded immediately, rather than
+// blocking until the limit resets.
+func WithFailFast() RateLimiterOption {
+	return func(l *RateLimiter) {
+		l.failFast = true
+	}
+}
+
+// WithRateLimiterClock returns a RateLimiterOption to set the func used to
+// read the current time. This is useful for testing. Defaults to time.Now.
+func WithRateLimiterClock(now func() time.Time) RateLimiterOption {
+	return func(l *RateLimiter) {
+		l.now = now
+	}
+}
+
+// WithRateLimits returns a RateLimiterOption to set the limits enforced.
+// Defaults to DefaultRateLimits.
+func WithRateLimits(limits []RateLimit) RateLimiterOption {
+	return func(l *RateLimiter) {
+		l.windows = newRateWindows(limits)
+	}
+}
+
+// NewRateLimiter returns a RateLimiter which enforces DefaultRateLimits
+// unless configured otherwise.
+func NewRateLimiter(opts ...RateLimiterOption) *RateLimiter {
+	l := RateLimiter{
+		now:     time.Now,
+		windows: newRateWindows(DefaultRateLimits),
+	}
+
+	for _, o := range opts {
+		o(&l)
+	}
+
+	return &l
+}
+
+func newRateWindows(limits []RateLimit) []*rateWindow {
+	windows := make([]*rateWindow, 0, len(limits))
+	for _, limit := range limits {
+		if limit.Duration() <= 0 {
+			continue
+		}
+		windows = append(windows, &rateWindow{limit: limit})
+	}
+	return windows
+}
+
+// SetLimits replaces the limits enforced, typically with those reported by
+// ExchangeInfo. Usage is kept for limits which are unchanged.
+func (l *RateLimiter) SetLimits(limits []RateLimit) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	windows := newRateWindows(limits)
+	for _, w := range windows {
+		for _, old := range l.windows {
+			if old.limit == w.limit {
+				*w = *old
+			}
+		}
+	}
+	l.windows = windows
+}
+
+// Usage returns the current usage of each limit.
+func (l *RateLimiter) Usage() []RateLimitUsage {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	now := l.now()
+	usage := make([]RateLimitUsage, 0, len(l.windows))
+	for _, w := range l.windows {
+		w.roll(now)
+		usage = append(usage, RateLimitUsage{
+			RateLimit: w.limit,
+			ResetTime: w.end(),
+			Used:      w.used,
+		})
+	}
+	return usage
+}
+
+// wait blocks until a request with `cost` can be sent without exceeding any
+// limit, and then counts it. It fails with ErrRateLimitExceeded if the
+// limiter is set to fail fast, or if `ctx` would end before the request
+// could be sent.
+func (l *RateLimiter) wait(ctx context.Context, cost requestCost) error {
+	for {
+		until, err := l.reserve(cost)
+		if err != nil {
+			return err
+		}
+
+		if until.IsZero() {
+			return nil
+		}
+
+		if deadline, ok := ctx.Deadline(); ok && deadline.Before(until) {
+			return errors.Wrap(ErrRateLimitExceeded,
+				"context deadline is before rate limit resets",
+				j.KV("reset_time", until))
+		}
+
+		t := time.NewTimer(until.Sub(l.now()))
+		select {
+		case <-ctx.Done():
+			t.Stop()
+			return ctx.Err()
+		case <-t.C:
+		}
+	}
+}
+
+// reserve counts a request against every limit if none would be exceeded.
+// Otherwise it returns the time at which to try again.
+func (l *RateLimiter) reserve(cost requestCost) (time.Time, error) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	now := l.now()
+
+	var until time.Time
+	if now.Before(l.retryAfter) {
+		until = l.retryAfter
+	}
+
+	for _, w := range l.windows {
+		w.roll(now)
+
+		n := cost.of(w.limit.RateLimitType)
+		if n == 0 || w.used+n <= w.limit.Limit {
+			continue
+		}
+
+		if n > w.limit.Limit {
+			return time.Time{}, errors.Wrap(ErrRateLimitExceeded,
+				"request cost is larger than rate limit",
+				j.MKV{"cost": n, "limit": w.limit.Limit,
+					"type": w.limit.RateLimitType})
+		}
+
+		if w.end().After(until) {
+			until = w.end()
+		}
+	}
+
+	if until.IsZero() {
+		for _, w := range l.windows {
+			w.used += cost.of(w.limit.RateLimitType)
+		}
+		return time.Time{}, nil
+	}
+
+	if l.failFast {
+		return time.Time{}, errors.Wrap(ErrRateLimitExceeded,
+			"request would exceed rate limit", j.KV("reset_time", until))
+	}
+
+	return until, nil
+}
+
+// Header prefixes used by the exchange to report usage. The interval is
+// appended, e.g. X-MBX-USED-WEIGHT-1M.
+const (
+	headerUsedWeight = "X-Mbx-Used-Weight-"
+	headerOrderCount = "X-Mbx-Order-Count-"
+)
+
+// update records the usage reported in a response, and any instruction to
+// back off.
+func (l *RateLimiter) update(header http.Header, statusCode int) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	now := l.now()
+
+	for key, values := range header {
+		var limitType RateLimitType
+		switch {
+		case strings.HasPrefix(key, headerUsedWeight):
+			limitType = RateLimitTypeRequestWeight
+		case strings.HasPrefix(key, headerOrderCount):
+			limitType = RateLimitTypeOrders
+		default:
+			continue
+		}
+
+		used, err := strconv.Atoi(values[0])
+		if err != nil {
+			continue
+		}
+
+		// Header keys are canonicalized, so "1M" arrives as "1m".
+		interval := strings.ToUpper(key[strings.LastIndex(key, "-")+1:])
+		for _, w := range l.windows {
+			if w.limit.RateLimitType != limitType ||
+				!w.limit.matchesInterval(interval) {
+				continue
+			}
+
+			w.roll(now)
+			if used > w.used {
+				w.used = used
+			}
+		}
+	}
+
+	if statusCode != http.StatusTooManyRequests &&
+		statusCode != http.StatusTeapot {
+		return
+	}
+
+	if seconds, err := strconv.Atoi(header.Get("Retry-After")); err == nil {
+		retryAfter := now.Add(time.Duration(seconds) * time.Second)
+		if retryAfter.After(l.retryAfter) {
+			l.retryAfter = retryAfter
+		}
+	}
+}
+
+// matchesInterval returns whether the interval suffix of a usage header, such
+// as "1M" or "10S", refers to a RateLimit's window.
+func (l RateLimit) matchesInterval(interval string) bool {
+	if len(interval) < 2 {
+		return false
+	}
+
+	num, err := strconv.Atoi(interval[:len(interval)-1])
+	if err != nil || num != l.IntervalNum {
+		return false
+	}
+
+	return interval[len(interval)-1:] == string(l.Interval)[:1]
+}
+
+// getRequestCost returns the cost of a request. Requests to unknown endpoints
+// are assumed to have a weight of one.
+func getRequestCost(u *url.URL, method string, body []byte) requestCost {
+	cost, ok := requestCosts[u.Path][method]
+	if !ok {
+		return requestCost{weight: 1}
+	}
+
+	params := u.Query()
+	if len(body) > 0 {
+		bodyParams, _ := url.ParseQuery(string(body))
+		for k, v := range bodyParams {
+			params[k] = v
+		}
+	}
+
+	return requestCost{
+		orders: cost.orders,
+		weight: cost.weight(params),
+	}
+}
+
+// endpointCost describes the cost of an endpoint. The weight of some
+// endpoints depends on their parameters.
+type endpointCost struct {
+	orders int
+	weight func(params url.Values) int
+}
+
+func fixedWeight(weight int) func(url.Values) int {
+	return func(url.Values) int {
+		return weight
+	}
+}
+
+// symbolWeight returns a weight func for endpoints which are cheaper when
+// querying a single symbol.
+func symbolWeight(single, all int) func(url.Values) int {
+	return func(params url.Values) int {
+		if params.Get("symbol") != "" {
+			return single
+		}
+		return all
+	}
+}
+
+// depthWeight returns the weight of an order book request, which depends on
+// its limit.
+func depthWeight(params url.Values) int {
+	limit, _ := strconv.Atoi(params.Get("limit"))
+	switch {
+	case limit > 1000:
+		return 50
+	case limit > 500:
+		return 10
+	case limit > 100:
+		return 5
+	default:
+		return 1
+	}
+}
+
+var requestCosts = map[string]map[string]endpointCost{
+	"/api/v3/account": {
+		http.MethodGet: {weight: fixedWeight(10)},
+	},
+
+	"/api/v3/aggTrades": {
+		http.MethodGet: {weight: fixedWeight(1)},
+	},
+
+	"/api/v3/allOrderList": {
+		http.MethodGet: {weight: fixedWeight(10)},
+	},
+
+	"/api/v3/allOrders": {
+		http.MethodGet: {weight: fixedWeight(10)},
+	},
+
+	"/api/v3/avgPrice": {
+		http.MethodGet: {weight: fixedWeight(1)},
+	},
+
+	"/api/v3/depth": {
+		http.MethodGet: {weight: depthWeight},
+	},
+
+	"/api/v3/exchangeInfo": {
+		http.MethodGet: {weight: fixedWeight(10)},
+	},
+
+	"/api/v3/historicalTrades": {
+		http.MethodGet: {weight: fixedWeight(5)},
+	},
+
+	"/api/v3/klines": {
+		http.MethodGet: {weight: fixedWeight(1)},
+	},
+
+	"/api/v3/myTrades": {
+		http.MethodGet: {weight: fixedWeight(10)},
+	},
+
+	"/api/v3/openOrderList": {
+		http.MethodGet: {weight: fixedWeight(3)},
+	},
+
+	"/api/v3/openOrders": {
+		http.MethodDelete: {weight: fixedWeight(1)},
+		http.MethodGet:    {weight: symbolWeight(3, 40)},
+	},
+
+	"/api/v3/order": {
+		http.MethodDelete: {weight: fixedWeight(1)},
+		http.MethodGet:    {weight: fixedWeight(2)},
+		http.MethodPost:   {orders: 1, weight: fixedWeight(1)},
+	},
+
+	"/api/v3/order/oco": {
+		http.MethodPost: {orders: 2, weight: fixedWeight(1)},
+	},
+
+	"/api/v3/order/test": {
+		http.MethodPost: {weight: fixedWeight(1)},
+	},
+
+	"/api/v3/orderList": {
+		http.MethodDelete: {weight: fixedWeight(1)},
+		http.MethodGet:    {weight: fixedWeight(2)},
+	},
+
+	"/api/v3/ping": {
+		http.MethodGet: {weight: fixedWeight(1)},
+	},
+
+	"/api/v3/ticker/24hr": {
+		http.MethodGet: {weight: symbolWeight(1, 40)},
+	},
+
+	"/api/v3/ticker/bookTicker": {
+		http.MethodGet: {weight: symbolWeight(1, 2)},
+	},
+
+	"/api/v3/ticker/price": {
+		http.MethodGet: {weight: symbolWeight(1, 2)},
+	},
+
+	"/api/v3/time": {
+		http.MethodGet: {weight: fixedWeight(1)},
+	},
+
+	"/api/v3/trades": {
+		http.MethodGet: {weight: fixedWeight(1)},
+	},
+
+	"/api/v3/userDataStream": {
+		http.MethodDelete: {weight: fixedWeight(1)},
+		http.MethodPost:   {weight: fixedWeight(1)},
+		http.MethodPut:    {weight: fixedWeight(1)},
+	},
+}
@@ -0,0 +1,184 @@
+package binance
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/luno/jettison/errors"
+	"github.com/stretchr/testify/require"
+)
+
+func TestGetRequestCost(t *testing.T) {
+	tests := []struct {
+		method   string
+		path     string
+		body     string
+		expected requestCost
+	}{
+		{http.MethodGet, "/api/v3/depth?symbol=BTCUSDT", "",
+			requestCost{weight: 1}},
+		{http.MethodGet, "/api/v3/depth?symbol=BTCUSDT&limit=500", "",
+			requestCost{weight: 5}},
+		{http.MethodGet, "/api/v3/depth?symbol=BTCUSDT&limit=1000", "",
+			requestCost{weight: 10}},
+		{http.MethodGet, "/api/v3/depth?symbol=BTCUSDT&limit=5000", "",
+			requestCost{weight: 50}},
+		{http.MethodGet, "/api/v3/openOrders?symbol=BTCUSDT", "",
+			requestCost{weight: 3}},
+		{http.MethodGet, "/api/v3/openOrders", "", requestCost{weight: 40}},
+		{http.MethodPost, "/api/v3/order", "symbol=BTCUSDT",
+			requestCost{orders: 1, weight: 1}},
+		{http.MethodPost, "/api/v3/order/test", "symbol=BTCUSDT",
+			requestCost{weight: 1}},
+		{http.MethodPost, "/api/v3/order/oco", "symbol=BTCUSDT",
+			requestCost{orders: 2, weight: 1}},
+		{http.MethodGet, "/api/v3/unknown", "", requestCost{weight: 1}},
+	}
+
+	for i, test := range tests {
+		t.Run(fmt.Sprintf("%d", i), func(t *testing.T) {
+			u, err := url.ParseRequestURI(test.path)
+			require.NoError(t, err)
+			require.Equal(t, test.expected, getRequestCost(u, test.method,
+				[]byte(test.body)))
+		})
+	}
+}
+
+func TestRateLimiter_FailFast(t *testing.T) {
+	clock := &fakeClock{now: time.Unix(1600000000, 0)}
+	l := NewRateLimiter(WithFailFast(), WithRateLimiterClock(clock.Now),
+		WithRateLimits([]RateLimit{
+			{
+				Interval:      RateLimitIntervalMinute,
+				IntervalNum:   1,
+				Limit:         10,
+				RateLimitType: RateLimitTypeRequestWeight,
+			},
+			{
+				Interval:      RateLimitIntervalSecond,
+				IntervalNum:   10,
+				Limit:         1,
+				RateLimitType: RateLimitTypeOrders,
+			},
+		}))
+	ctx := context.Background()
+
+	require.NoError(t, l.wait(ctx, requestCost{weight: 6}))
+	require.NoError(t, l.wait(ctx, requestCost{weight: 4}))
+
+	err := l.wait(ctx, requestCost{weight: 1})
+	require.True(t, errors.Is(err, ErrRateLimitExceeded))
+
+	// A request larger than the limit can never be sent.
+	err = l.wait(ctx, requestCost{weight: 11})
+	require.True(t, errors.Is(err, ErrRateLimitExceeded))
+
+	clock.Advance(time.Minute)
+	require.NoError(t, l.wait(ctx, requestCost{orders: 1, weight: 1}))
+
+	err = l.wait(ctx, requestCost{orders: 1, weight: 1})
+	require.True(t, errors.Is(err, ErrRateLimitExceeded))
+
+	usage := l.Usage()
+	require.Len(t, usage, 2)
+	require.Equal(t, 1, usage[0].Used)
+	require.Equal(t, 1, usage[1].Used)
+	require.Equal(t, clock.Now().Truncate(10*time.Second).Add(
+		10*time.Second), usage[1].ResetTime)
+}
+
+func TestRateLimiter_Update(t *testing.T) {
+	clock := &fakeClock{now: time.Unix(1600000000, 0)}
+	l := NewRateLimiter(WithFailFast(), WithRateLimiterClock(clock.Now))
+
+	header := make(http.Header)
+	header.Set("X-MBX-USED-WEIGHT-1M", "1150")
+	header.Set("X-MBX-ORDER-COUNT-10S", "7")
+	header.Set("X-MBX-ORDER-COUNT-1D", "90")
+	l.update(header, http.StatusOK)
+
+	used := make(map[RateLimit]int)
+	for _, u := range l.Usage() {
+		used[u.RateLimit] = u.Used
+	}
+	require.Equal(t, 1150, used[DefaultRateLimits[0]])
+	require.Equal(t, 7, used[DefaultRateLimits[1]])
+	require.Equal(t, 90, used[DefaultRateLimits[2]])
+	require.Equal(t, 0, used[DefaultRateLimits[3]])
+
+	// The exchange asking us to back off blocks all requests.
+	header = make(http.Header)
+	header.Set("Retry-After", "30")
+	l.update(header, http.StatusTeapot)
+
+	err := l.wait(context.Background(), requestCost{weight: 1})
+	require.True(t, errors.Is(err, ErrRateLimitExceeded))
+
+	clock.Advance(time.Minute)
+	require.NoError(t, l.wait(context.Background(), requestCost{weight: 1}))
+}
+
+func TestRateLimiter_Deadline(t *testing.T) {
+	l := NewRateLimiter(WithRateLimits([]RateLimit{
+		{
+			Interval:      RateLimitIntervalDay,
+			IntervalNum:   1,
+			Limit:         1,
+			RateLimitType: RateLimitTypeRawRequests,
+		},
+	}))
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+
+	require.NoError(t, l.wait(ctx, requestCost{}))
+
+	// We would have to wait until tomorrow, so don't wait at all.
+	err := l.wait(ctx, requestCost{})
+	require.True(t, errors.Is(err, ErrRateLimitExceeded))
+	require.NoError(t, ctx.Err())
+}
+
+func TestClient_RateLimiter(t *testing.T) {
+	var calls int32
+	srv := httptest.NewServer(http.HandlerFunc(
+		func(w http.ResponseWriter, r *http.Request) {
+			n := atomic.AddInt32(&calls, 1)
+			w.Header().Set("X-MBX-USED-WEIGHT-1M", fmt.Sprintf("%d", n*2))
+			w.Write([]byte("[]"))
+		}))
+	defer srv.Close()
+
+	clock := &fakeClock{now: time.Unix(1600000000, 0)}
+	l := NewRateLimiter(WithFailFast(), WithRateLimiterClock(clock.Now),
+		WithRateLimits([]RateLimit{
+			{
+				Interval:      RateLimitIntervalMinute,
+				IntervalNum:   1,
+				Limit:         4,
+				RateLimitType: RateLimitTypeRequestWeight,
+			},
+		}))
+	c := NewClient(WithBaseURL(srv.URL+"/api/v3"), WithRateLimiter(l))
+	ctx := context.Background()
+
+	// Another client on the same IP is also using weight, which the
+	// exchange reports.
+	req := &KlinesRequest{Symbol: "BTCUSDT", Interval: OneMinute}
+	for i := 0; i < 2; i++ {
+		_, err := c.Klines(ctx, req)
+		require.NoError(t, err)
+	}
+
+	_, err := c.Klines(ctx, req)
+	require.True(t, errors.Is(err, ErrRateLimitExceeded))
+	require.Equal(t, int32(2), atomic.LoadInt32(&calls))
+	require.Equal(t, 4, l.Usage()[0].Used)
+}
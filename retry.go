@@ -0,0 +1,113 @@
+package binance
+
+import (
+	"context"
+	"math/rand"
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+)
+
+// RetryPolicy describes which failed requests a Client retries, and how long
+// it waits between attempts.
+//
+// Only GET requests are retried, since they don't change any state. Requests
+// which place or cancel orders are never resent, as a failure doesn't mean
+// that the exchange didn't act on them.
+type RetryPolicy struct {
+	// MaxAttempts represents the maximum number of times a request is sent,
+	// including the first. Values below two disable retries.
+	MaxAttempts int
+
+	// MinWait represents how long to wait before the first retry. The wait
+	// doubles with each retry, and is jittered by up to half.
+	MinWait time.Duration
+
+	// MaxWait represents the longest wait between attempts. Requests are not
+	// retried if the exchange asks for a longer wait using Retry-After.
+	MaxWait time.Duration
+}
+
+// DefaultRetryPolicy is a RetryPolicy suitable for most uses.
+var DefaultRetryPolicy = RetryPolicy{
+	MaxAttempts: 4,
+	MinWait:     100 * time.Millisecond,
+	MaxWait:     10 * time.Second,
+}
+
+var (
+	jitterMu sync.Mutex
+	jitter   = rand.New(rand.NewSource(time.Now().UnixNano()))
+)
+
+// backoff returns the jittered wait before retrying after `attempt`.
+func (p RetryPolicy) backoff(attempt int) time.Duration {
+	wait := p.MinWait
+	for i := 1; i < attempt && wait < p.MaxWait; i++ {
+		wait *= 2
+	}
+
+	if wait > p.MaxWait {
+		wait = p.MaxWait
+	}
+
+	if wait <= 1 {
+		return wait
+	}
+
+	jitterMu.Lock()
+	defer jitterMu.Unlock()
+	return wait/2 + time.Duration(jitter.Int63n(int64(wait/2)))
+}
+
+// retryWait returns how long to wait before retrying a request which failed
+// with `err` on its `attempt`th try, and whether it should be retried at all.
+// `res` is nil if no response was received.
+func (p RetryPolicy) retryWait(ctx context.Context, method string,
+	attempt int, res *http.Response, err error) (time.Duration, bool) {
+	if attempt >= p.MaxAttempts || method != http.MethodGet ||
+		ctx.Err() != nil {
+		return 0, false
+	}
+
+	wait := p.backoff(attempt)
+
+	switch {
+	case res == nil:
+		// Only retry if the request failed in transport, rather than
+		// before it was sent.
+		if !failedInTransport(err) {
+			return 0, false
+		}
+
+	case res.StatusCode == http.StatusTooManyRequests,
+		res.StatusCode == http.StatusTeapot:
+		retryAfter, err := strconv.Atoi(res.Header.Get("Retry-After"))
+		if err != nil {
+			break
+		}
+
+		d := time.Duration(retryAfter) * time.Second
+		if d > p.MaxWait {
+			return 0, false
+		}
+		if d > wait {
+			wait = d
+		}
+
+	case res.StatusCode >= http.StatusInternalServerError,
+		IsError(err, ErrDisconnected, ErrServiceShuttingDown):
+		// The exchange failed to handle the request, so try again.
+
+	default:
+		return 0, false
+	}
+
+	if deadline, ok := ctx.Deadline(); ok &&
+		deadline.Before(time.Now().Add(wait)) {
+		return 0, false
+	}
+
+	return wait, true
+}
@@ -0,0 +1,210 @@
+package binance
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+type testResponse struct {
+	status     int
+	body       string
+	retryAfter string
+}
+
+// sequenceServer responds to each request with the next response in a
+// sequence, repeating the last once the sequence is exhausted.
+func sequenceServer(responses ...testResponse) (*httptest.Server, *int32) {
+	var calls int32
+	h := func(w http.ResponseWriter, r *http.Request) {
+		n := int(atomic.AddInt32(&calls, 1))
+		if n > len(responses) {
+			n = len(responses)
+		}
+
+		res := responses[n-1]
+		if res.retryAfter != "" {
+			w.Header().Set("Retry-After", res.retryAfter)
+		}
+		w.WriteHeader(res.status)
+		w.Write([]byte(res.body))
+	}
+
+	return httptest.NewServer(http.HandlerFunc(h)), &calls
+}
+
+var (
+	okResponse = testResponse{status: http.StatusOK, body: "{}"}
+
+	serverError = testResponse{status: http.StatusBadGateway,
+		body: "<html>Bad Gateway</html>"}
+)
+
+func TestRetryPolicy(t *testing.T) {
+	policy := RetryPolicy{
+		MaxAttempts: 3,
+		MinWait:     time.Millisecond,
+		MaxWait:     2 * time.Second,
+	}
+
+	tests := []struct {
+		name      string
+		call      func(ctx context.Context, c Client) error
+		timeout   time.Duration
+		responses []testResponse
+		calls     int32
+		success   bool
+	}{
+		{
+			name:      "server error then success",
+			call:      ping,
+			responses: []testResponse{serverError, okResponse},
+			calls:     2,
+			success:   true,
+		},
+		{
+			name: "disconnected then success",
+			call: ping,
+			responses: []testResponse{
+				{status: http.StatusBadRequest,
+					body: `{"code":-1001,"msg":"Internal error."}`},
+				okResponse,
+			},
+			calls:   2,
+			success: true,
+		},
+		{
+			name: "service shutting down then success",
+			call: ping,
+			responses: []testResponse{
+				{status: http.StatusBadRequest,
+					body: `{"code":-1016,"msg":"Shutting down."}`},
+				okResponse,
+			},
+			calls:   2,
+			success: true,
+		},
+		{
+			name: "too many requests honours retry after",
+			call: ping,
+			responses: []testResponse{
+				{status: http.StatusTooManyRequests, retryAfter: "1"},
+				okResponse,
+			},
+			calls:   2,
+			success: true,
+		},
+		{
+			name: "retry after longer than max wait",
+			call: ping,
+			responses: []testResponse{
+				{status: http.StatusTeapot, retryAfter: "60"},
+				okResponse,
+			},
+			calls: 1,
+		},
+		{
+			name: "retry after longer than deadline",
+			call: ping,
+			responses: []testResponse{
+				{status: http.StatusTooManyRequests, retryAfter: "1"},
+				okResponse,
+			},
+			timeout: 500 * time.Millisecond,
+			calls:   1,
+		},
+		{
+			name:      "attempts exhausted",
+			call:      ping,
+			responses: []testResponse{serverError},
+			calls:     3,
+		},
+		{
+			name: "client error",
+			call: ping,
+			responses: []testResponse{
+				{status: http.StatusBadRequest,
+					body: `{"code":-1121,"msg":"Invalid symbol."}`},
+				okResponse,
+			},
+			calls: 1,
+		},
+		{
+			name: "new order is never resent",
+			call: func(ctx context.Context, c Client) error {
+				_, err := c.NewOrder(ctx, &NewOrderRequest{Symbol: "BTCUSDT"})
+				return err
+			},
+			responses: []testResponse{
+				{status: http.StatusBadRequest,
+					body: `{"code":-1001,"msg":"Internal error."}`},
+				okResponse,
+			},
+			calls: 1,
+		},
+		{
+			name: "cancel order is never resent",
+			call: func(ctx context.Context, c Client) error {
+				_, err := c.CancelOrder(ctx, &CancelOrderRequest{
+					Symbol: "BTCUSDT",
+				})
+				return err
+			},
+			responses: []testResponse{serverError, okResponse},
+			calls:     1,
+		},
+	}
+
+	for i, test := range tests {
+		t.Run(fmt.Sprintf("%d", i), func(t *testing.T) {
+			srv, calls := sequenceServer(test.responses...)
+			defer srv.Close()
+
+			ctx := context.Background()
+			if test.timeout > 0 {
+				var cancel context.CancelFunc
+				ctx, cancel = context.WithTimeout(ctx, test.timeout)
+				defer cancel()
+			}
+
+			c := NewClient(WithBaseURL(srv.URL), WithRetryPolicy(policy))
+			err := test.call(ctx, c)
+			if test.success {
+				require.NoError(t, err, test.name)
+			} else {
+				require.Error(t, err, test.name)
+			}
+			require.Equal(t, test.calls, atomic.LoadInt32(calls), test.name)
+		})
+	}
+}
+
+func TestRetryPolicy_Disabled(t *testing.T) {
+	srv, calls := sequenceServer(serverError, okResponse)
+	defer srv.Close()
+
+	c := NewClient(WithBaseURL(srv.URL))
+	require.Error(t, c.Ping(context.Background()))
+	require.Equal(t, int32(1), atomic.LoadInt32(calls))
+}
+
+func TestRetryPolicy_Backoff(t *testing.T) {
+	p := RetryPolicy{MinWait: time.Second, MaxWait: 5 * time.Second}
+
+	for attempt, max := range []time.Duration{time.Second, 2 * time.Second,
+		4 * time.Second, 5 * time.Second, 5 * time.Second} {
+		wait := p.backoff(attempt + 1)
+		require.True(t, wait >= max/2 && wait <= max,
+			"attempt %d waited %s", attempt+1, wait)
+	}
+}
+
+func ping(ctx context.Context, c Client) error {
+	return c.Ping(ctx)
+}
@@ -1,6 +1,7 @@
 package binance
 
 import (
+	"fmt"
 	"net/http"
 	"net/url"
 )
@@ -33,6 +34,24 @@ func (level SecurityLevel) Valid() bool {
 	return level >= SecurityLevelNone && level < securityLevelSentinel
 }
 
+// String returns the name the API documentation uses for a SecurityLevel.
+func (level SecurityLevel) String() string {
+	switch level {
+	case SecurityLevelNone:
+		return "NONE"
+	case SecurityLevelUserStream:
+		return "USER_STREAM"
+	case SecurityLevelMarketData:
+		return "MARKET_DATA"
+	case SecurityLevelTrade:
+		return "TRADE"
+	case SecurityLevelUserData:
+		return "USER_DATA"
+	default:
+		return fmt.Sprintf("SecurityLevel(%d)", int(level))
+	}
+}
+
 // RequiresAuth returns whether a SecurityLevel requires a request to have an
 // authentication header present.
 func (level SecurityLevel) RequiresAuth() bool {
@@ -75,7 +94,8 @@ var securityGroups = map[string]map[string]SecurityLevel{
 	},
 
 	"/api/v3/openOrders": {
-		http.MethodGet: SecurityLevelUserData,
+		http.MethodDelete: SecurityLevelTrade,
+		http.MethodGet:    SecurityLevelUserData,
 	},
 
 	"/api/v3/order": {
@@ -94,6 +114,13 @@ var securityGroups = map[string]map[string]SecurityLevel{
 	},
 
 	"/api/v3/orderList": {
-		http.MethodGet: SecurityLevelUserData,
+		http.MethodDelete: SecurityLevelTrade,
+		http.MethodGet:    SecurityLevelUserData,
+	},
+
+	"/api/v3/userDataStream": {
+		http.MethodDelete: SecurityLevelUserStream,
+		http.MethodPost:   SecurityLevelUserStream,
+		http.MethodPut:    SecurityLevelUserStream,
 	},
 }
@@ -44,6 +44,15 @@ func TestSecurityLevels(t *testing.T) {
 			method:        http.MethodPost,
 			securityLevel: SecurityLevelTrade,
 		},
+		{
+			url: &url.URL{
+				Scheme: "https",
+				Host:   "api.binance.com",
+				Path:   "/api/v3/openOrders",
+			},
+			method:        http.MethodDelete,
+			securityLevel: SecurityLevelTrade,
+		},
 	}
 
 	for i, test := range tests {
@@ -53,3 +62,8 @@ func TestSecurityLevels(t *testing.T) {
 		})
 	}
 }
+
+func TestSecurityLevel_String(t *testing.T) {
+	require.Equal(t, "USER_DATA", SecurityLevelUserData.String())
+	require.Equal(t, "SecurityLevel(9)", SecurityLevel(9).String())
+}
@@ -0,0 +1,141 @@
+package binance
+
+import (
+	"context"
+	"crypto"
+	"crypto/ed25519"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/pem"
+
+	"github.com/luno/jettison/errors"
+)
+
+// Signer signs requests which require a signature. The payload is the
+// request's query string followed by its body, and the returned signature is
+// sent as the `signature` parameter.
+//
+// Implementations must be safe for concurrent use. They needn't hold the
+// private key in memory, and may instead call out to a key custody service.
+type Signer interface {
+	Sign(ctx context.Context, payload []byte) (string, error)
+}
+
+type hmacSigner struct {
+	secretKey []byte
+}
+
+// NewHMACSigner returns a Signer for HMAC API keys, which signs payloads
+// with HMAC-SHA256 using `secretKey`. This is the Signer used by default,
+// with the key set by WithSecretKey.
+func NewHMACSigner(secretKey string) Signer {
+	return &hmacSigner{secretKey: []byte(secretKey)}
+}
+
+// Sign satisfies the Signer interface.
+func (s *hmacSigner) Sign(_ context.Context, payload []byte) (string,
+	error) {
+	mac := hmac.New(sha256.New, s.secretKey)
+	mac.Write(payload)
+	return hex.EncodeToString(mac.Sum(nil)), nil
+}
+
+type rsaSigner struct {
+	key *rsa.PrivateKey
+}
+
+// NewRSASigner returns a Signer for RSA API keys, which signs payloads with
+// RSASSA-PKCS1-v1_5 over SHA-256.
+func NewRSASigner(key *rsa.PrivateKey) Signer {
+	return &rsaSigner{key: key}
+}
+
+// ParseRSASigner returns a Signer for the PEM encoded RSA private key in
+// `pemBytes`, in either PKCS #1 or PKCS #8 form.
+func ParseRSASigner(pemBytes []byte) (Signer, error) {
+	der, err := decodePEM(pemBytes)
+	if err != nil {
+		return nil, err
+	}
+
+	if key, err := x509.ParsePKCS1PrivateKey(der); err == nil {
+		return NewRSASigner(key), nil
+	}
+
+	key, err := x509.ParsePKCS8PrivateKey(der)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to parse private key")
+	}
+
+	rsaKey, ok := key.(*rsa.PrivateKey)
+	if !ok {
+		return nil, errors.New("private key is not an RSA key")
+	}
+
+	return NewRSASigner(rsaKey), nil
+}
+
+// Sign satisfies the Signer interface.
+func (s *rsaSigner) Sign(_ context.Context, payload []byte) (string,
+	error) {
+	digest := sha256.Sum256(payload)
+	sig, err := rsa.SignPKCS1v15(rand.Reader, s.key, crypto.SHA256,
+		digest[:])
+	if err != nil {
+		return "", errors.Wrap(err, "failed to sign payload")
+	}
+
+	return base64.StdEncoding.EncodeToString(sig), nil
+}
+
+type ed25519Signer struct {
+	key ed25519.PrivateKey
+}
+
+// NewEd25519Signer returns a Signer for Ed25519 API keys.
+func NewEd25519Signer(key ed25519.PrivateKey) Signer {
+	return &ed25519Signer{key: key}
+}
+
+// ParseEd25519Signer returns a Signer for the PEM encoded PKCS #8 Ed25519
+// private key in `pemBytes`.
+func ParseEd25519Signer(pemBytes []byte) (Signer, error) {
+	der, err := decodePEM(pemBytes)
+	if err != nil {
+		return nil, err
+	}
+
+	key, err := x509.ParsePKCS8PrivateKey(der)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to parse private key")
+	}
+
+	edKey, ok := key.(ed25519.PrivateKey)
+	if !ok {
+		return nil, errors.New("private key is not an Ed25519 key")
+	}
+
+	return NewEd25519Signer(edKey), nil
+}
+
+// Sign satisfies the Signer interface.
+func (s *ed25519Signer) Sign(_ context.Context, payload []byte) (string,
+	error) {
+	return base64.StdEncoding.EncodeToString(ed25519.Sign(s.key, payload)),
+		nil
+}
+
+// decodePEM returns the DER bytes of the first PEM block in `pemBytes`.
+func decodePEM(pemBytes []byte) ([]byte, error) {
+	block, _ := pem.Decode(pemBytes)
+	if block == nil {
+		return nil, errors.New("no PEM block found")
+	}
+
+	return block.Bytes, nil
+}
@@ -0,0 +1,122 @@
+package binance
+
+import (
+	"context"
+	"crypto"
+	"crypto/ed25519"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/pem"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+// The example from the API documentation.
+const signerPayload = "symbol=LTCBTC&side=BUY&type=LIMIT&timeInForce=GTC&" +
+	"quantity=1&price=0.1&recvWindow=5000&timestamp=1499827319559"
+
+func TestHMACSigner(t *testing.T) {
+	s := NewHMACSigner(
+		"NhqPtmdSJYdKjVHjA7PZj4Mge3R5YNiP1e3UZjInClVN65XAbvqqM6A7H5fATj0j")
+	sig, err := s.Sign(context.Background(), []byte(signerPayload))
+	require.NoError(t, err)
+	require.Equal(t,
+		"c8db56825ae71d6d79447849e617115f4a920fa2acdcab2b053c4b2838bd6b71",
+		sig)
+}
+
+func TestRSASigner(t *testing.T) {
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	require.NoError(t, err)
+
+	pkcs8, err := x509.MarshalPKCS8PrivateKey(key)
+	require.NoError(t, err)
+
+	pems := [][]byte{
+		pem.EncodeToMemory(&pem.Block{Type: "RSA PRIVATE KEY",
+			Bytes: x509.MarshalPKCS1PrivateKey(key)}),
+		pem.EncodeToMemory(&pem.Block{Type: "PRIVATE KEY", Bytes: pkcs8}),
+	}
+
+	digest := sha256.Sum256([]byte(signerPayload))
+	for _, p := range pems {
+		s, err := ParseRSASigner(p)
+		require.NoError(t, err)
+
+		sig, err := s.Sign(context.Background(), []byte(signerPayload))
+		require.NoError(t, err)
+
+		b, err := base64.StdEncoding.DecodeString(sig)
+		require.NoError(t, err)
+		require.NoError(t, rsa.VerifyPKCS1v15(&key.PublicKey, crypto.SHA256,
+			digest[:], b))
+	}
+}
+
+func TestEd25519Signer(t *testing.T) {
+	pub, key, err := ed25519.GenerateKey(rand.Reader)
+	require.NoError(t, err)
+
+	pkcs8, err := x509.MarshalPKCS8PrivateKey(key)
+	require.NoError(t, err)
+
+	s, err := ParseEd25519Signer(pem.EncodeToMemory(&pem.Block{
+		Type: "PRIVATE KEY", Bytes: pkcs8}))
+	require.NoError(t, err)
+
+	sig, err := s.Sign(context.Background(), []byte(signerPayload))
+	require.NoError(t, err)
+
+	b, err := base64.StdEncoding.DecodeString(sig)
+	require.NoError(t, err)
+	require.True(t, ed25519.Verify(pub, []byte(signerPayload), b))
+
+	// An RSA key isn't accepted.
+	rsaKey, err := rsa.GenerateKey(rand.Reader, 1024)
+	require.NoError(t, err)
+	pkcs8, err = x509.MarshalPKCS8PrivateKey(rsaKey)
+	require.NoError(t, err)
+	_, err = ParseEd25519Signer(pem.EncodeToMemory(&pem.Block{
+		Type: "PRIVATE KEY", Bytes: pkcs8}))
+	require.Error(t, err)
+
+	_, err = ParseEd25519Signer([]byte("not a key"))
+	require.Error(t, err)
+}
+
+type signerFunc func(ctx context.Context, payload []byte) (string, error)
+
+func (f signerFunc) Sign(ctx context.Context, payload []byte) (string,
+	error) {
+	return f(ctx, payload)
+}
+
+func TestWithSigner(t *testing.T) {
+	var signature string
+	srv := httptest.NewServer(http.HandlerFunc(
+		func(w http.ResponseWriter, r *http.Request) {
+			signature = r.URL.Query().Get("signature")
+			w.Write([]byte(`{}`))
+		}))
+	defer srv.Close()
+
+	var payload []byte
+	c := NewClient(WithBaseURL(srv.URL+"/api/v3"),
+		WithSecretKey("ignored"),
+		WithSigner(signerFunc(func(_ context.Context, p []byte) (string,
+			error) {
+			payload = p
+			return "a+b/c=", nil
+		})))
+
+	_, err := c.AccountInfo(context.Background())
+	require.NoError(t, err)
+	require.Contains(t, string(payload), "timestamp=")
+	require.Equal(t, "a+b/c=", signature)
+}
@@ -0,0 +1,93 @@
+package stream
+
+import (
+	"context"
+	"time"
+
+	"github.com/gorilla/websocket"
+	"github.com/luno/jettison/errors"
+	"github.com/luno/jettison/j"
+)
+
+// connect dials `url` and calls `onMessage` with every message received until
+// the connection drops, `onMessage` returns an error or `ctx` ends. The
+// `onConnect` func, if not nil, is called once the connection is open.
+func connect(ctx context.Context, o *options, url string,
+	onConnect func(*websocket.Conn) error, onMessage func([]byte) error) error {
+	conn, res, err := o.dialer.DialContext(ctx, url, nil)
+	if err != nil {
+		if res != nil {
+			return errors.Wrap(err, "failed to dial stream",
+				j.KV("status_code", res.StatusCode))
+		}
+		return errors.Wrap(err, "failed to dial stream")
+	}
+	defer conn.Close()
+
+	// Unblock ReadMessage when the context ends.
+	done := make(chan struct{})
+	defer close(done)
+	go func() {
+		select {
+		case <-ctx.Done():
+			conn.Close()
+		case <-done:
+		}
+	}()
+
+	if onConnect != nil {
+		if err := onConnect(conn); err != nil {
+			return err
+		}
+	}
+
+	for {
+		_, msg, err := conn.ReadMessage()
+		if err != nil {
+			if ctx.Err() != nil {
+				return ctx.Err()
+			}
+			return errors.Wrap(err, "failed to read from stream")
+		}
+
+		if err := onMessage(msg); err != nil {
+			return err
+		}
+	}
+}
+
+// backoff tracks how long to wait between reconnection attempts.
+type backoff struct {
+	max  time.Duration
+	min  time.Duration
+	next time.Duration
+}
+
+func newBackoff(o *options) *backoff {
+	return &backoff{max: o.maxReconnectWait, min: o.reconnectWait,
+		next: o.reconnectWait}
+}
+
+// wait blocks until the next attempt is due or `ctx` ends, and returns
+// whether the attempt should go ahead.
+func (b *backoff) wait(ctx context.Context) bool {
+	t := time.NewTimer(b.next)
+	defer t.Stop()
+
+	b.next *= 2
+	if b.next > b.max {
+		b.next = b.max
+	}
+
+	select {
+	case <-ctx.Done():
+		return false
+	case <-t.C:
+		return true
+	}
+}
+
+// reset sets the wait back to its minimum after a successful connection.
+func (b *backoff) reset() {
+	b.next = b.min
+}
@@ -0,0 +1,6 @@
+// Package stream provides WebSocket consumers for Binance's user data and
+// market data streams.
+//
+// Connections are re-established automatically when they drop, which Binance
+// does to every connection after 24 hours.
+package stream
@@ -0,0 +1,34 @@
+package stream
+
+// EventType identifies the kind of message received on a stream.
+type EventType string
+
+// Enumerated types for EventType.
+const (
+	EventTypeExecutionReport  EventType = "executionReport"
+	EventTypeAccountPosition  EventType = "outboundAccountPosition"
+	EventTypeBalanceUpdate    EventType = "balanceUpdate"
+	EventTypeListStatus       EventType = "listStatus"
+	EventTypeListenKeyExpired EventType = "listenKeyExpired"
+)
+
+// Event is a message received on a stream. Use a type switch to get at the
+// concrete event.
+type Event interface {
+	EventType() EventType
+}
+
+// EventHeader contains the fields common to every event.
+type EventHeader struct {
+	// Time represents the unix timestamp in milliseconds at which the event
+	// was generated.
+	Time int64 `json:"E"`
+
+	// Type represents the kind of event.
+	Type EventType `json:"e"`
+}
+
+// EventType satisfies the Event interface.
+func (h EventHeader) EventType() EventType {
+	return h.Type
+}
@@ -0,0 +1,612 @@
+package stream
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/gorilla/websocket"
+	"github.com/luno/jettison/errors"
+	"github.com/luno/jettison/j"
+	"github.com/nickcorin/binance"
+)
+
+// Enumerated types for EventType on market streams. Book ticker and partial
+// depth messages don't carry an event type, so one is assigned to them.
+const (
+	EventTypeTrade        EventType = "trade"
+	EventTypeAggTrade     EventType = "aggTrade"
+	EventTypeKline        EventType = "kline"
+	EventTypeMiniTicker   EventType = "24hrMiniTicker"
+	EventTypeTicker       EventType = "24hrTicker"
+	EventTypeBookTicker   EventType = "bookTicker"
+	EventTypePartialDepth EventType = "partialDepth"
+	EventTypeDepthUpdate  EventType = "depthUpdate"
+)
+
+// TradeStream returns the name of the stream of raw trades on a market.
+func TradeStream(symbol string) string {
+	return fmt.Sprintf("%s@trade", strings.ToLower(symbol))
+}
+
+// AggTradeStream returns the name of the stream of aggregate trades on a
+// market.
+func AggTradeStream(symbol string) string {
+	return fmt.Sprintf("%s@aggTrade", strings.ToLower(symbol))
+}
+
+// KlineStream returns the name of the stream of kline updates on a market.
+func KlineStream(symbol string, interval binance.KlineInterval) string {
+	return fmt.Sprintf("%s@kline_%s", strings.ToLower(symbol), interval)
+}
+
+// MiniTickerStream returns the name of the stream of rolling 24 hour mini
+// ticker statistics on a market.
+func MiniTickerStream(symbol string) string {
+	return fmt.Sprintf("%s@miniTicker", strings.ToLower(symbol))
+}
+
+// TickerStream returns the name of the stream of rolling 24 hour ticker
+// statistics on a market.
+func TickerStream(symbol string) string {
+	return fmt.Sprintf("%s@ticker", strings.ToLower(symbol))
+}
+
+// BookTickerStream returns the name of the stream of updates to the best bid
+// and ask on a market.
+func BookTickerStream(symbol string) string {
+	return fmt.Sprintf("%s@bookTicker", strings.ToLower(symbol))
+}
+
+// PartialDepthStream returns the name of the stream of the top `levels` bids
+// and asks on a market, sent every second. Valid levels are 5, 10 and 20.
+func PartialDepthStream(symbol string, levels int) string {
+	return fmt.Sprintf("%s@depth%d", strings.ToLower(symbol), levels)
+}
+
+// DiffDepthStream returns the name of the stream of order book changes on a
+// market, sent every second, or every 100ms if `fast` is true.
+func DiffDepthStream(symbol string, fast bool) string {
+	if fast {
+		return fmt.Sprintf("%s@depth@100ms", strings.ToLower(symbol))
+	}
+	return fmt.Sprintf("%s@depth", strings.ToLower(symbol))
+}
+
+// TradeEvent is sent for every trade on a market.
+type TradeEvent struct {
+	EventHeader
+
+	BuyerOrderID  int64           `json:"b"`
+	IsBuyerMaker  bool            `json:"m"`
+	Price         binance.Decimal `json:"p"`
+	Qty           binance.Decimal `json:"q"`
+	SellerOrderID int64           `json:"a"`
+	Symbol        string          `json:"s"`
+	TradeID       int64           `json:"t"`
+	TradeTime     int64           `json:"T"`
+
+	// IgnoreM is undocumented. It is declared so that its key isn't decoded
+	// into IsBuyerMaker.
+	IgnoreM bool `json:"M"`
+}
+
+// AggTradeEvent is sent for every aggregate trade on a market.
+type AggTradeEvent struct {
+	EventHeader
+
+	AggTradeID   int64           `json:"a"`
+	FirstTradeID int64           `json:"f"`
+	IsBuyerMaker bool            `json:"m"`
+	LastTradeID  int64           `json:"l"`
+	Price        binance.Decimal `json:"p"`
+	Qty          binance.Decimal `json:"q"`
+	Symbol       string          `json:"s"`
+	TradeTime    int64           `json:"T"`
+
+	// IgnoreM is undocumented. It is declared so that its key isn't decoded
+	// into IsBuyerMaker.
+	IgnoreM bool `json:"M"`
+}
+
+// KlineEvent is sent every second with the current state of a kline.
+type KlineEvent struct {
+	EventHeader
+
+	Kline  StreamKline `json:"k"`
+	Symbol string      `json:"s"`
+}
+
+// StreamKline contains kline / candlestick data sent on a kline stream.
+type StreamKline struct {
+	Close                    binance.Decimal       `json:"c"`
+	CloseTime                int64                 `json:"T"`
+	FirstTradeID             int64                 `json:"f"`
+	High                     binance.Decimal       `json:"h"`
+	Interval                 binance.KlineInterval `json:"i"`
+	IsClosed                 bool                  `json:"x"`
+	LastTradeID              int64                 `json:"L"`
+	Low                      binance.Decimal       `json:"l"`
+	Open                     binance.Decimal       `json:"o"`
+	OpenTime                 int64                 `json:"t"`
+	QuoteVolume              binance.Decimal       `json:"q"`
+	Symbol                   string                `json:"s"`
+	TakerBuyBaseAssetVolume  binance.Decimal       `json:"V"`
+	TakerBuyQuoteAssetVolume binance.Decimal       `json:"Q"`
+	TradeCount               int64                 `json:"n"`
+	Volume                   binance.Decimal       `json:"v"`
+}
+
+// MiniTickerEvent is sent every second with rolling 24 hour statistics of a
+// market.
+type MiniTickerEvent struct {
+	EventHeader
+
+	Close       binance.Decimal `json:"c"`
+	High        binance.Decimal `json:"h"`
+	Low         binance.Decimal `json:"l"`
+	Open        binance.Decimal `json:"o"`
+	QuoteVolume binance.Decimal `json:"q"`
+	Symbol      string          `json:"s"`
+	Volume      binance.Decimal `json:"v"`
+}
+
+// TickerEvent is sent every second with rolling 24 hour statistics of a
+// market.
+type TickerEvent struct {
+	EventHeader
+
+	AskPrice           binance.Decimal `json:"a"`
+	AskQty             binance.Decimal `json:"A"`
+	BidPrice           binance.Decimal `json:"b"`
+	BidQty             binance.Decimal `json:"B"`
+	CloseTime          int64           `json:"C"`
+	Count              int64           `json:"n"`
+	FirstID            int64           `json:"F"`
+	FirstTradePrice    binance.Decimal `json:"x"`
+	HighPrice          binance.Decimal `json:"h"`
+	LastID             int64           `json:"L"`
+	LastPrice          binance.Decimal `json:"c"`
+	LastQty            binance.Decimal `json:"Q"`
+	LowPrice           binance.Decimal `json:"l"`
+	OpenPrice          binance.Decimal `json:"o"`
+	OpenTime           int64           `json:"O"`
+	PriceChange        binance.Decimal `json:"p"`
+	PriceChangePercent binance.Decimal `json:"P"`
+	QuoteVolume        binance.Decimal `json:"q"`
+	Symbol             string          `json:"s"`
+	Volume             binance.Decimal `json:"v"`
+	WeightedAvgPrice   binance.Decimal `json:"w"`
+}
+
+// BookTickerEvent is sent whenever the best bid or ask of a market changes.
+type BookTickerEvent struct {
+	AskPrice binance.Decimal `json:"a"`
+	AskQty   binance.Decimal `json:"A"`
+	BidPrice binance.Decimal `json:"b"`
+	BidQty   binance.Decimal `json:"B"`
+	Symbol   string          `json:"s"`
+	UpdateID int64           `json:"u"`
+}
+
+// EventType satisfies the Event interface.
+func (*BookTickerEvent) EventType() EventType {
+	return EventTypeBookTicker
+}
+
+// PartialDepthEvent contains the top bids and asks of a market.
+type PartialDepthEvent struct {
+	Asks         []binance.PriceLevel `json:"asks"`
+	Bids         []binance.PriceLevel `json:"bids"`
+	LastUpdateID int64                `json:"lastUpdateId"`
+
+	// Symbol is not sent by the exchange, and is taken from the stream name.
+	Symbol string `json:"-"`
+}
+
+// EventType satisfies the Event interface.
+func (*PartialDepthEvent) EventType() EventType {
+	return EventTypePartialDepth
+}
+
+// DepthUpdateEvent contains the changes to an order book between two update
+// IDs. A Qty of zero means the price level should be removed.
+type DepthUpdateEvent struct {
+	EventHeader
+
+	Asks          []binance.PriceLevel `json:"a"`
+	Bids          []binance.PriceLevel `json:"b"`
+	FinalUpdateID int64                `json:"u"`
+	FirstUpdateID int64                `json:"U"`
+	Symbol        string               `json:"s"`
+}
+
+// decodeMarketEvent decodes a message from a market stream into its concrete
+// Event type based on the name of the stream. Unknown streams are returned as
+// nil.
+func decodeMarketEvent(stream string, data []byte) (Event, error) {
+	index := strings.Index(stream, "@")
+	if index == -1 {
+		return nil, nil
+	}
+	symbol, kind := stream[:index], stream[index+1:]
+
+	var event Event
+	switch {
+	case kind == "trade":
+		event = new(TradeEvent)
+	case kind == "aggTrade":
+		event = new(AggTradeEvent)
+	case strings.HasPrefix(kind, "kline_"):
+		event = new(KlineEvent)
+	case kind == "miniTicker":
+		event = new(MiniTickerEvent)
+	case kind == "ticker":
+		event = new(TickerEvent)
+	case kind == "bookTicker":
+		event = new(BookTickerEvent)
+	case kind == "depth" || strings.HasPrefix(kind, "depth@"):
+		event = new(DepthUpdateEvent)
+	case strings.HasPrefix(kind, "depth"):
+		event = &PartialDepthEvent{Symbol: strings.ToUpper(symbol)}
+	default:
+		return nil, nil
+	}
+
+	if err := json.Unmarshal(data, event); err != nil {
+		return nil, errors.Wrap(err, "failed to parse event",
+			j.KV("stream", stream))
+	}
+
+	return event, nil
+}
+
+// ControlError is returned when the exchange rejects a control message.
+type ControlError struct {
+	Code    int    `json:"code"`
+	Message string `json:"msg"`
+}
+
+// Error satisfies the error interface.
+func (e ControlError) Error() string {
+	return e.Message
+}
+
+// controlRequest is sent to change or list the subscriptions of a
+// connection.
+type controlRequest struct {
+	ID     int64    `json:"id"`
+	Method string   `json:"method"`
+	Params []string `json:"params,omitempty"`
+}
+
+// marketMessage is received on a combined stream connection. It is either an
+// event, with Stream and Data set, or a response to a control request.
+type marketMessage struct {
+	Data   json.RawMessage `json:"data"`
+	Error  *ControlError   `json:"error"`
+	ID     *int64          `json:"id"`
+	Result json.RawMessage `json:"result"`
+	Stream string          `json:"stream"`
+}
+
+// MarketStream consumes any number of market streams over a single
+// connection. Subscriptions can be changed while it runs, and are restored
+// when the connection is re-established.
+type MarketStream struct {
+	opts options
+
+	// onConnect, if not nil, is called each time a connection is opened.
+	onConnect func()
+
+	mu      sync.Mutex
+	conn    *websocket.Conn
+	nextID  int64
+	pending map[int64]chan marketMessage
+	streams map[string]bool
+}
+
+// NewMarketStream returns a MarketStream subscribed to `streams`. Use the
+// *Stream funcs, such as TradeStream, to build stream names.
+func NewMarketStream(streams []string, opts ...Option) *MarketStream {
+	s := MarketStream{
+		opts:    newOptions(opts),
+		pending: make(map[int64]chan marketMessage),
+		streams: make(map[string]bool),
+	}
+
+	for _, stream := range streams {
+		s.streams[stream] = true
+	}
+
+	return &s
+}
+
+// Run connects to the subscribed streams and calls `handler` with each event
+// received, until `ctx` ends. Dropped connections are re-established.
+//
+// The handler is called from a single goroutine and should not block.
+func (s *MarketStream) Run(ctx context.Context, handler func(Event)) error {
+	b := newBackoff(&s.opts)
+	for {
+		err := s.consume(ctx, b, handler)
+		if ctx.Err() != nil {
+			return ctx.Err()
+		}
+		s.opts.errorHandler(err)
+
+		if !b.wait(ctx) {
+			return ctx.Err()
+		}
+	}
+}
+
+func (s *MarketStream) consume(ctx context.Context, b *backoff,
+	handler func(Event)) error {
+	url := fmt.Sprintf("%s/stream", s.opts.baseURL)
+	streams := s.Streams()
+	if len(streams) > 0 {
+		url = fmt.Sprintf("%s?streams=%s", url, strings.Join(streams, "/"))
+	}
+
+	// resyncIDs are the IDs of the control requests sent on connecting, whose
+	// errors are reported to the error handler.
+	resyncIDs := make(map[int64]bool)
+
+	onConnect := func(conn *websocket.Conn) error {
+		b.reset()
+
+		s.mu.Lock()
+		s.conn = conn
+
+		// Subscriptions changed while connecting weren't sent, so bring the
+		// connection up to date with them.
+		subscribe, unsubscribe := s.diffStreams(streams)
+		for _, r := range []controlRequest{
+			{Method: "SUBSCRIBE", Params: subscribe},
+			{Method: "UNSUBSCRIBE", Params: unsubscribe},
+		} {
+			if len(r.Params) == 0 {
+				continue
+			}
+
+			id, _, err := s.request(r.Method, r.Params)
+			if err != nil {
+				s.mu.Unlock()
+				return err
+			}
+			delete(s.pending, id)
+			resyncIDs[id] = true
+		}
+		s.mu.Unlock()
+
+		if s.onConnect != nil {
+			s.onConnect()
+		}
+		return nil
+	}
+
+	defer func() {
+		s.mu.Lock()
+		s.conn = nil
+		for id, ch := range s.pending {
+			close(ch)
+			delete(s.pending, id)
+		}
+		s.mu.Unlock()
+	}()
+
+	return connect(ctx, &s.opts, url, onConnect, func(msg []byte) error {
+		var m marketMessage
+		if err := json.Unmarshal(msg, &m); err != nil {
+			s.opts.errorHandler(errors.Wrap(err, "failed to parse message"))
+			return nil
+		}
+
+		if m.ID != nil && resyncIDs[*m.ID] {
+			delete(resyncIDs, *m.ID)
+			if m.Error != nil {
+				s.opts.errorHandler(errors.Wrap(*m.Error,
+					"failed to update subscriptions"))
+			}
+			return nil
+		}
+
+		if m.ID != nil {
+			s.mu.Lock()
+			ch, ok := s.pending[*m.ID]
+			delete(s.pending, *m.ID)
+			s.mu.Unlock()
+
+			if ok {
+				ch <- m
+			}
+			return nil
+		}
+
+		event, err := decodeMarketEvent(m.Stream, m.Data)
+		if err != nil {
+			s.opts.errorHandler(err)
+			return nil
+		}
+
+		if event != nil {
+			handler(event)
+		}
+		return nil
+	})
+}
+
+// diffStreams returns the streams which are subscribed to but not in
+// `streams`, and those which are in `streams` but not subscribed to. It must
+// be called with the lock held.
+func (s *MarketStream) diffStreams(streams []string) (added,
+	removed []string) {
+	had := make(map[string]bool)
+	for _, stream := range streams {
+		had[stream] = true
+		if !s.streams[stream] {
+			removed = append(removed, stream)
+		}
+	}
+
+	for stream := range s.streams {
+		if !had[stream] {
+			added = append(added, stream)
+		}
+	}
+	sort.Strings(added)
+
+	return added, removed
+}
+
+// Streams returns the names of the streams that will be subscribed to on the
+// next connection, in lexical order.
+func (s *MarketStream) Streams() []string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	streams := make([]string, 0, len(s.streams))
+	for stream := range s.streams {
+		streams = append(streams, stream)
+	}
+	sort.Strings(streams)
+	return streams
+}
+
+// Subscribe adds streams to the connection. If the stream is not connected,
+// the streams are subscribed to when it next connects.
+func (s *MarketStream) Subscribe(ctx context.Context, streams ...string) error {
+	var added []string
+	_, err := s.control(ctx, "SUBSCRIBE", streams, func() {
+		for _, stream := range streams {
+			if !s.streams[stream] {
+				s.streams[stream] = true
+				added = append(added, stream)
+			}
+		}
+	})
+	if err != nil {
+		s.mu.Lock()
+		for _, stream := range added {
+			delete(s.streams, stream)
+		}
+		s.mu.Unlock()
+		return err
+	}
+
+	return nil
+}
+
+// Unsubscribe removes streams from the connection. If the stream is not
+// connected, the streams are left out when it next connects.
+func (s *MarketStream) Unsubscribe(ctx context.Context,
+	streams ...string) error {
+	var removed []string
+	_, err := s.control(ctx, "UNSUBSCRIBE", streams, func() {
+		for _, stream := range streams {
+			if s.streams[stream] {
+				delete(s.streams, stream)
+				removed = append(removed, stream)
+			}
+		}
+	})
+	if err != nil {
+		s.mu.Lock()
+		for _, stream := range removed {
+			s.streams[stream] = true
+		}
+		s.mu.Unlock()
+		return err
+	}
+
+	return nil
+}
+
+// ListSubscriptions asks the exchange which streams the connection is
+// subscribed to. It fails if the stream is not connected.
+func (s *MarketStream) ListSubscriptions(ctx context.Context) ([]string,
+	error) {
+	res, err := s.control(ctx, "LIST_SUBSCRIPTIONS", nil, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	if res == nil {
+		return nil, errors.New("not connected")
+	}
+
+	var streams []string
+	if err := json.Unmarshal(res.Result, &streams); err != nil {
+		return nil, errors.Wrap(err, "failed to parse subscriptions")
+	}
+
+	return streams, nil
+}
+
+// control sends a control request and waits for its response. The `update`
+// func, if not nil, is called with the lock held before the request is sent,
+// so that it can't race with a connection being opened. It returns a nil
+// response without error if the stream is not connected.
+func (s *MarketStream) control(ctx context.Context, method string,
+	params []string, update func()) (*marketMessage, error) {
+	s.mu.Lock()
+	if update != nil {
+		update()
+	}
+
+	if s.conn == nil {
+		s.mu.Unlock()
+		return nil, nil
+	}
+
+	id, ch, err := s.request(method, params)
+	s.mu.Unlock()
+
+	if err != nil {
+		return nil, err
+	}
+
+	select {
+	case <-ctx.Done():
+		s.mu.Lock()
+		delete(s.pending, id)
+		s.mu.Unlock()
+		return nil, ctx.Err()
+
+	case res, ok := <-ch:
+		if !ok {
+			return nil, errors.New("connection closed before response",
+				j.KV("method", method))
+		}
+
+		if res.Error != nil {
+			return nil, *res.Error
+		}
+
+		return &res, nil
+	}
+}
+
+// request sends a control request, and returns its ID and the channel its
+// response is delivered on. The channel is closed if the connection drops
+// first. It must be called with the lock held while connected.
+func (s *MarketStream) request(method string, params []string) (int64,
+	chan marketMessage, error) {
+	s.nextID++
+	req := controlRequest{ID: s.nextID, Method: method, Params: params}
+	ch := make(chan marketMessage, 1)
+	s.pending[req.ID] = ch
+
+	// Writes must not be concurrent, so they happen under the lock.
+	if err := s.conn.WriteJSON(req); err != nil {
+		delete(s.pending, req.ID)
+		return 0, nil, errors.Wrap(err, "failed to send control message",
+			j.KV("method", method))
+	}
+
+	return req.ID, ch, nil
+}
@@ -0,0 +1,326 @@
+package stream
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/gorilla/websocket"
+	"github.com/nickcorin/binance"
+	"github.com/stretchr/testify/require"
+)
+
+func TestStreamNames(t *testing.T) {
+	require.Equal(t, "btcusdt@trade", TradeStream("BTCUSDT"))
+	require.Equal(t, "btcusdt@aggTrade", AggTradeStream("BTCUSDT"))
+	require.Equal(t, "btcusdt@kline_15m", KlineStream("BTCUSDT",
+		binance.FifteenMinutes))
+	require.Equal(t, "btcusdt@miniTicker", MiniTickerStream("BTCUSDT"))
+	require.Equal(t, "btcusdt@ticker", TickerStream("BTCUSDT"))
+	require.Equal(t, "btcusdt@bookTicker", BookTickerStream("BTCUSDT"))
+	require.Equal(t, "btcusdt@depth10", PartialDepthStream("BTCUSDT", 10))
+	require.Equal(t, "btcusdt@depth", DiffDepthStream("BTCUSDT", false))
+	require.Equal(t, "btcusdt@depth@100ms", DiffDepthStream("BTCUSDT", true))
+}
+
+func TestDecodeMarketEvent(t *testing.T) {
+	tests := []struct {
+		stream string
+		data   string
+		event  Event
+	}{
+		{
+			stream: "bnbbtc@trade",
+			data: `{"e":"trade","E":123456789,"s":"BNBBTC","t":12345,
+				"p":"0.001","q":"100","b":88,"a":50,"T":123456785,"m":true,
+				"M":true}`,
+			event: &TradeEvent{
+				EventHeader:   EventHeader{Time: 123456789, Type: EventTypeTrade},
+				BuyerOrderID:  88,
+				IsBuyerMaker:  true,
+				Price:         binance.MustParseDecimal("0.001"),
+				Qty:           binance.MustParseDecimal("100"),
+				SellerOrderID: 50,
+				Symbol:        "BNBBTC",
+				TradeID:       12345,
+				TradeTime:     123456785,
+				IgnoreM:       true,
+			},
+		},
+		{
+			stream: "bnbbtc@aggTrade",
+			data: `{"e":"aggTrade","E":123456789,"s":"BNBBTC","a":12345,
+				"p":"0.001","q":"100","f":100,"l":105,"T":123456785,"m":false,
+				"M":true}`,
+			event: &AggTradeEvent{
+				EventHeader:  EventHeader{Time: 123456789, Type: EventTypeAggTrade},
+				AggTradeID:   12345,
+				FirstTradeID: 100,
+				LastTradeID:  105,
+				Price:        binance.MustParseDecimal("0.001"),
+				Qty:          binance.MustParseDecimal("100"),
+				Symbol:       "BNBBTC",
+				TradeTime:    123456785,
+				IgnoreM:      true,
+			},
+		},
+		{
+			stream: "bnbbtc@kline_1m",
+			data: `{"e":"kline","E":123456789,"s":"BNBBTC","k":{"t":123400000,
+				"T":123460000,"s":"BNBBTC","i":"1m","f":100,"L":200,
+				"o":"0.0010","c":"0.0020","h":"0.0025","l":"0.0015",
+				"v":"1000","n":100,"x":false,"q":"1.0000","V":"500",
+				"Q":"0.500","B":"123456"}}`,
+			event: &KlineEvent{
+				EventHeader: EventHeader{Time: 123456789, Type: EventTypeKline},
+				Kline: StreamKline{
+					Close:                    binance.MustParseDecimal("0.0020"),
+					CloseTime:                123460000,
+					FirstTradeID:             100,
+					High:                     binance.MustParseDecimal("0.0025"),
+					Interval:                 binance.OneMinute,
+					LastTradeID:              200,
+					Low:                      binance.MustParseDecimal("0.0015"),
+					Open:                     binance.MustParseDecimal("0.0010"),
+					OpenTime:                 123400000,
+					QuoteVolume:              binance.MustParseDecimal("1.0000"),
+					Symbol:                   "BNBBTC",
+					TakerBuyBaseAssetVolume:  binance.MustParseDecimal("500"),
+					TakerBuyQuoteAssetVolume: binance.MustParseDecimal("0.500"),
+					TradeCount:               100,
+					Volume:                   binance.MustParseDecimal("1000"),
+				},
+				Symbol: "BNBBTC",
+			},
+		},
+		{
+			stream: "bnbusdt@bookTicker",
+			data: `{"u":400900217,"s":"BNBUSDT","b":"25.35190000",
+				"B":"31.21000000","a":"25.36520000","A":"40.66000000"}`,
+			event: &BookTickerEvent{
+				AskPrice: binance.MustParseDecimal("25.36520000"),
+				AskQty:   binance.MustParseDecimal("40.66000000"),
+				BidPrice: binance.MustParseDecimal("25.35190000"),
+				BidQty:   binance.MustParseDecimal("31.21000000"),
+				Symbol:   "BNBUSDT",
+				UpdateID: 400900217,
+			},
+		},
+		{
+			stream: "bnbbtc@depth5@100ms",
+			data: `{"lastUpdateId":160,"bids":[["0.0024","10"]],
+				"asks":[["0.0026","100"]]}`,
+			event: &PartialDepthEvent{
+				Asks:         []binance.PriceLevel{{Price: binance.MustParseDecimal("0.0026"), Qty: binance.MustParseDecimal("100")}},
+				Bids:         []binance.PriceLevel{{Price: binance.MustParseDecimal("0.0024"), Qty: binance.MustParseDecimal("10")}},
+				LastUpdateID: 160,
+				Symbol:       "BNBBTC",
+			},
+		},
+		{
+			stream: "bnbbtc@depth@100ms",
+			data: `{"e":"depthUpdate","E":123456789,"s":"BNBBTC","U":157,
+				"u":160,"b":[["0.0024","10"]],"a":[["0.0026","0"]]}`,
+			event: &DepthUpdateEvent{
+				EventHeader:   EventHeader{Time: 123456789, Type: EventTypeDepthUpdate},
+				Asks:          []binance.PriceLevel{{Price: binance.MustParseDecimal("0.0026"), Qty: binance.MustParseDecimal("0")}},
+				Bids:          []binance.PriceLevel{{Price: binance.MustParseDecimal("0.0024"), Qty: binance.MustParseDecimal("10")}},
+				FinalUpdateID: 160,
+				FirstUpdateID: 157,
+				Symbol:        "BNBBTC",
+			},
+		},
+		{
+			stream: "!unknown@arr",
+			data:   `[]`,
+			event:  nil,
+		},
+	}
+
+	for i, test := range tests {
+		t.Run(fmt.Sprintf("%d", i), func(t *testing.T) {
+			event, err := decodeMarketEvent(test.stream, []byte(test.data))
+			require.NoError(t, err)
+			require.Equal(t, test.event, event)
+		})
+	}
+}
+
+// marketServer is a combined stream endpoint which sends a trade event for
+// every subscribed stream, and answers control requests. It calls `onDial`,
+// if set, before accepting a connection.
+type marketServer struct {
+	onDial   func()
+	t        *testing.T
+	upgrader websocket.Upgrader
+
+	mu      sync.Mutex
+	streams map[string]bool
+}
+
+func (s *marketServer) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if s.onDial != nil {
+		s.onDial()
+	}
+
+	conn, err := s.upgrader.Upgrade(w, r, nil)
+	require.NoError(s.t, err)
+	defer conn.Close()
+
+	s.mu.Lock()
+	s.streams = make(map[string]bool)
+	for _, stream := range strings.Split(r.URL.Query().Get("streams"), "/") {
+		if stream != "" {
+			s.streams[stream] = true
+		}
+	}
+	s.mu.Unlock()
+
+	s.sendTrades(conn)
+
+	for {
+		var req controlRequest
+		if err := conn.ReadJSON(&req); err != nil {
+			return
+		}
+
+		s.mu.Lock()
+		res := map[string]interface{}{"id": req.ID, "result": nil}
+		switch req.Method {
+		case "SUBSCRIBE":
+			for _, p := range req.Params {
+				s.streams[p] = true
+			}
+		case "UNSUBSCRIBE":
+			for _, p := range req.Params {
+				delete(s.streams, p)
+			}
+		case "LIST_SUBSCRIPTIONS":
+			var list []string
+			for stream := range s.streams {
+				list = append(list, stream)
+			}
+			res["result"] = list
+		default:
+			res = map[string]interface{}{"id": req.ID,
+				"error": map[string]interface{}{"code": 1, "msg": "bad method"}}
+		}
+		s.mu.Unlock()
+
+		require.NoError(s.t, conn.WriteJSON(res))
+		s.sendTrades(conn)
+	}
+}
+
+func (s *marketServer) sendTrades(conn *websocket.Conn) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for stream := range s.streams {
+		symbol := strings.ToUpper(strings.Split(stream, "@")[0])
+		data, _ := json.Marshal(map[string]interface{}{
+			"e": "trade", "E": 1, "s": symbol,
+		})
+		require.NoError(s.t, conn.WriteJSON(map[string]interface{}{
+			"stream": stream,
+			"data":   json.RawMessage(data),
+		}))
+	}
+}
+
+func TestMarketStream_Run(t *testing.T) {
+	srv := httptest.NewServer(&marketServer{t: t})
+	defer srv.Close()
+
+	s := NewMarketStream([]string{TradeStream("BTCUSDT")},
+		WithBaseURL("ws"+strings.TrimPrefix(srv.URL, "http")))
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	events := make(chan Event, 10)
+	errs := make(chan error, 1)
+	go func() {
+		errs <- s.Run(ctx, func(e Event) { events <- e })
+	}()
+
+	next := func() *TradeEvent {
+		select {
+		case e := <-events:
+			return e.(*TradeEvent)
+		case <-time.After(5 * time.Second):
+			t.Fatal("timed out waiting for event")
+			return nil
+		}
+	}
+
+	require.Equal(t, "BTCUSDT", next().Symbol)
+
+	require.NoError(t, s.Subscribe(ctx, TradeStream("ETHBTC")))
+	symbols := []string{next().Symbol, next().Symbol}
+	require.ElementsMatch(t, []string{"BTCUSDT", "ETHBTC"}, symbols)
+
+	require.NoError(t, s.Unsubscribe(ctx, TradeStream("BTCUSDT")))
+	require.Equal(t, "ETHBTC", next().Symbol)
+
+	list, err := s.ListSubscriptions(ctx)
+	require.NoError(t, err)
+	require.Equal(t, []string{"ethbtc@trade"}, list)
+	require.Equal(t, []string{"ethbtc@trade"}, s.Streams())
+
+	cancel()
+	require.Equal(t, context.Canceled, <-errs)
+}
+
+func TestMarketStream_SubscribeWhileConnecting(t *testing.T) {
+	ms := &marketServer{t: t}
+	srv := httptest.NewServer(ms)
+	defer srv.Close()
+
+	s := NewMarketStream([]string{TradeStream("BTCUSDT")},
+		WithBaseURL("ws"+strings.TrimPrefix(srv.URL, "http")))
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	// The subscriptions change after the URL is built, but before the
+	// connection is open.
+	dialErrs := make(chan error, 2)
+	ms.onDial = func() {
+		dialErrs <- s.Subscribe(ctx, TradeStream("ETHBTC"))
+		dialErrs <- s.Unsubscribe(ctx, TradeStream("BTCUSDT"))
+	}
+
+	errs := make(chan error, 1)
+	go func() {
+		errs <- s.Run(ctx, func(Event) {})
+	}()
+
+	require.NoError(t, <-dialErrs)
+	require.NoError(t, <-dialErrs)
+
+	require.Eventually(t, func() bool {
+		list, err := s.ListSubscriptions(ctx)
+		return err == nil && len(list) == 1 && list[0] == "ethbtc@trade"
+	}, 5*time.Second, time.Millisecond)
+
+	cancel()
+	require.Equal(t, context.Canceled, <-errs)
+}
+
+func TestMarketStream_NotConnected(t *testing.T) {
+	s := NewMarketStream(nil)
+	ctx := context.Background()
+
+	require.NoError(t, s.Subscribe(ctx, TradeStream("BTCUSDT")))
+	require.Equal(t, []string{"btcusdt@trade"}, s.Streams())
+
+	_, err := s.ListSubscriptions(ctx)
+	require.Error(t, err)
+}
@@ -0,0 +1,110 @@
+package stream
+
+import (
+	"time"
+
+	"github.com/gorilla/websocket"
+	"github.com/nickcorin/binance"
+)
+
+var defaultOptions = options{
+	baseURL:           binance.Production.StreamURL,
+	dialer:            websocket.DefaultDialer,
+	errorHandler:      func(error) {},
+	keepAliveInterval: binance.DefaultKeepAliveInterval,
+	maxReconnectWait:  30 * time.Second,
+	reconnectWait:     time.Second,
+	snapshotLimit:     1000,
+}
+
+type options struct {
+	baseURL           string
+	dialer            *websocket.Dialer
+	errorHandler      func(error)
+	keepAliveInterval time.Duration
+	maxReconnectWait  time.Duration
+	reconnectWait     time.Duration
+	snapshotLimit     int
+}
+
+// Option is a func-to-options adapter.
+type Option func(*options)
+
+func newOptions(opts []Option) options {
+	o := defaultOptions
+	for _, opt := range opts {
+		opt(&o)
+	}
+	return o
+}
+
+// WithBaseURL returns an Option to set the WebSocket URL that stream paths are
+// appended to. This is useful for testing.
+func WithBaseURL(url string) Option {
+	return func(o *options) {
+		o.baseURL = url
+	}
+}
+
+// WithDialer returns an Option to set the dialer used to open connections.
+// Defaults to websocket.DefaultDialer.
+func WithDialer(dialer *websocket.Dialer) Option {
+	return func(o *options) {
+		o.dialer = dialer
+	}
+}
+
+// WithEnvironment returns an Option to connect to the streams of `env`. Pass
+// the same Environment to binance.WithEnvironment so that the REST client
+// used for snapshots and listen keys matches. Defaults to binance.Production.
+func WithEnvironment(env binance.Environment) Option {
+	return func(o *options) {
+		o.baseURL = env.StreamURL
+	}
+}
+
+// WithErrorHandler returns an Option to set a func which is called with
+// errors that the stream recovers from, such as dropped connections. Errors
+// are discarded by default.
+func WithErrorHandler(handler func(error)) Option {
+	return func(o *options) {
+		o.errorHandler = handler
+	}
+}
+
+// WithReconnectWait returns an Option to set how long to wait before
+// reconnecting after a connection drops. The wait doubles after each failed
+// attempt, up to `max`. Non-positive durations are ignored, and `max` is
+// raised to `wait` if it is shorter. Defaults to one second, up to 30
+// seconds.
+func WithReconnectWait(wait, max time.Duration) Option {
+	return func(o *options) {
+		if wait > 0 {
+			o.reconnectWait = wait
+		}
+		if max > 0 {
+			o.maxReconnectWait = max
+		}
+		if o.maxReconnectWait < o.reconnectWait {
+			o.maxReconnectWait = o.reconnectWait
+		}
+	}
+}
+
+// WithKeepAliveInterval returns an Option to set how often a user data
+// stream's listen key is renewed. Defaults to
+// binance.DefaultKeepAliveInterval.
+func WithKeepAliveInterval(d time.Duration) Option {
+	return func(o *options) {
+		o.keepAliveInterval = d
+	}
+}
+
+// WithSnapshotLimit returns an Option to set the number of price levels a
+// LocalOrderBook fetches in its REST snapshot. Levels beyond the snapshot are
+// only known once an update touches them. Defaults to 1000.
+func WithSnapshotLimit(limit int) Option {
+	return func(o *options) {
+		o.snapshotLimit = limit
+	}
+}
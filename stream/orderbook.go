@@ -0,0 +1,362 @@
+package stream
+
+import (
+	"context"
+	"sort"
+	"sync"
+
+	"github.com/luno/jettison/errors"
+	"github.com/luno/jettison/j"
+	"github.com/nickcorin/binance"
+)
+
+// errSequenceGap is returned when a depth update doesn't follow on from the
+// last update applied to a LocalOrderBook, and the book must be resynced.
+var errSequenceGap = errors.New("gap in depth update sequence",
+	j.C("ERR_SEQUENCE_GAP"))
+
+// LocalOrderBook maintains a full order book for a market by applying the
+// diff depth stream to a REST snapshot, following the exchange's documented
+// procedure. It resyncs automatically whenever an update is missed.
+//
+// All read methods are safe to call from multiple goroutines while Run is
+// applying updates, and return empty results while the book is not synced.
+type LocalOrderBook struct {
+	client binance.Client
+	opts   options
+	symbol string
+
+	mu           sync.RWMutex
+	applied      bool
+	asks         bookSide
+	bids         bookSide
+	lastUpdateID int64
+	synced       bool
+}
+
+// NewLocalOrderBook returns a LocalOrderBook for `symbol` which uses `c` to
+// fetch snapshots.
+func NewLocalOrderBook(c binance.Client, symbol string,
+	opts ...Option) *LocalOrderBook {
+	return &LocalOrderBook{
+		client: c,
+		opts:   newOptions(opts),
+		symbol: symbol,
+		asks:   bookSide{descending: false},
+		bids:   bookSide{descending: true},
+	}
+}
+
+// Run keeps the order book up to date until `ctx` ends.
+func (b *LocalOrderBook) Run(ctx context.Context) error {
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	// Updates are buffered while the snapshot is fetched.
+	updates := make(chan *DepthUpdateEvent, 1000)
+
+	connected := make(chan struct{}, 1)
+	s := NewMarketStream([]string{DiffDepthStream(b.symbol, true)})
+	s.opts = b.opts
+	s.onConnect = func() {
+		select {
+		case connected <- struct{}{}:
+		default:
+		}
+	}
+
+	streamErr := make(chan error, 1)
+	go func() {
+		streamErr <- s.Run(ctx, func(e Event) {
+			u, ok := e.(*DepthUpdateEvent)
+			if !ok {
+				return
+			}
+
+			select {
+			case updates <- u:
+			case <-ctx.Done():
+			}
+		})
+	}()
+
+	// Wait for the stream to connect so that updates are buffered before the
+	// first snapshot is taken. Updates missed while reconnecting later leave
+	// a gap in the sequence, which causes a resync.
+	select {
+	case <-ctx.Done():
+		<-streamErr
+		return ctx.Err()
+	case <-connected:
+	}
+
+	bo := newBackoff(&b.opts)
+	for {
+		err := b.sync(ctx, updates)
+		b.setSynced(false)
+
+		if ctx.Err() != nil {
+			<-streamErr
+			return ctx.Err()
+		}
+		b.opts.errorHandler(err)
+
+		if !bo.wait(ctx) {
+			<-streamErr
+			return ctx.Err()
+		}
+	}
+}
+
+// sync seeds the book from a snapshot and applies buffered and later updates
+// until one is missed. The book is synced as soon as the snapshot is taken,
+// so that a market without updates can still be read.
+func (b *LocalOrderBook) sync(ctx context.Context,
+	updates <-chan *DepthUpdateEvent) error {
+	snapshot, err := b.client.OrderBook(ctx, &binance.OrderBookRequest{
+		Symbol: b.symbol,
+		Limit:  b.opts.snapshotLimit,
+	})
+	if err != nil {
+		return errors.Wrap(err, "failed to fetch order book snapshot")
+	}
+
+	b.reset(snapshot)
+
+	for {
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case u := <-updates:
+			if err := b.apply(u); err != nil {
+				return err
+			}
+		}
+	}
+}
+
+// reset replaces the contents of the book with a snapshot, which is synced
+// until an update shows that it is too old.
+func (b *LocalOrderBook) reset(snapshot *binance.OrderBook) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	b.asks.levels = b.asks.levels[:0]
+	b.bids.levels = b.bids.levels[:0]
+	b.applied = false
+	b.lastUpdateID = snapshot.LastUpdateID
+	b.synced = true
+
+	for _, l := range snapshot.Asks {
+		b.asks.set(l)
+	}
+
+	for _, l := range snapshot.Bids {
+		b.bids.set(l)
+	}
+}
+
+// apply applies an update to the book, following the update ID rules:
+// updates older than the snapshot are dropped, the first update applied must
+// straddle the snapshot, and each update after that must start where the
+// previous one finished.
+func (b *LocalOrderBook) apply(u *DepthUpdateEvent) error {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if u.FinalUpdateID <= b.lastUpdateID {
+		return nil
+	}
+
+	if b.applied && u.FirstUpdateID != b.lastUpdateID+1 {
+		return errors.Wrap(errSequenceGap, "missed depth update",
+			j.MKV{"expected": b.lastUpdateID + 1, "got": u.FirstUpdateID})
+	}
+
+	if !b.applied && u.FirstUpdateID > b.lastUpdateID+1 {
+		return errors.Wrap(errSequenceGap, "snapshot is too old",
+			j.MKV{"expected": b.lastUpdateID + 1, "got": u.FirstUpdateID})
+	}
+
+	for _, l := range u.Asks {
+		b.asks.set(l)
+	}
+
+	for _, l := range u.Bids {
+		b.bids.set(l)
+	}
+
+	b.applied = true
+	b.lastUpdateID = u.FinalUpdateID
+	return nil
+}
+
+func (b *LocalOrderBook) setSynced(synced bool) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.synced = synced
+}
+
+// Synced returns whether the book is currently in sync with the exchange.
+func (b *LocalOrderBook) Synced() bool {
+	b.mu.RLock()
+	defer b.mu.RUnlock()
+	return b.synced
+}
+
+// LastUpdateID returns the ID of the last update applied to the book.
+func (b *LocalOrderBook) LastUpdateID() int64 {
+	b.mu.RLock()
+	defer b.mu.RUnlock()
+	return b.lastUpdateID
+}
+
+// BestBid returns the highest bid, and whether there is one.
+func (b *LocalOrderBook) BestBid() (binance.PriceLevel, bool) {
+	b.mu.RLock()
+	defer b.mu.RUnlock()
+	return b.bids.best(b.synced)
+}
+
+// BestAsk returns the lowest ask, and whether there is one.
+func (b *LocalOrderBook) BestAsk() (binance.PriceLevel, bool) {
+	b.mu.RLock()
+	defer b.mu.RUnlock()
+	return b.asks.best(b.synced)
+}
+
+// Bids returns the top `n` bids, from highest to lowest price. All bids are
+// returned if `n` is not positive.
+func (b *LocalOrderBook) Bids(n int) []binance.PriceLevel {
+	b.mu.RLock()
+	defer b.mu.RUnlock()
+	return b.bids.top(n, b.synced)
+}
+
+// Asks returns the top `n` asks, from lowest to highest price. All asks are
+// returned if `n` is not positive.
+func (b *LocalOrderBook) Asks(n int) []binance.PriceLevel {
+	b.mu.RLock()
+	defer b.mu.RUnlock()
+	return b.asks.top(n, b.synced)
+}
+
+// Snapshot returns the top `n` levels of both sides of the book as of a single
+// update. All levels are returned if `n` is not positive.
+func (b *LocalOrderBook) Snapshot(n int) *binance.OrderBook {
+	b.mu.RLock()
+	defer b.mu.RUnlock()
+
+	return &binance.OrderBook{
+		Asks:         b.asks.top(n, b.synced),
+		Bids:         b.bids.top(n, b.synced),
+		LastUpdateID: b.lastUpdateID,
+	}
+}
+
+// DepthAt returns the quantity resting at exactly `price` on one side of the
+// book. Bids are on the Buy side and asks on the Sell side.
+func (b *LocalOrderBook) DepthAt(side binance.OrderSide,
+	price binance.Decimal) binance.Decimal {
+	b.mu.RLock()
+	defer b.mu.RUnlock()
+
+	if !b.synced {
+		return binance.Decimal{}
+	}
+
+	s := b.side(side)
+	i, found := s.search(price)
+	if !found {
+		return binance.Decimal{}
+	}
+	return s.levels[i].Qty
+}
+
+// CumulativeDepth returns the total quantity resting at `price` or better on
+// one side of the book, which is the quantity that an order at `price` could
+// fill against. Bids are on the Buy side and asks on the Sell side.
+func (b *LocalOrderBook) CumulativeDepth(side binance.OrderSide,
+	price binance.Decimal) binance.Decimal {
+	b.mu.RLock()
+	defer b.mu.RUnlock()
+
+	var total binance.Decimal
+	if !b.synced {
+		return total
+	}
+
+	s := b.side(side)
+	i, found := s.search(price)
+	if found {
+		i++
+	}
+
+	for _, l := range s.levels[:i] {
+		total = total.Add(l.Qty)
+	}
+	return total
+}
+
+func (b *LocalOrderBook) side(side binance.OrderSide) *bookSide {
+	if side == binance.Buy {
+		return &b.bids
+	}
+	return &b.asks
+}
+
+// bookSide holds the levels of one side of a book, ordered best first.
+type bookSide struct {
+	descending bool
+	levels     []binance.PriceLevel
+}
+
+// search returns the index at which `price` is, or would be inserted, and
+// whether it is present.
+func (s *bookSide) search(price binance.Decimal) (int, bool) {
+	i := sort.Search(len(s.levels), func(i int) bool {
+		c := s.levels[i].Price.Cmp(price)
+		if s.descending {
+			return c <= 0
+		}
+		return c >= 0
+	})
+	return i, i < len(s.levels) && s.levels[i].Price.Equal(price)
+}
+
+// set updates the quantity at a price level, removing it if the quantity is
+// zero.
+func (s *bookSide) set(l binance.PriceLevel) {
+	i, found := s.search(l.Price)
+	switch {
+	case found && l.Qty.IsZero():
+		s.levels = append(s.levels[:i], s.levels[i+1:]...)
+	case found:
+		s.levels[i] = l
+	case !l.Qty.IsZero():
+		s.levels = append(s.levels, binance.PriceLevel{})
+		copy(s.levels[i+1:], s.levels[i:])
+		s.levels[i] = l
+	}
+}
+
+func (s *bookSide) best(synced bool) (binance.PriceLevel, bool) {
+	if !synced || len(s.levels) == 0 {
+		return binance.PriceLevel{}, false
+	}
+	return s.levels[0], true
+}
+
+func (s *bookSide) top(n int, synced bool) []binance.PriceLevel {
+	if !synced {
+		return nil
+	}
+
+	if n <= 0 || n > len(s.levels) {
+		n = len(s.levels)
+	}
+
+	levels := make([]binance.PriceLevel, n)
+	copy(levels, s.levels)
+	return levels
+}
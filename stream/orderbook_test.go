@@ -0,0 +1,237 @@
+package stream
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/gorilla/websocket"
+	"github.com/luno/jettison/errors"
+	"github.com/nickcorin/binance"
+	"github.com/stretchr/testify/require"
+)
+
+func levels(pairs ...string) []binance.PriceLevel {
+	var l []binance.PriceLevel
+	for i := 0; i < len(pairs); i += 2 {
+		l = append(l, binance.PriceLevel{
+			Price: binance.MustParseDecimal(pairs[i]),
+			Qty:   binance.MustParseDecimal(pairs[i+1]),
+		})
+	}
+	return l
+}
+
+func TestLocalOrderBook_Apply(t *testing.T) {
+	b := NewLocalOrderBook(nil, "BTCUSDT")
+	b.reset(&binance.OrderBook{
+		LastUpdateID: 100,
+		Asks:         levels("11", "1", "12", "1"),
+		Bids:         levels("10", "1", "9", "1"),
+	})
+	require.True(t, b.Synced())
+
+	// Updates from before the snapshot are dropped.
+	require.NoError(t, b.apply(&DepthUpdateEvent{
+		FirstUpdateID: 95,
+		FinalUpdateID: 99,
+		Bids:          levels("10", "100"),
+	}))
+	require.Equal(t, int64(100), b.LastUpdateID())
+
+	// The first update must straddle the snapshot.
+	require.NoError(t, b.apply(&DepthUpdateEvent{
+		FirstUpdateID: 100,
+		FinalUpdateID: 102,
+		Asks:          levels("11", "0"),
+		Bids:          levels("10", "2"),
+	}))
+	require.True(t, b.Synced())
+
+	require.NoError(t, b.apply(&DepthUpdateEvent{
+		FirstUpdateID: 103,
+		FinalUpdateID: 103,
+		Bids:          levels("9.5", "3"),
+	}))
+
+	bid, ok := b.BestBid()
+	require.True(t, ok)
+	require.Equal(t, levels("10", "2")[0], bid)
+
+	ask, ok := b.BestAsk()
+	require.True(t, ok)
+	require.Equal(t, levels("12", "1")[0], ask)
+
+	require.Equal(t, levels("10", "2", "9.5", "3"), b.Bids(2))
+	require.Equal(t, levels("10", "2", "9.5", "3", "9", "1"), b.Bids(0))
+	require.Equal(t, int64(103), b.LastUpdateID())
+
+	price := binance.MustParseDecimal
+	require.Equal(t, "3", b.DepthAt(binance.Buy, price("9.5")).String())
+	require.True(t, b.DepthAt(binance.Sell, price("11")).IsZero())
+	require.Equal(t, "5", b.CumulativeDepth(binance.Buy, price("9.5")).String())
+	require.Equal(t, "5", b.CumulativeDepth(binance.Buy, price("9.2")).String())
+
+	// A missed update is reported as a gap.
+	err := b.apply(&DepthUpdateEvent{FirstUpdateID: 105, FinalUpdateID: 106})
+	require.True(t, errors.Is(err, errSequenceGap))
+}
+
+func TestLocalOrderBook_StaleSnapshot(t *testing.T) {
+	b := NewLocalOrderBook(nil, "BTCUSDT")
+	b.reset(&binance.OrderBook{LastUpdateID: 100})
+
+	err := b.apply(&DepthUpdateEvent{FirstUpdateID: 102, FinalUpdateID: 103})
+	require.True(t, errors.Is(err, errSequenceGap))
+}
+
+// depthServer serves order book snapshots over REST, and depth updates pushed
+// by the test over the combined stream endpoint.
+type depthServer struct {
+	t         *testing.T
+	snapshots chan string
+	updates   chan string
+	upgrader  websocket.Upgrader
+
+	mu        sync.Mutex
+	snapshotN int
+}
+
+func (s *depthServer) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if r.URL.Path == "/depth" {
+		s.mu.Lock()
+		s.snapshotN++
+		s.mu.Unlock()
+		w.Write([]byte(<-s.snapshots))
+		return
+	}
+
+	conn, err := s.upgrader.Upgrade(w, r, nil)
+	require.NoError(s.t, err)
+	defer conn.Close()
+
+	stream := r.URL.Query().Get("streams")
+	for {
+		select {
+		case u := <-s.updates:
+			msg := fmt.Sprintf(`{"stream":%q,"data":%s}`, stream, u)
+			require.NoError(s.t, conn.WriteMessage(websocket.TextMessage,
+				[]byte(msg)))
+		case <-r.Context().Done():
+			return
+		}
+	}
+}
+
+func depthUpdate(first, final int64, bids, asks []binance.PriceLevel) string {
+	pairs := func(l []binance.PriceLevel) [][]string {
+		p := make([][]string, 0, len(l))
+		for _, level := range l {
+			p = append(p, []string{level.Price.String(), level.Qty.String()})
+		}
+		return p
+	}
+
+	b, _ := json.Marshal(map[string]interface{}{
+		"e": "depthUpdate", "E": 1, "s": "BTCUSDT", "U": first, "u": final,
+		"b": pairs(bids), "a": pairs(asks),
+	})
+	return string(b)
+}
+
+func TestLocalOrderBook_Run(t *testing.T) {
+	ds := &depthServer{
+		t:         t,
+		snapshots: make(chan string, 2),
+		updates:   make(chan string, 10),
+	}
+	srv := httptest.NewServer(ds)
+	defer srv.Close()
+
+	ds.snapshots <- `{"lastUpdateId":100,"bids":[["10","1"]],"asks":[["11","1"]]}`
+	ds.snapshots <- `{"lastUpdateId":200,"bids":[["20","1"]],"asks":[["21","1"]]}`
+
+	c := binance.NewClient(binance.WithBaseURL(srv.URL))
+	b := NewLocalOrderBook(c, "BTCUSDT",
+		WithBaseURL("ws"+strings.TrimPrefix(srv.URL, "http")),
+		WithReconnectWait(time.Millisecond, time.Millisecond))
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	errs := make(chan error, 1)
+	go func() {
+		errs <- b.Run(ctx)
+	}()
+
+	ds.updates <- depthUpdate(99, 101, levels("10", "5"), nil)
+	require.Eventually(t, func() bool {
+		bid, ok := b.BestBid()
+		return ok && bid.Qty.String() == "5"
+	}, 5*time.Second, time.Millisecond)
+
+	// Skipping 102 forces a resync from the second snapshot.
+	ds.updates <- depthUpdate(103, 103, levels("10", "6"), nil)
+	ds.updates <- depthUpdate(150, 201, nil, levels("21", "2"))
+	require.Eventually(t, func() bool {
+		ask, ok := b.BestAsk()
+		return ok && ask.Price.String() == "21" && ask.Qty.String() == "2"
+	}, 5*time.Second, time.Millisecond)
+
+	bid, ok := b.BestBid()
+	require.True(t, ok)
+	require.Equal(t, "20", bid.Price.String())
+
+	ds.mu.Lock()
+	require.Equal(t, 2, ds.snapshotN)
+	ds.mu.Unlock()
+
+	cancel()
+	require.Equal(t, context.Canceled, <-errs)
+}
+
+func TestLocalOrderBook_RunQuiet(t *testing.T) {
+	ds := &depthServer{
+		t:         t,
+		snapshots: make(chan string, 1),
+		updates:   make(chan string, 10),
+	}
+	srv := httptest.NewServer(ds)
+	defer srv.Close()
+
+	ds.snapshots <- `{"lastUpdateId":100,"bids":[["10","1"]],"asks":[["11","1"]]}`
+
+	c := binance.NewClient(binance.WithBaseURL(srv.URL))
+	b := NewLocalOrderBook(c, "BTCUSDT",
+		WithBaseURL("ws"+strings.TrimPrefix(srv.URL, "http")),
+		WithReconnectWait(time.Millisecond, time.Millisecond))
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	errs := make(chan error, 1)
+	go func() {
+		errs <- b.Run(ctx)
+	}()
+
+	// A market without updates is synced from its snapshot.
+	require.Eventually(t, b.Synced, 5*time.Second, time.Millisecond)
+	bid, ok := b.BestBid()
+	require.True(t, ok)
+	require.Equal(t, "10", bid.Price.String())
+
+	ds.updates <- depthUpdate(101, 101, levels("10", "2"), nil)
+	require.Eventually(t, func() bool {
+		bid, ok := b.BestBid()
+		return ok && bid.Qty.String() == "2"
+	}, 5*time.Second, time.Millisecond)
+
+	cancel()
+	require.Equal(t, context.Canceled, <-errs)
+}
@@ -0,0 +1,394 @@
+package stream
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"sync/atomic"
+
+	"github.com/gorilla/websocket"
+	"github.com/luno/jettison/errors"
+	"github.com/luno/jettison/j"
+	"github.com/nickcorin/binance"
+)
+
+// ExecutionType describes what caused an ExecutionReport to be sent.
+type ExecutionType string
+
+const (
+	// ExecutionTypeNew indicates that an order was accepted by the engine.
+	ExecutionTypeNew ExecutionType = "NEW"
+
+	// ExecutionTypeCanceled indicates that an order was cancelled by the
+	// user.
+	ExecutionTypeCanceled ExecutionType = "CANCELED"
+
+	// ExecutionTypeReplaced is currently unused.
+	ExecutionTypeReplaced ExecutionType = "REPLACED"
+
+	// ExecutionTypeRejected indicates that an order was rejected and not
+	// processed by the engine.
+	ExecutionTypeRejected ExecutionType = "REJECTED"
+
+	// ExecutionTypeTrade indicates that part or all of an order was filled.
+	ExecutionTypeTrade ExecutionType = "TRADE"
+
+	// ExecutionTypeExpired indicates that an order was cancelled according
+	// to its TimeInForce, or by the exchange.
+	ExecutionTypeExpired ExecutionType = "EXPIRED"
+)
+
+// ExecutionReport is sent whenever an order is created, updated or filled.
+type ExecutionReport struct {
+	EventHeader
+
+	// ClientOrderID represents the unique identifier provided by the client on
+	// order creation. For a cancel, this is the ID of the cancel request.
+	ClientOrderID string `json:"c"`
+
+	// Commission represents the fee charged for the last fill.
+	Commission binance.Decimal `json:"n"`
+
+	// CommissionAsset represents the asset the fee for the last fill was
+	// charged in. Empty if there was no fill.
+	CommissionAsset string `json:"N"`
+
+	// CumulativeFilledQty represents how much of the order has been filled.
+	CumulativeFilledQty binance.Decimal `json:"z"`
+
+	// CumulativeQuoteQty represents the quote asset quantity that has been
+	// transacted.
+	CumulativeQuoteQty binance.Decimal `json:"Z"`
+
+	// ExecutionType represents what caused the report to be sent.
+	ExecutionType ExecutionType `json:"x"`
+
+	// IcebergQty represents the maximum amount per sub-order.
+	IcebergQty binance.Decimal `json:"F"`
+
+	// IsMaker represents whether the last fill was as the maker.
+	IsMaker bool `json:"m"`
+
+	// IsWorking represents whether the order is on the book.
+	IsWorking bool `json:"w"`
+
+	// LastExecutedPrice represents the price of the last fill.
+	LastExecutedPrice binance.Decimal `json:"L"`
+
+	// LastExecutedQty represents the quantity of the last fill.
+	LastExecutedQty binance.Decimal `json:"l"`
+
+	// LastQuoteQty represents the quote asset quantity of the last fill.
+	LastQuoteQty binance.Decimal `json:"Y"`
+
+	// OrderCreationTime represents the unix timestamp in milliseconds at
+	// which the order was created.
+	OrderCreationTime int64 `json:"O"`
+
+	// OrderID represents the unique identifier provided by Binance on order
+	// creation.
+	OrderID int64 `json:"i"`
+
+	// OrderListID will always be -1 if the order is not part of an order
+	// list.
+	OrderListID int64 `json:"g"`
+
+	// OrderType represents the type of the order.
+	OrderType binance.OrderType `json:"o"`
+
+	// OrigClientOrderID represents the client order ID of the order being
+	// cancelled. Empty unless the order was cancelled.
+	OrigClientOrderID string `json:"C"`
+
+	// Price represents the price the order was placed at.
+	Price binance.Decimal `json:"p"`
+
+	// Qty represents the quantity the order was placed for.
+	Qty binance.Decimal `json:"q"`
+
+	// QuoteOrderQty represents the quote asset quantity the order was placed
+	// for.
+	QuoteOrderQty binance.Decimal `json:"Q"`
+
+	// RejectReason represents why the order was rejected, or NONE.
+	RejectReason string `json:"r"`
+
+	// Side represents whether the order is a buy or sell.
+	Side binance.OrderSide `json:"S"`
+
+	// Status represents the current status of the order.
+	Status binance.OrderStatus `json:"X"`
+
+	// StopPrice represents the price the market needs to reach before the
+	// order is triggered.
+	StopPrice binance.Decimal `json:"P"`
+
+	// Symbol represents the market the order was placed on.
+	Symbol string `json:"s"`
+
+	// TimeInForce represents the duration of validity of the order.
+	TimeInForce binance.TimeInForce `json:"f"`
+
+	// TradeID represents the ID of the last fill, or -1.
+	TradeID int64 `json:"t"`
+
+	// TransactionTime represents the unix timestamp in milliseconds of the
+	// update.
+	TransactionTime int64 `json:"T"`
+
+	// WorkingTime represents the unix timestamp in milliseconds at which the
+	// order was placed on the book.
+	WorkingTime int64 `json:"W"`
+
+	// IgnoreI and IgnoreM are undocumented. They are declared so that their
+	// keys aren't decoded into the fields whose keys differ only in case.
+	IgnoreI int64 `json:"I"`
+	IgnoreM bool  `json:"M"`
+}
+
+// AccountPosition is sent whenever an account balance changes, and contains
+// the assets that may have changed.
+type AccountPosition struct {
+	EventHeader
+
+	// Balances contains the balances of the assets that changed.
+	Balances []PositionBalance `json:"B"`
+
+	// LastUpdateTime represents the unix timestamp in milliseconds of the
+	// last account update.
+	LastUpdateTime int64 `json:"u"`
+}
+
+// PositionBalance contains the balance of an asset in an AccountPosition.
+type PositionBalance struct {
+	Asset  string          `json:"a"`
+	Free   binance.Decimal `json:"f"`
+	Locked binance.Decimal `json:"l"`
+}
+
+// BalanceUpdate is sent when an account deposits or withdraws, or funds are
+// transferred between accounts.
+type BalanceUpdate struct {
+	EventHeader
+
+	// Asset represents the asset that changed.
+	Asset string `json:"a"`
+
+	// ClearTime represents the unix timestamp in milliseconds at which the
+	// change cleared.
+	ClearTime int64 `json:"T"`
+
+	// Delta represents the change in balance.
+	Delta binance.Decimal `json:"d"`
+}
+
+// ListStatus is sent alongside the ExecutionReports of the orders in an
+// order list whenever the list is updated.
+type ListStatus struct {
+	EventHeader
+
+	// ContingencyType represents how the orders in the list relate.
+	ContingencyType binance.ContingencyType `json:"c"`
+
+	// ListClientOrderID represents the unique identifier provided by the
+	// client on order list creation.
+	ListClientOrderID string `json:"C"`
+
+	// ListOrderStatus represents the status of the orders in the list.
+	ListOrderStatus binance.ListOrderStatus `json:"L"`
+
+	// ListRejectReason represents why the list was rejected, or NONE.
+	ListRejectReason string `json:"r"`
+
+	// ListStatusType represents the status of the list as a whole.
+	ListStatusType binance.ListStatusType `json:"l"`
+
+	// OrderListID represents the unique identifier provided by Binance on
+	// order list creation.
+	OrderListID int64 `json:"g"`
+
+	// Orders contains the identifiers of each order in the list.
+	Orders []ListStatusOrder `json:"O"`
+
+	// Symbol represents the market the order list was placed on.
+	Symbol string `json:"s"`
+
+	// TransactionTime represents the unix timestamp in milliseconds of the
+	// update.
+	TransactionTime int64 `json:"T"`
+}
+
+// ListStatusOrder identifies an order in a ListStatus.
+type ListStatusOrder struct {
+	ClientOrderID string `json:"c"`
+	OrderID       int64  `json:"i"`
+	Symbol        string `json:"s"`
+}
+
+// ListenKeyExpired is sent when the listen key of a stream expires. No more
+// events are sent on the stream after it.
+type ListenKeyExpired struct {
+	EventHeader
+
+	// ListenKey represents the key that expired.
+	ListenKey string `json:"listenKey"`
+}
+
+// decodeUserDataEvent decodes a message from a user data stream into its
+// concrete Event type. Unknown events are returned as nil.
+func decodeUserDataEvent(msg []byte) (Event, error) {
+	var header EventHeader
+	if err := json.Unmarshal(msg, &header); err != nil {
+		return nil, errors.Wrap(err, "failed to parse event header")
+	}
+
+	var event Event
+	switch header.Type {
+	case EventTypeExecutionReport:
+		event = new(ExecutionReport)
+	case EventTypeAccountPosition:
+		event = new(AccountPosition)
+	case EventTypeBalanceUpdate:
+		event = new(BalanceUpdate)
+	case EventTypeListStatus:
+		event = new(ListStatus)
+	case EventTypeListenKeyExpired:
+		event = new(ListenKeyExpired)
+	default:
+		return nil, nil
+	}
+
+	if err := json.Unmarshal(msg, event); err != nil {
+		return nil, errors.Wrap(err, "failed to parse event",
+			j.KV("event_type", header.Type))
+	}
+
+	return event, nil
+}
+
+// errListenKeyExpired is returned when a connection must be re-established
+// with a new listen key.
+var errListenKeyExpired = errors.New("listen key expired",
+	j.C("ERR_LISTEN_KEY_EXPIRED"))
+
+// UserDataStream consumes the user data stream of an account, which reports
+// order updates and balance changes as they happen.
+type UserDataStream struct {
+	client binance.Client
+	opts   options
+}
+
+// NewUserDataStream returns a UserDataStream which uses `c` to manage its
+// listen key. The Client must be configured with an API key.
+func NewUserDataStream(c binance.Client, opts ...Option) *UserDataStream {
+	return &UserDataStream{
+		client: c,
+		opts:   newOptions(opts),
+	}
+}
+
+// Run connects to the user data stream and calls `handler` with each event
+// received, until `ctx` ends. Dropped connections are re-established, and
+// the listen key is replaced whenever it expires or becomes invalid.
+//
+// Events are one of *ExecutionReport, *AccountPosition, *BalanceUpdate,
+// *ListStatus or *ListenKeyExpired. The handler is called from a single
+// goroutine and should not block.
+func (s *UserDataStream) Run(ctx context.Context, handler func(Event)) error {
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	keeper, err := binance.NewListenKeyKeeper(ctx, s.client,
+		binance.WithKeepAliveInterval(s.opts.keepAliveInterval))
+	if err != nil {
+		return err
+	}
+
+	// Wait for the keeper to close the listen key before returning.
+	defer func() {
+		cancel()
+		<-keeper.Done()
+	}()
+
+	// A keepalive failing with ErrInvalidListenKey means that the current
+	// connection will receive nothing further.
+	invalidKey := make(chan struct{}, 1)
+	go func() {
+		for err := range keeper.Errors() {
+			s.opts.errorHandler(err)
+			if binance.IsError(err, binance.ErrInvalidListenKey) {
+				select {
+				case invalidKey <- struct{}{}:
+				default:
+				}
+			}
+		}
+	}()
+
+	b := newBackoff(&s.opts)
+	for {
+		err := s.consume(ctx, keeper.ListenKey(), invalidKey, b, handler)
+		if ctx.Err() != nil {
+			return ctx.Err()
+		}
+		s.opts.errorHandler(err)
+
+		if errors.Is(err, errListenKeyExpired) {
+			if _, err := keeper.Renew(ctx); err != nil {
+				s.opts.errorHandler(err)
+			}
+		}
+
+		if !b.wait(ctx) {
+			return ctx.Err()
+		}
+	}
+}
+
+// consume reads events from a single connection until it drops or the listen
+// key is reported invalid.
+func (s *UserDataStream) consume(ctx context.Context, listenKey string,
+	invalidKey <-chan struct{}, b *backoff, handler func(Event)) error {
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	var invalid int32
+	go func() {
+		select {
+		case <-invalidKey:
+			atomic.StoreInt32(&invalid, 1)
+			cancel()
+		case <-ctx.Done():
+		}
+	}()
+
+	url := fmt.Sprintf("%s/ws/%s", s.opts.baseURL, listenKey)
+	onConnect := func(*websocket.Conn) error {
+		b.reset()
+		return nil
+	}
+
+	err := connect(ctx, &s.opts, url, onConnect, func(msg []byte) error {
+		event, err := decodeUserDataEvent(msg)
+		if err != nil {
+			s.opts.errorHandler(err)
+			return nil
+		}
+
+		if event == nil {
+			return nil
+		}
+
+		handler(event)
+
+		if event.EventType() == EventTypeListenKeyExpired {
+			return errListenKeyExpired
+		}
+		return nil
+	})
+
+	if atomic.LoadInt32(&invalid) == 1 {
+		return errListenKeyExpired
+	}
+	return err
+}
@@ -0,0 +1,259 @@
+package stream
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/gorilla/websocket"
+	"github.com/nickcorin/binance"
+	"github.com/stretchr/testify/require"
+)
+
+const executionReport = `{
+  "e": "executionReport", "E": 1499405658658, "s": "ETHBTC",
+  "c": "mUvoqJxFIILMdfAW5iGSOW", "S": "BUY", "o": "LIMIT", "f": "GTC",
+  "q": "1.00000000", "p": "0.10264410", "P": "0.00000000",
+  "F": "0.00000000", "g": -1, "C": "", "x": "TRADE",
+  "X": "PARTIALLY_FILLED", "r": "NONE", "i": 4293153, "l": "0.50000000",
+  "z": "0.50000000", "L": "0.10264410", "n": "0.00050000", "N": "BNB",
+  "T": 1499405658657, "t": 7, "I": 8641984, "w": true, "m": true,
+  "M": false, "O": 1499405658657, "Z": "0.05132205", "Y": "0.05132205",
+  "Q": "0.00000000", "W": 1499405658657
+}`
+
+func TestDecodeUserDataEvent(t *testing.T) {
+	tests := []struct {
+		msg   string
+		event Event
+	}{
+		{
+			msg: executionReport,
+			event: &ExecutionReport{
+				EventHeader: EventHeader{
+					Time: 1499405658658,
+					Type: EventTypeExecutionReport,
+				},
+				ClientOrderID:       "mUvoqJxFIILMdfAW5iGSOW",
+				Commission:          binance.MustParseDecimal("0.00050000"),
+				CommissionAsset:     "BNB",
+				CumulativeFilledQty: binance.MustParseDecimal("0.50000000"),
+				CumulativeQuoteQty:  binance.MustParseDecimal("0.05132205"),
+				ExecutionType:       ExecutionTypeTrade,
+				IcebergQty:          binance.MustParseDecimal("0.00000000"),
+				IsMaker:             true,
+				IsWorking:           true,
+				LastExecutedPrice:   binance.MustParseDecimal("0.10264410"),
+				LastExecutedQty:     binance.MustParseDecimal("0.50000000"),
+				LastQuoteQty:        binance.MustParseDecimal("0.05132205"),
+				OrderCreationTime:   1499405658657,
+				OrderID:             4293153,
+				OrderListID:         -1,
+				OrderType:           binance.OrderTypeLimit,
+				Price:               binance.MustParseDecimal("0.10264410"),
+				Qty:                 binance.MustParseDecimal("1.00000000"),
+				QuoteOrderQty:       binance.MustParseDecimal("0.00000000"),
+				RejectReason:        "NONE",
+				Side:                binance.Buy,
+				Status:              binance.OrderStatusPartiallyFilled,
+				StopPrice:           binance.MustParseDecimal("0.00000000"),
+				Symbol:              "ETHBTC",
+				TimeInForce:         binance.GoodUntilCancelled,
+				TradeID:             7,
+				TransactionTime:     1499405658657,
+				WorkingTime:         1499405658657,
+				IgnoreI:             8641984,
+			},
+		},
+		{
+			msg: `{"e":"outboundAccountPosition","E":1564034571105,
+				"u":1564034571073,"B":[{"a":"ETH","f":"10000.000000",
+				"l":"0.000000"}]}`,
+			event: &AccountPosition{
+				EventHeader: EventHeader{
+					Time: 1564034571105,
+					Type: EventTypeAccountPosition,
+				},
+				Balances: []PositionBalance{
+					{Asset: "ETH", Free: binance.MustParseDecimal("10000.000000"), Locked: binance.MustParseDecimal("0.000000")},
+				},
+				LastUpdateTime: 1564034571073,
+			},
+		},
+		{
+			msg: `{"e":"balanceUpdate","E":1573200697110,"a":"BTC",
+				"d":"100.00000000","T":1573200697068}`,
+			event: &BalanceUpdate{
+				EventHeader: EventHeader{
+					Time: 1573200697110,
+					Type: EventTypeBalanceUpdate,
+				},
+				Asset:     "BTC",
+				ClearTime: 1573200697068,
+				Delta:     binance.MustParseDecimal("100.00000000"),
+			},
+		},
+		{
+			msg: `{"e":"listStatus","E":1564035303637,"s":"ETHBTC","g":2,
+				"c":"OCO","l":"EXEC_STARTED","L":"EXECUTING","r":"NONE",
+				"C":"F4QN4G8DlFATFlIUQ0cjdD","T":1564035303625,
+				"O":[{"s":"ETHBTC","i":17,"c":"AJYsMjErWJesZvqlJCTUgL"}]}`,
+			event: &ListStatus{
+				EventHeader: EventHeader{
+					Time: 1564035303637,
+					Type: EventTypeListStatus,
+				},
+				ContingencyType:   binance.ContingencyTypeOCO,
+				ListClientOrderID: "F4QN4G8DlFATFlIUQ0cjdD",
+				ListOrderStatus:   binance.ListOrderStatusExecuting,
+				ListRejectReason:  "NONE",
+				ListStatusType:    binance.ListStatusTypeExecStarted,
+				OrderListID:       2,
+				Orders: []ListStatusOrder{{
+					ClientOrderID: "AJYsMjErWJesZvqlJCTUgL",
+					OrderID:       17,
+					Symbol:        "ETHBTC",
+				}},
+				Symbol:          "ETHBTC",
+				TransactionTime: 1564035303625,
+			},
+		},
+		{
+			msg:   `{"e":"somethingNew","E":1564035303637}`,
+			event: nil,
+		},
+	}
+
+	for i, test := range tests {
+		t.Run(fmt.Sprintf("%d", i), func(t *testing.T) {
+			event, err := decodeUserDataEvent([]byte(test.msg))
+			require.NoError(t, err)
+			require.Equal(t, test.event, event)
+		})
+	}
+}
+
+// userDataServer serves both the listen key REST endpoints and the user data
+// WebSocket, handing out a new listen key on every POST.
+type userDataServer struct {
+	t      *testing.T
+	events map[string][]string
+
+	mu       sync.Mutex
+	keys     int
+	upgrader websocket.Upgrader
+}
+
+func (s *userDataServer) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if r.URL.Path == "/userDataStream" {
+		s.mu.Lock()
+		defer s.mu.Unlock()
+		if r.Method == http.MethodPost {
+			s.keys++
+			fmt.Fprintf(w, `{"listenKey":"key%d"}`, s.keys)
+			return
+		}
+		w.Write([]byte(`{}`))
+		return
+	}
+
+	key := strings.TrimPrefix(r.URL.Path, "/ws/")
+	events, ok := s.events[key]
+	if !ok {
+		http.Error(w, "unknown listen key", http.StatusBadRequest)
+		return
+	}
+
+	conn, err := s.upgrader.Upgrade(w, r, nil)
+	require.NoError(s.t, err)
+	defer conn.Close()
+
+	for _, e := range events {
+		require.NoError(s.t, conn.WriteMessage(websocket.TextMessage,
+			[]byte(e)))
+	}
+
+	// Hold the connection open until the client goes away.
+	for {
+		if _, _, err := conn.ReadMessage(); err != nil {
+			return
+		}
+	}
+}
+
+func TestUserDataStream_Run(t *testing.T) {
+	srv := httptest.NewServer(&userDataServer{
+		t: t,
+		events: map[string][]string{
+			"key1": {
+				executionReport,
+				`{"e":"listenKeyExpired","E":1576653824250,
+					"listenKey":"key1"}`,
+			},
+			"key2": {
+				`{"e":"balanceUpdate","E":1573200697110,"a":"BTC",
+					"d":"100.00000000","T":1573200697068}`,
+			},
+		},
+	})
+	defer srv.Close()
+
+	c := binance.NewClient(binance.WithBaseURL(srv.URL))
+	s := NewUserDataStream(c,
+		WithBaseURL("ws"+strings.TrimPrefix(srv.URL, "http")),
+		WithReconnectWait(time.Millisecond, time.Millisecond))
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	events := make(chan Event, 10)
+	errs := make(chan error, 1)
+	go func() {
+		errs <- s.Run(ctx, func(e Event) { events <- e })
+	}()
+
+	var types []EventType
+	for len(types) < 3 {
+		select {
+		case e := <-events:
+			types = append(types, e.EventType())
+		case <-time.After(5 * time.Second):
+			t.Fatalf("timed out waiting for events, got %v", types)
+		}
+	}
+
+	require.Equal(t, []EventType{EventTypeExecutionReport,
+		EventTypeListenKeyExpired, EventTypeBalanceUpdate}, types)
+
+	cancel()
+	require.Equal(t, context.Canceled, <-errs)
+}
+
+func TestWithReconnectWait(t *testing.T) {
+	tests := []struct {
+		wait, max           time.Duration
+		expWait, expMaxWait time.Duration
+	}{
+		{wait: time.Millisecond, max: time.Second,
+			expWait: time.Millisecond, expMaxWait: time.Second},
+		{wait: 0, max: -time.Second,
+			expWait: time.Second, expMaxWait: 30 * time.Second},
+		{wait: time.Minute, max: time.Second,
+			expWait: time.Minute, expMaxWait: time.Minute},
+		{wait: -time.Second, max: time.Millisecond,
+			expWait: time.Second, expMaxWait: time.Second},
+	}
+
+	for i, test := range tests {
+		t.Run(fmt.Sprintf("%d", i), func(t *testing.T) {
+			o := newOptions([]Option{WithReconnectWait(test.wait, test.max)})
+			require.Equal(t, test.expWait, o.reconnectWait)
+			require.Equal(t, test.expMaxWait, o.maxReconnectWait)
+		})
+	}
+}
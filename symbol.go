@@ -1,79 +1,235 @@
 package binance
 
-import "strings"
-
-// Symbol represents a trading market. Created by concatenating the quote
-// asset and the base asset.
-type Symbol string
-
-const (
-	ETHBTC   Symbol = "ETHBTC"
-	LTCBTC   Symbol = "LTCBTC"
-	BNBBTC   Symbol = "BNBBTC"
-	NEOBTC   Symbol = "NEOBTC"
-	BCCBTC   Symbol = "BCCBTC"
-	GASBTC   Symbol = "GASBTC"
-	HSDBTC   Symbol = "HSDBTC"
-	MCOBTC   Symbol = "MCOBTC"
-	WTCBTC   Symbol = "WTCBTC"
-	LRCBTC   Symbol = "LRCBTC"
-	QTUMBTC  Symbol = "QTUMBTC"
-	YOYOBTC  Symbol = "YOYOBTC"
-	OMGBTC   Symbol = "OMGBTC"
-	ZRXBTC   Symbol = "ZRXBTC"
-	STRATBTC Symbol = "STRATBTC"
-
-	QTUMETH  Symbol = "QTUMETH"
-	EOSETH   Symbol = "EOSETH"
-	SNTETH   Symbol = "SNTETH"
-	BNTETH   Symbol = "BNTETH"
-	BNBETH   Symbol = "BNBETH"
-	OAXETH   Symbol = "OAXETH"
-	DNTETH   Symbol = "DNTETH"
-	MCOETH   Symbol = "MCOETH"
-	ICNETH   Symbol = "ICNETH"
-	WTCETH   Symbol = "WTCETH"
-	LRCETH   Symbol = "LRCETH"
-	OMGETH   Symbol = "OMGETH"
-	ZRXETH   Symbol = "ZRXETH"
-	STRATETH Symbol = "STRATETH"
-
-	BTCUSDT Symbol = "BTCUSDT"
-	ETHUSDT Symbol = "ETHUSDT"
+import (
+	"context"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/luno/jettison/errors"
+	"github.com/luno/jettison/j"
 )
 
-// Valid returns whether `s` is a declared Symbol constant.
-func (s Symbol) Valid() bool {
-	return s.BTCBase() || s.ETHBase() || s.USDTBase()
+// ErrUnknownSymbol is returned when a symbol isn't listed on the exchange.
+var ErrUnknownSymbol = errors.New("unknown symbol",
+	j.C("ERR_UNKNOWN_SYMBOL"))
+
+// DefaultSymbolRefreshInterval is how often a SymbolRegistry reloads the
+// exchange's symbols by default.
+const DefaultSymbolRefreshInterval = time.Hour
+
+// SymbolRegistry contains the symbols listed on the exchange, loaded from
+// ExchangeInfo. It is safe for concurrent use.
+type SymbolRegistry struct {
+	client       Client
+	errorHandler func(error)
+	interval     time.Duration
+	now          func() time.Time
+
+	mu        sync.RWMutex
+	symbols   map[string]*SymbolInfo
+	pairs     map[string]*SymbolInfo
+	quotes    []string
+	refreshed time.Time
 }
 
-// BTCBase checks if a Symbol has a base asset of BTC.
-func (s Symbol) BTCBase() bool {
-	return strings.EqualFold(string(s[len(s)-3:len(s)]), "BTC")
+// SymbolRegistryOption is a func-to-SymbolRegistry adapter.
+type SymbolRegistryOption func(*SymbolRegistry)
+
+// WithRefreshErrorHandler returns a SymbolRegistryOption to set a func which
+// is called when Run fails to refresh the symbols. Errors are discarded by
+// default.
+func WithRefreshErrorHandler(handler func(error)) SymbolRegistryOption {
+	return func(r *SymbolRegistry) {
+		r.errorHandler = handler
+	}
 }
 
-// ETHBase checks if a Symbol has a base asset of ETH.
-func (s Symbol) ETHBase() bool {
-	return strings.EqualFold(string(s[len(s)-3:len(s)]), "ETH")
+// WithRefreshInterval returns a SymbolRegistryOption to set how often Run
+// reloads the symbols. Defaults to DefaultSymbolRefreshInterval.
+func WithRefreshInterval(interval time.Duration) SymbolRegistryOption {
+	return func(r *SymbolRegistry) {
+		if interval > 0 {
+			r.interval = interval
+		}
+	}
 }
 
-// StableBase checks if a Symbol has a base asset of USDT.
-func (s Symbol) USDTBase() bool {
-	return strings.EqualFold(string(s[len(s)-3:len(s)]), "USDT")
+// WithRegistryClock returns a SymbolRegistryOption to set the func used to
+// read the current time. This is useful for testing. Defaults to time.Now.
+func WithRegistryClock(now func() time.Time) SymbolRegistryOption {
+	return func(r *SymbolRegistry) {
+		r.now = now
+	}
 }
 
-// Is compares a Symbol string to a given string and tests equality.
-func (s Symbol) Is(s2 string) bool {
-	return strings.EqualFold(string(s), s2)
+// NewSymbolRegistry returns an empty SymbolRegistry which loads symbols using
+// `c`. Call Refresh or Run to load them.
+func NewSymbolRegistry(c Client,
+	opts ...SymbolRegistryOption) *SymbolRegistry {
+	r := SymbolRegistry{
+		client:       c,
+		errorHandler: func(error) {},
+		interval:     DefaultSymbolRefreshInterval,
+		now:          time.Now,
+	}
+
+	for _, o := range opts {
+		o(&r)
+	}
+
+	return &r
 }
 
-// IsAny compares a Symbol string to a list of strings and returns if it is
-// contained.
-func (s Symbol) IsAny(sl ...string) bool {
-	for _, s2 := range sl {
-		if s.Is(s2) {
-			return true
+// pairKey returns the key used to look up a symbol by its assets.
+func pairKey(base, quote string) string {
+	return strings.ToUpper(base) + "/" + strings.ToUpper(quote)
+}
+
+// Load replaces the registry's symbols with those in `info`.
+func (r *SymbolRegistry) Load(info *ExchangeInfo) {
+	symbols := make(map[string]*SymbolInfo, len(info.Symbols))
+	pairs := make(map[string]*SymbolInfo, len(info.Symbols))
+	quoteSet := make(map[string]bool)
+
+	for i := range info.Symbols {
+		s := &info.Symbols[i]
+		symbols[strings.ToUpper(s.Symbol)] = s
+		pairs[pairKey(s.BaseAsset, s.QuoteAsset)] = s
+		quoteSet[strings.ToUpper(s.QuoteAsset)] = true
+	}
+
+	// Sort the quote assets longest first, so that Split prefers USDT over
+	// a shorter suffix such as DT.
+	quotes := make([]string, 0, len(quoteSet))
+	for q := range quoteSet {
+		quotes = append(quotes, q)
+	}
+	sort.Slice(quotes, func(i, j int) bool {
+		if len(quotes[i]) != len(quotes[j]) {
+			return len(quotes[i]) > len(quotes[j])
 		}
+		return quotes[i] < quotes[j]
+	})
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.symbols = symbols
+	r.pairs = pairs
+	r.quotes = quotes
+	r.refreshed = r.now()
+}
+
+// Refresh reloads the registry's symbols from the exchange.
+func (r *SymbolRegistry) Refresh(ctx context.Context) error {
+	info, err := r.client.ExchangeInfo(ctx)
+	if err != nil {
+		return errors.Wrap(err, "failed to refresh symbols")
+	}
+
+	r.Load(info)
+	return nil
+}
+
+// Run refreshes the registry immediately, and then periodically until `ctx`
+// is cancelled. Failed refreshes are passed to the error handler, and the
+// previous symbols are kept until the next refresh succeeds. It always
+// returns a non-nil error.
+func (r *SymbolRegistry) Run(ctx context.Context) error {
+	t := time.NewTicker(r.interval)
+	defer t.Stop()
+
+	for {
+		if err := r.Refresh(ctx); err != nil && ctx.Err() == nil {
+			r.errorHandler(err)
+		}
+
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-t.C:
+		}
+	}
+}
+
+// Refreshed returns when the registry's symbols were last loaded, or the
+// zero time if they haven't been.
+func (r *SymbolRegistry) Refreshed() time.Time {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	return r.refreshed
+}
+
+// Lookup returns the SymbolInfo of `symbol`, which is case insensitive, and
+// whether it was found.
+func (r *SymbolRegistry) Lookup(symbol string) (*SymbolInfo, bool) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	s, ok := r.symbols[strings.ToUpper(symbol)]
+	return s, ok
+}
+
+// LookupPair returns the SymbolInfo of the symbol trading `base` against
+// `quote`, and whether it was found. For example, LookupPair("ETH", "BTC")
+// returns ETHBTC.
+func (r *SymbolRegistry) LookupPair(base, quote string) (*SymbolInfo,
+	bool) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	s, ok := r.pairs[pairKey(base, quote)]
+	return s, ok
+}
+
+// Split returns the base and quote assets of `symbol`. Symbols which aren't
+// listed are split on the longest known quote asset that they end with, so
+// that recently listed symbols can be split before the next refresh.
+func (r *SymbolRegistry) Split(symbol string) (base, quote string,
+	err error) {
+	if s, ok := r.Lookup(symbol); ok {
+		return s.BaseAsset, s.QuoteAsset, nil
+	}
+
+	upper := strings.ToUpper(symbol)
+
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	for _, q := range r.quotes {
+		if len(upper) > len(q) && strings.HasSuffix(upper, q) {
+			return upper[:len(upper)-len(q)], q, nil
+		}
+	}
+
+	return "", "", errors.Wrap(ErrUnknownSymbol,
+		"symbol doesn't end with a known quote asset",
+		j.KV("symbol", symbol))
+}
+
+// Status returns the trading status of `symbol`.
+func (r *SymbolRegistry) Status(symbol string) (SymbolStatus, error) {
+	s, ok := r.Lookup(symbol)
+	if !ok {
+		return "", errors.Wrap(ErrUnknownSymbol, "symbol not listed",
+			j.KV("symbol", symbol))
+	}
+	return s.Status, nil
+}
+
+// IsTrading returns whether `symbol` is listed and currently trading.
+func (r *SymbolRegistry) IsTrading(symbol string) bool {
+	status, err := r.Status(symbol)
+	return err == nil && status == SymbolStatusTrading
+}
+
+// Symbols returns the names of every listed symbol, sorted alphabetically.
+func (r *SymbolRegistry) Symbols() []string {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	names := make([]string, 0, len(r.symbols))
+	for _, s := range r.symbols {
+		names = append(names, s.Symbol)
 	}
-	return false
+	sort.Strings(names)
+	return names
 }
@@ -0,0 +1,135 @@
+package binance
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestSymbolRegistry_OK(t *testing.T) {
+	srv, err := createTestServer(t, http.StatusOK)
+	require.NoError(t, err)
+	defer srv.Close()
+
+	r := NewSymbolRegistry(NewClient(WithBaseURL(srv.URL)))
+	require.True(t, r.Refreshed().IsZero())
+	require.NoError(t, r.Refresh(context.Background()))
+	require.False(t, r.Refreshed().IsZero())
+
+	require.Equal(t, []string{"BCCBTC", "BTCUSDT", "ETHBTC", "USDTDAI"},
+		r.Symbols())
+
+	s, ok := r.Lookup("btcusdt")
+	require.True(t, ok)
+	require.Equal(t, "BTC", s.BaseAsset)
+
+	s, ok = r.LookupPair("eth", "BTC")
+	require.True(t, ok)
+	require.Equal(t, "ETHBTC", s.Symbol)
+
+	_, ok = r.LookupPair("BTC", "ETH")
+	require.False(t, ok)
+
+	require.True(t, r.IsTrading("ETHBTC"))
+	require.False(t, r.IsTrading("BCCBTC"))
+	require.False(t, r.IsTrading("HSDBTC"))
+
+	status, err := r.Status("BCCBTC")
+	require.NoError(t, err)
+	require.Equal(t, SymbolStatusBreak, status)
+
+	_, err = r.Status("HSDBTC")
+	require.True(t, errors.Is(err, ErrUnknownSymbol))
+}
+
+func TestSymbolRegistry_Split(t *testing.T) {
+	r := NewSymbolRegistry(nil)
+	r.Load(&ExchangeInfo{Symbols: []SymbolInfo{
+		{Symbol: "ETHBTC", BaseAsset: "ETH", QuoteAsset: "BTC"},
+		{Symbol: "BTCUSDT", BaseAsset: "BTC", QuoteAsset: "USDT"},
+		{Symbol: "USDTDAI", BaseAsset: "USDT", QuoteAsset: "DAI"},
+		{Symbol: "BNBUSD", BaseAsset: "BNB", QuoteAsset: "USD"},
+	}})
+
+	tests := []struct {
+		symbol string
+		base   string
+		quote  string
+		err    error
+	}{
+		{symbol: "BTCUSDT", base: "BTC", quote: "USDT"},
+		{symbol: "usdtdai", base: "USDT", quote: "DAI"},
+		// Unlisted symbols are split on the longest known quote asset.
+		{symbol: "SOLUSDT", base: "SOL", quote: "USDT"},
+		{symbol: "ethusd", base: "ETH", quote: "USD"},
+		{symbol: "BTC", err: ErrUnknownSymbol},
+		{symbol: "ETHEUR", err: ErrUnknownSymbol},
+	}
+
+	for i, test := range tests {
+		t.Run(fmt.Sprintf("%d", i), func(t *testing.T) {
+			base, quote, err := r.Split(test.symbol)
+			if test.err != nil {
+				require.True(t, errors.Is(err, test.err))
+				return
+			}
+
+			require.NoError(t, err)
+			require.Equal(t, test.base, base)
+			require.Equal(t, test.quote, quote)
+		})
+	}
+}
+
+func TestSymbolRegistry_Run(t *testing.T) {
+	var (
+		mu       sync.Mutex
+		requests int
+	)
+	srv := httptest.NewServer(http.HandlerFunc(
+		func(w http.ResponseWriter, r *http.Request) {
+			mu.Lock()
+			defer mu.Unlock()
+			requests++
+
+			// The second refresh fails, and the symbols are kept.
+			if requests == 2 {
+				w.WriteHeader(http.StatusInternalServerError)
+				return
+			}
+			w.Write([]byte(`{"symbols":[{"symbol":"ETHBTC"}]}`))
+		}))
+	defer srv.Close()
+
+	errs := make(chan error, 10)
+	r := NewSymbolRegistry(NewClient(WithBaseURL(srv.URL)),
+		WithRefreshInterval(time.Millisecond),
+		WithRefreshErrorHandler(func(err error) { errs <- err }))
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	done := make(chan error, 1)
+	go func() {
+		done <- r.Run(ctx)
+	}()
+
+	require.Error(t, <-errs)
+	_, ok := r.Lookup("ETHBTC")
+	require.True(t, ok)
+
+	refreshed := r.Refreshed()
+	require.Eventually(t, func() bool {
+		return r.Refreshed().After(refreshed)
+	}, 5*time.Second, time.Millisecond)
+
+	cancel()
+	require.Equal(t, context.Canceled, <-done)
+}
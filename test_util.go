@@ -4,6 +4,7 @@ import (
 	"io/ioutil"
 	"net/http"
 	"net/http/httptest"
+	"net/url"
 	"os"
 	"path/filepath"
 	"testing"
@@ -41,3 +42,22 @@ func createTestServer(t *testing.T, statusCode int) (*httptest.Server, error) {
 	srv := httptest.NewServer(http.HandlerFunc(h))
 	return srv, nil
 }
+
+// createQueryTestServer is like createTestServer, but stores the query of the
+// last request it receives in `query`.
+func createQueryTestServer(t *testing.T, statusCode int,
+	query *url.Values) (*httptest.Server, error) {
+	response, err := readTestData(t)
+	if err != nil {
+		return nil, err
+	}
+
+	h := func(w http.ResponseWriter, r *http.Request) {
+		*query = r.URL.Query()
+		w.WriteHeader(statusCode)
+		w.Write(response)
+	}
+
+	srv := httptest.NewServer(http.HandlerFunc(h))
+	return srv, nil
+}
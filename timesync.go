@@ -0,0 +1,94 @@
+package binance
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/luno/jettison/errors"
+)
+
+// DefaultTimeSyncInterval is how often a Client with time synchronization
+// enabled re-measures its offset from the server clock.
+const DefaultTimeSyncInterval = 10 * time.Minute
+
+// timeSync tracks the offset between the local clock and the server clock, so
+// that signed requests can be timestamped in server time.
+type timeSync struct {
+	interval time.Duration
+	now      func() time.Time
+
+	// syncMu is held while a measurement is in flight, so that concurrent
+	// requests wait for a single measurement rather than each making one.
+	syncMu sync.Mutex
+
+	mu       sync.RWMutex
+	offset   time.Duration
+	lastSync time.Time
+	stale    bool
+}
+
+func newTimeSync(interval time.Duration, now func() time.Time) *timeSync {
+	return &timeSync{
+		interval: interval,
+		now:      now,
+		stale:    true,
+	}
+}
+
+// Offset returns the last measured offset of the server clock from the local
+// clock.
+func (s *timeSync) Offset() time.Duration {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return s.offset
+}
+
+// Invalidate marks the offset as stale, so that it is re-measured before the
+// next signed request.
+func (s *timeSync) Invalidate() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.stale = true
+}
+
+func (s *timeSync) needsSync() bool {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return s.stale || s.now().Sub(s.lastSync) >= s.interval
+}
+
+// Sync re-measures the offset if it is stale or older than the sync interval.
+func (s *timeSync) Sync(ctx context.Context,
+	serverTime func(context.Context) (time.Time, error)) error {
+	if !s.needsSync() {
+		return nil
+	}
+
+	s.syncMu.Lock()
+	defer s.syncMu.Unlock()
+
+	// Another request may have synced while we were waiting.
+	if !s.needsSync() {
+		return nil
+	}
+
+	sent := s.now()
+	server, err := serverTime(ctx)
+	if err != nil {
+		return errors.Wrap(err, "failed to sync server time")
+	}
+	received := s.now()
+
+	// Assume that the server read its clock halfway through the round trip.
+	rtt := received.Sub(sent)
+	offset := server.Sub(sent.Add(rtt / 2))
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.offset = offset
+	s.lastSync = received
+	s.stale = false
+	return nil
+}
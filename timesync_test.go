@@ -0,0 +1,172 @@
+package binance
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+// fakeClock is a local clock which only moves when told to.
+type fakeClock struct {
+	mu  sync.Mutex
+	now time.Time
+}
+
+func (c *fakeClock) Now() time.Time {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.now
+}
+
+func (c *fakeClock) Advance(d time.Duration) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.now = c.now.Add(d)
+}
+
+// timeServer runs ahead of a fakeClock by `skew`, and takes `rtt` to answer
+// each ServerTime request. It records the query of each signed request.
+type timeServer struct {
+	clock *fakeClock
+	rtt   time.Duration
+	skew  time.Duration
+
+	mu          sync.Mutex
+	timeCalls   int
+	queries     []map[string][]string
+	rejectNextN int
+}
+
+func (s *timeServer) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if r.URL.Path == "/api/v3/time" {
+		s.timeCalls++
+		s.clock.Advance(s.rtt / 2)
+		serverTime := s.clock.Now().Add(s.skew).UnixNano() / 1e6
+		s.clock.Advance(s.rtt / 2)
+		fmt.Fprintf(w, `{"serverTime":%d}`, serverTime)
+		return
+	}
+
+	s.queries = append(s.queries, r.URL.Query())
+
+	if s.rejectNextN > 0 {
+		s.rejectNextN--
+		w.WriteHeader(http.StatusBadRequest)
+		fmt.Fprintf(w, `{"code":-1021,"msg":"Timestamp outside recvWindow."}`)
+		return
+	}
+
+	w.Write([]byte("{}"))
+}
+
+func (s *timeServer) lastQuery() map[string][]string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.queries[len(s.queries)-1]
+}
+
+func (s *timeServer) calls() int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.timeCalls
+}
+
+func timestampOf(t *testing.T, query map[string][]string) int64 {
+	ts, err := strconv.ParseInt(query["timestamp"][0], 10, 64)
+	require.NoError(t, err)
+	return ts
+}
+
+func TestTimeSync(t *testing.T) {
+	clock := &fakeClock{now: time.Unix(1600000000, 0)}
+	ts := &timeServer{clock: clock, rtt: 200 * time.Millisecond,
+		skew: 5 * time.Second}
+	srv := httptest.NewServer(ts)
+	defer srv.Close()
+
+	c := NewClient(WithBaseURL(srv.URL+"/api/v3"), WithClock(clock.Now),
+		WithTimeSync(time.Minute))
+	ctx := context.Background()
+
+	// The offset is measured before the first signed request.
+	_, err := c.AccountInfo(ctx)
+	require.NoError(t, err)
+	require.Equal(t, 1, ts.calls())
+
+	expected := clock.Now().Add(ts.skew).UnixNano() / 1e6
+	require.Equal(t, expected, timestampOf(t, ts.lastQuery()))
+
+	// The offset is reused until the interval passes.
+	_, err = c.AccountInfo(ctx)
+	require.NoError(t, err)
+	require.Equal(t, 1, ts.calls())
+
+	clock.Advance(time.Minute)
+	_, err = c.AccountInfo(ctx)
+	require.NoError(t, err)
+	require.Equal(t, 2, ts.calls())
+
+	// A rejected timestamp causes the offset to be measured again.
+	ts.mu.Lock()
+	ts.skew = -3 * time.Second
+	ts.rejectNextN = 1
+	ts.mu.Unlock()
+
+	_, err = c.AccountInfo(ctx)
+	require.True(t, IsError(err, ErrInvalidTimestamp))
+	require.Equal(t, 2, ts.calls())
+
+	_, err = c.AccountInfo(ctx)
+	require.NoError(t, err)
+	require.Equal(t, 3, ts.calls())
+
+	expected = clock.Now().Add(ts.skew).UnixNano() / 1e6
+	require.Equal(t, expected, timestampOf(t, ts.lastQuery()))
+}
+
+func TestTimeSync_Disabled(t *testing.T) {
+	clock := &fakeClock{now: time.Unix(1600000000, 0)}
+	ts := &timeServer{clock: clock, skew: 5 * time.Second}
+	srv := httptest.NewServer(ts)
+	defer srv.Close()
+
+	c := NewClient(WithBaseURL(srv.URL+"/api/v3"), WithClock(clock.Now))
+
+	_, err := c.AccountInfo(context.Background())
+	require.NoError(t, err)
+	require.Equal(t, 0, ts.calls())
+	require.Equal(t, clock.Now().UnixNano()/1e6,
+		timestampOf(t, ts.lastQuery()))
+}
+
+func TestRecvWindow(t *testing.T) {
+	ts := &timeServer{clock: &fakeClock{}}
+	srv := httptest.NewServer(ts)
+	defer srv.Close()
+
+	c := NewClient(WithBaseURL(srv.URL+"/api/v3"),
+		WithRecvWindow(10*time.Second))
+	ctx := context.Background()
+
+	_, err := c.AccountInfo(ctx)
+	require.NoError(t, err)
+	require.Equal(t, []string{"10000"}, ts.lastQuery()["recvWindow"])
+
+	// A window set on the request is left alone.
+	err = c.NewOrderTest(ctx, &NewOrderRequest{
+		Symbol:        "BTCUSDT",
+		ReceiveWindow: 2000,
+	})
+	require.NoError(t, err)
+	require.Empty(t, ts.lastQuery()["recvWindow"])
+}
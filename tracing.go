@@ -0,0 +1,97 @@
+package binance
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+
+	"github.com/luno/jettison/errors"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// tracerName is the name of the instrumentation library that spans are
+// recorded by.
+const tracerName = "github.com/nickcorin/binance"
+
+// Attribute keys set on the span of each request.
+const (
+	attrAttempt       = attribute.Key("binance.attempt")
+	attrEndpoint      = attribute.Key("binance.endpoint")
+	attrErrorCode     = attribute.Key("binance.error_code")
+	attrMethod        = attribute.Key("http.request.method")
+	attrPath          = attribute.Key("url.path")
+	attrRetryAttempts = attribute.Key("binance.retry_attempts")
+	attrRetryWait     = attribute.Key("binance.retry_wait_ms")
+	attrSecurityLevel = attribute.Key("binance.security_level")
+	attrStatusCode    = attribute.Key("http.response.status_code")
+)
+
+// Prefixes of the attribute keys which record the usage reported by the
+// exchange. The interval is appended, e.g. binance.used_weight.1m.
+const (
+	attrOrderCountPrefix = "binance.order_count."
+	attrUsedWeightPrefix = "binance.used_weight."
+)
+
+// startSpan starts the span of a request for `path`, which may include a
+// query string, as a child of any span in `ctx`.
+func (c *client) startSpan(ctx context.Context, method, path string,
+	u *url.URL) (context.Context, trace.Span) {
+	endpoint := stripQueryParams(path)
+
+	return c.tracer.Start(ctx, fmt.Sprintf("binance %s %s", method, endpoint),
+		trace.WithSpanKind(trace.SpanKindClient),
+		trace.WithAttributes(
+			attrEndpoint.String(endpoint),
+			attrMethod.String(method),
+			attrPath.String(u.Path),
+			attrSecurityLevel.String(getSecurityLevel(u, method).String()),
+		))
+}
+
+// endSpan ends the span of a request which was sent `attempts` times. `res`
+// is the last response received, or nil if there wasn't one.
+func endSpan(span trace.Span, attempts int, res *http.Response, err error) {
+	span.SetAttributes(attrRetryAttempts.Int(attempts - 1))
+
+	if res != nil {
+		span.SetAttributes(attrStatusCode.Int(res.StatusCode))
+
+		for key, values := range res.Header {
+			var prefix string
+			switch {
+			case strings.HasPrefix(key, headerUsedWeight):
+				prefix = attrUsedWeightPrefix
+			case strings.HasPrefix(key, headerOrderCount):
+				prefix = attrOrderCountPrefix
+			default:
+				continue
+			}
+
+			used, err := strconv.ParseInt(values[0], 10, 64)
+			if err != nil {
+				continue
+			}
+
+			interval := strings.ToLower(key[strings.LastIndex(key, "-")+1:])
+			span.SetAttributes(attribute.Int64(prefix+interval, used))
+		}
+	}
+
+	if err != nil {
+		var apiErr Error
+		if errors.As(err, &apiErr) {
+			span.SetAttributes(attrErrorCode.Int(int(apiErr.Code)))
+		}
+
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+	}
+
+	span.End()
+}
@@ -0,0 +1,114 @@
+package binance
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/propagation"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+	"go.opentelemetry.io/otel/trace"
+)
+
+func spanAttributes(span sdktrace.ReadOnlySpan) map[attribute.Key]attribute.Value {
+	attrs := make(map[attribute.Key]attribute.Value)
+	for _, kv := range span.Attributes() {
+		attrs[kv.Key] = kv.Value
+	}
+	return attrs
+}
+
+func TestTracing(t *testing.T) {
+	defer otel.SetTextMapPropagator(otel.GetTextMapPropagator())
+	otel.SetTextMapPropagator(propagation.TraceContext{})
+
+	calls := 0
+	var traceParents []string
+	h := func(w http.ResponseWriter, r *http.Request) {
+		calls++
+		traceParents = append(traceParents, r.Header.Get("Traceparent"))
+		w.Header().Set("X-MBX-USED-WEIGHT-1M", "12")
+
+		switch {
+		case r.URL.Path == "/api/v3/depth" && calls == 1:
+			w.WriteHeader(http.StatusBadGateway)
+		case r.URL.Path == "/api/v3/depth":
+			w.Write([]byte(`{"lastUpdateId":1,"bids":[],"asks":[]}`))
+		default:
+			w.WriteHeader(http.StatusBadRequest)
+			w.Write([]byte(`{"code":-2013,"msg":"Order does not exist."}`))
+		}
+	}
+
+	srv := httptest.NewServer(http.HandlerFunc(h))
+	defer srv.Close()
+
+	recorder := tracetest.NewSpanRecorder()
+	provider := sdktrace.NewTracerProvider(
+		sdktrace.WithSpanProcessor(recorder))
+
+	c := NewClient(
+		WithBaseURL(srv.URL+"/api/v3"),
+		WithMetrics(nil),
+		WithRetryPolicy(RetryPolicy{MaxAttempts: 2, MinWait: time.Millisecond}),
+		WithSecretKey("secret"),
+		WithTracerProvider(provider),
+	)
+
+	ctx, parent := provider.Tracer("test").Start(context.Background(),
+		"parent")
+
+	_, err := c.OrderBook(ctx, &OrderBookRequest{Limit: 5, Symbol: "BTCUSDT"})
+	require.NoError(t, err)
+
+	_, err = c.QueryOrder(ctx, &QueryOrderRequest{OrderID: 1,
+		Symbol: "BTCUSDT"})
+	require.True(t, IsError(err, ErrNoSuchOrder))
+	parent.End()
+
+	spans := recorder.Ended()
+	require.Len(t, spans, 3)
+
+	depth := spans[0]
+	require.Equal(t, "binance GET /depth", depth.Name())
+	require.Equal(t, trace.SpanKindClient, depth.SpanKind())
+	require.Equal(t, parent.SpanContext().SpanID(), depth.Parent().SpanID())
+	require.Len(t, depth.Events(), 1)
+
+	attrs := spanAttributes(depth)
+	require.Equal(t, "/depth", attrs[attrEndpoint].AsString())
+	require.Equal(t, http.MethodGet, attrs[attrMethod].AsString())
+	require.Equal(t, "NONE", attrs[attrSecurityLevel].AsString())
+	require.Equal(t, int64(http.StatusOK), attrs[attrStatusCode].AsInt64())
+	require.Equal(t, int64(1), attrs[attrRetryAttempts].AsInt64())
+	require.Equal(t, int64(12),
+		attrs[attribute.Key(attrUsedWeightPrefix+"1m")].AsInt64())
+	require.Equal(t, codes.Unset, depth.Status().Code)
+
+	// The span's context is propagated with every attempt.
+	require.Len(t, traceParents, 3)
+	for i, span := range []sdktrace.ReadOnlySpan{depth, depth, spans[1]} {
+		require.Contains(t, traceParents[i],
+			span.SpanContext().SpanID().String())
+	}
+
+	// Query parameters, including the signature, aren't recorded.
+	query := spans[1]
+	require.Equal(t, "binance GET /order", query.Name())
+	require.Equal(t, codes.Error, query.Status().Code)
+
+	attrs = spanAttributes(query)
+	require.Equal(t, "USER_DATA", attrs[attrSecurityLevel].AsString())
+	require.Equal(t, int64(ErrNoSuchOrder), attrs[attrErrorCode].AsInt64())
+	require.Equal(t, int64(0), attrs[attrRetryAttempts].AsInt64())
+	for _, kv := range query.Attributes() {
+		require.NotContains(t, kv.Value.Emit(), "signature")
+	}
+}
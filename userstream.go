@@ -0,0 +1,194 @@
+package binance
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/url"
+	"sync"
+	"time"
+
+	"github.com/luno/jettison/errors"
+)
+
+// DefaultKeepAliveInterval is how often a ListenKeyKeeper renews its listen
+// key. Listen keys expire after 60 minutes without a keepalive.
+const DefaultKeepAliveInterval = 30 * time.Minute
+
+// closeTimeout bounds how long a ListenKeyKeeper waits to close its listen
+// key once its context has ended.
+const closeTimeout = 10 * time.Second
+
+// StartUserDataStream starts a new user data stream and returns its listen
+// key. The stream stays open for 60 minutes unless kept alive. If the
+// account already has an active listen key, that key is returned and its
+// validity is extended.
+func (c *client) StartUserDataStream(ctx context.Context) (string, error) {
+	res, err := c.post(ctx, "/userDataStream", nil)
+	if err != nil {
+		return "", err
+	}
+
+	var stream struct {
+		ListenKey string `json:"listenKey"`
+	}
+	if err = json.Unmarshal(res, &stream); err != nil {
+		return "", errors.Wrap(err, "failed to parse listen key")
+	}
+
+	return stream.ListenKey, nil
+}
+
+// KeepAliveUserDataStream extends the validity of a listen key by 60
+// minutes.
+func (c *client) KeepAliveUserDataStream(ctx context.Context,
+	listenKey string) error {
+	params := make(url.Values)
+	params.Set("listenKey", listenKey)
+
+	_, err := c.put(ctx, fmt.Sprintf("/userDataStream?%s", params.Encode()),
+		nil)
+	if err != nil {
+		return err
+	}
+
+	return nil
+}
+
+// CloseUserDataStream closes a user data stream.
+func (c *client) CloseUserDataStream(ctx context.Context,
+	listenKey string) error {
+	params := make(url.Values)
+	params.Set("listenKey", listenKey)
+
+	_, err := c.delete(ctx, fmt.Sprintf("/userDataStream?%s",
+		params.Encode()), nil)
+	if err != nil {
+		return err
+	}
+
+	return nil
+}
+
+// ListenKeyKeeper holds a user data stream listen key and renews it in the
+// background until its context ends, at which point the key is closed.
+type ListenKeyKeeper struct {
+	client   Client
+	done     chan struct{}
+	errs     chan error
+	interval time.Duration
+
+	mu        sync.RWMutex
+	listenKey string
+}
+
+// ListenKeyKeeperOption is a func-to-ListenKeyKeeper adapter.
+type ListenKeyKeeperOption func(*ListenKeyKeeper)
+
+// WithKeepAliveInterval returns a ListenKeyKeeperOption to set how often the
+// listen key is renewed. Defaults to DefaultKeepAliveInterval.
+func WithKeepAliveInterval(d time.Duration) ListenKeyKeeperOption {
+	return func(k *ListenKeyKeeper) {
+		if d > 0 {
+			k.interval = d
+		}
+	}
+}
+
+// NewListenKeyKeeper starts a user data stream and keeps its listen key alive
+// until `ctx` ends. Failed renewals are reported on Errors.
+func NewListenKeyKeeper(ctx context.Context, c Client,
+	opts ...ListenKeyKeeperOption) (*ListenKeyKeeper, error) {
+	listenKey, err := c.StartUserDataStream(ctx)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to start user data stream")
+	}
+
+	k := ListenKeyKeeper{
+		client:    c,
+		done:      make(chan struct{}),
+		errs:      make(chan error, 1),
+		interval:  DefaultKeepAliveInterval,
+		listenKey: listenKey,
+	}
+
+	for _, o := range opts {
+		o(&k)
+	}
+
+	go k.run(ctx)
+
+	return &k, nil
+}
+
+// ListenKey returns the current listen key.
+func (k *ListenKeyKeeper) ListenKey() string {
+	k.mu.RLock()
+	defer k.mu.RUnlock()
+	return k.listenKey
+}
+
+// Errors returns a channel on which failed renewals are reported. Errors are
+// dropped if the channel is not being read. The channel is closed once the
+// keeper has stopped.
+func (k *ListenKeyKeeper) Errors() <-chan error {
+	return k.errs
+}
+
+// Done returns a channel which is closed once the keeper has stopped and the
+// listen key has been closed.
+func (k *ListenKeyKeeper) Done() <-chan struct{} {
+	return k.done
+}
+
+// Renew replaces the listen key with a newly started one. This is needed when
+// the current key has expired or been invalidated.
+func (k *ListenKeyKeeper) Renew(ctx context.Context) (string, error) {
+	listenKey, err := k.client.StartUserDataStream(ctx)
+	if err != nil {
+		return "", errors.Wrap(err, "failed to start user data stream")
+	}
+
+	k.mu.Lock()
+	k.listenKey = listenKey
+	k.mu.Unlock()
+
+	return listenKey, nil
+}
+
+func (k *ListenKeyKeeper) run(ctx context.Context) {
+	defer close(k.done)
+	defer close(k.errs)
+
+	ticker := time.NewTicker(k.interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			closeCtx, cancel := context.WithTimeout(context.Background(),
+				closeTimeout)
+			err := k.client.CloseUserDataStream(closeCtx, k.ListenKey())
+			cancel()
+			if err != nil {
+				k.report(errors.Wrap(err, "failed to close user data stream"))
+			}
+			return
+
+		case <-ticker.C:
+			err := k.client.KeepAliveUserDataStream(ctx, k.ListenKey())
+			if err != nil && ctx.Err() == nil {
+				k.report(errors.Wrap(err,
+					"failed to keep user data stream alive"))
+			}
+		}
+	}
+}
+
+// report sends an error on the errors channel without blocking.
+func (k *ListenKeyKeeper) report(err error) {
+	select {
+	case k.errs <- err:
+	default:
+	}
+}
@@ -0,0 +1,102 @@
+package binance
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+// listenKeyServer records the method, listen key and API key of every
+// request it receives.
+type listenKeyServer struct {
+	mu      sync.Mutex
+	apiKeys []string
+	methods []string
+	keys    []string
+}
+
+func (s *listenKeyServer) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	_ = r.ParseForm()
+	s.apiKeys = append(s.apiKeys, r.Header.Get(HeaderAPIKey))
+	s.methods = append(s.methods, r.Method)
+	s.keys = append(s.keys, r.Form.Get("listenKey"))
+
+	if r.Method == http.MethodPost {
+		w.Write([]byte(`{"listenKey":"pqia91ma19a5s61cv6a81va65sdf19v8a65a1"}`))
+		return
+	}
+	w.Write([]byte(`{}`))
+}
+
+func (s *listenKeyServer) calls() ([]string, []string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return append([]string(nil), s.methods...), append([]string(nil), s.keys...)
+}
+
+func TestUserDataStream_OK(t *testing.T) {
+	var handler listenKeyServer
+	srv := httptest.NewServer(&handler)
+	defer srv.Close()
+
+	c := NewClient(WithBaseURL(srv.URL+"/api/v3"), WithAPIKey("key"))
+	ctx := context.Background()
+
+	listenKey, err := c.StartUserDataStream(ctx)
+	require.NoError(t, err)
+	require.Equal(t, "pqia91ma19a5s61cv6a81va65sdf19v8a65a1", listenKey)
+
+	require.NoError(t, c.KeepAliveUserDataStream(ctx, listenKey))
+	require.NoError(t, c.CloseUserDataStream(ctx, listenKey))
+
+	methods, keys := handler.calls()
+	require.Equal(t, []string{http.MethodPost, http.MethodPut,
+		http.MethodDelete}, methods)
+	require.Equal(t, []string{"", listenKey, listenKey}, keys)
+	require.Equal(t, []string{"key", "key", "key"}, handler.apiKeys)
+}
+
+func TestListenKeyKeeper(t *testing.T) {
+	var handler listenKeyServer
+	srv := httptest.NewServer(&handler)
+	defer srv.Close()
+
+	c := NewClient(WithBaseURL(srv.URL+"/api/v3"), WithAPIKey("key"))
+	ctx, cancel := context.WithCancel(context.Background())
+
+	k, err := NewListenKeyKeeper(ctx, c,
+		WithKeepAliveInterval(10*time.Millisecond))
+	require.NoError(t, err)
+	require.Equal(t, "pqia91ma19a5s61cv6a81va65sdf19v8a65a1", k.ListenKey())
+
+	require.Eventually(t, func() bool {
+		methods, _ := handler.calls()
+		return len(methods) >= 3
+	}, time.Second, 5*time.Millisecond)
+
+	cancel()
+	<-k.Done()
+
+	methods, _ := handler.calls()
+	require.Equal(t, http.MethodPost, methods[0])
+	require.Equal(t, http.MethodPut, methods[1])
+	require.Equal(t, http.MethodDelete, methods[len(methods)-1])
+
+	handler.mu.Lock()
+	for _, apiKey := range handler.apiKeys {
+		require.Equal(t, "key", apiKey)
+	}
+	handler.mu.Unlock()
+
+	for err := range k.Errors() {
+		require.NoError(t, err)
+	}
+}
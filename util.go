@@ -1,6 +1,9 @@
 package binance
 
-import "strings"
+import (
+	"encoding/json"
+	"strings"
+)
 
 // stripQueryParams takes in a URL path, and removes all query parameters that
 // have been added on. This is used mainly to sanitize the path for metrics in
@@ -13,3 +16,14 @@ func stripQueryParams(path string) string {
 
 	return path[0:index]
 }
+
+// encodeSymbols formats a list of symbols in the form expected by endpoints
+// that accept a `symbols` parameter, for example ["ETHBTC","LTCBTC"].
+func encodeSymbols(symbols []string) (string, error) {
+	b, err := json.Marshal(symbols)
+	if err != nil {
+		return "", err
+	}
+
+	return string(b), nil
+}
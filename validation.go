@@ -0,0 +1,334 @@
+package binance
+
+import "fmt"
+
+// Violation describes how an order breaks one of a symbol's filters, or
+// the precision of one of its assets.
+type Violation struct {
+	// Filter represents the type of filter which was broken. It is empty if
+	// a value has more decimal places than its asset's precision.
+	Filter FilterType
+
+	// Field represents the request parameter which broke the filter, such as
+	// "price" or "quantity". It is "notional" for the order's value, and
+	// "openOrders" for the number of open orders.
+	Field string
+
+	// Limit represents the bound, interval, count or precision which Value
+	// breaks.
+	Limit Decimal
+
+	// Reason represents the way in which the filter was broken.
+	Reason ViolationReason
+
+	// Value represents the offending value.
+	Value Decimal
+}
+
+// String returns a description of the violation, for example
+// "PRICE_FILTER: price 1.2345 is not a multiple of 0.01".
+func (v Violation) String() string {
+	desc := fmt.Sprintf("%s %s %s %s", v.Field, v.Value, v.Reason, v.Limit)
+	if v.Filter == "" {
+		return desc
+	}
+	return fmt.Sprintf("%s: %s", v.Filter, desc)
+}
+
+// ViolationReason describes the way in which a filter was broken.
+type ViolationReason string
+
+// Enumerated types for ViolationReason.
+const (
+	ViolationAboveMaximum  ViolationReason = "is above the maximum of"
+	ViolationBelowMinimum  ViolationReason = "is below the minimum of"
+	ViolationNotMultipleOf ViolationReason = "is not a multiple of"
+	ViolationTooPrecise    ViolationReason = "has more decimal places than"
+)
+
+// MarketState contains the market and account data which some filters
+// depend on.
+type MarketState struct {
+	// AvgPrice represents the symbol's current average price, as returned by
+	// AveragePrice. The percent price filters, and the notional filters for
+	// MARKET orders, are skipped when it is zero.
+	AvgPrice Decimal
+
+	// OpenAlgoOrders represents the number of STOP_LOSS, STOP_LOSS_LIMIT,
+	// TAKE_PROFIT and TAKE_PROFIT_LIMIT orders the account has open on the
+	// symbol.
+	OpenAlgoOrders int
+
+	// OpenIcebergOrders represents the number of iceberg orders the account
+	// has open on the symbol.
+	OpenIcebergOrders int
+
+	// OpenOrders represents the number of orders the account has open on the
+	// symbol.
+	OpenOrders int
+}
+
+// ValidateOrder checks `req` against the symbol's filters and the precision
+// of its assets, and returns every violation. An order which passes won't be
+// rejected for a filter failure or ErrBadPrecision.
+//
+// Filters which depend on the market's price or on the account's open orders
+// are only checked if `state` is not nil.
+func (s *SymbolInfo) ValidateOrder(req *NewOrderRequest,
+	state *MarketState) []Violation {
+	var v validator
+	if state == nil {
+		state = &MarketState{}
+	} else {
+		v.checkOpenOrders(s.Filters, req, state)
+	}
+
+	v.checkPrecision(s.QuoteAssetPrecision, "price", req.Price)
+	v.checkPrecision(s.QuoteAssetPrecision, "stopPrice", req.StopPrice)
+	v.checkPrecision(s.QuoteAssetPrecision, "quoteOrderQty",
+		req.QuoteOrderQty)
+	v.checkPrecision(s.BaseAssetPrecision, "quantity", req.Qty)
+	v.checkPrecision(s.BaseAssetPrecision, "icebergQty", req.IcebergQty)
+
+	v.checkPrice(s.Filters.Price(), "price", req.Price)
+	v.checkPrice(s.Filters.Price(), "stopPrice", req.StopPrice)
+	v.checkPercentPrice(s.Filters, req, state.AvgPrice)
+
+	if lot := s.Filters.LotSize(); lot != nil {
+		v.checkLotSize(FilterTypeLotSize, lot.MinQty, lot.MaxQty,
+			lot.StepSize, "quantity", req.Qty)
+		v.checkLotSize(FilterTypeLotSize, lot.MinQty, lot.MaxQty,
+			lot.StepSize, "icebergQty", req.IcebergQty)
+	}
+
+	if lot := s.Filters.MarketLotSize(); lot != nil && isMarket(req.Type) {
+		v.checkLotSize(FilterTypeMarketLotSize, lot.MinQty, lot.MaxQty,
+			lot.StepSize, "quantity", req.Qty)
+	}
+
+	v.checkIcebergParts(s.Filters.IcebergParts(), req)
+	v.checkNotional(s.Filters, req, state.AvgPrice)
+
+	return v.violations
+}
+
+// RoundPriceDown returns `price` rounded down to the symbol's tick size.
+func (s *SymbolInfo) RoundPriceDown(price Decimal) Decimal {
+	if f := s.Filters.Price(); f != nil {
+		return price.RoundDown(f.TickSize)
+	}
+	return price
+}
+
+// RoundPriceUp returns `price` rounded up to the symbol's tick size.
+func (s *SymbolInfo) RoundPriceUp(price Decimal) Decimal {
+	if f := s.Filters.Price(); f != nil {
+		return price.RoundUp(f.TickSize)
+	}
+	return price
+}
+
+// RoundQtyDown returns `qty` rounded down to the symbol's step size.
+func (s *SymbolInfo) RoundQtyDown(qty Decimal) Decimal {
+	if f := s.Filters.LotSize(); f != nil {
+		return qty.RoundDown(f.StepSize)
+	}
+	return qty
+}
+
+// RoundQtyUp returns `qty` rounded up to the symbol's step size.
+func (s *SymbolInfo) RoundQtyUp(qty Decimal) Decimal {
+	if f := s.Filters.LotSize(); f != nil {
+		return qty.RoundUp(f.StepSize)
+	}
+	return qty
+}
+
+// isMarket returns whether orders of type `t` are filled at the market price.
+func isMarket(t OrderType) bool {
+	return t == OrderTypeMarket
+}
+
+// isAlgo returns whether orders of type `t` count towards the
+// MAX_NUM_ALGO_ORDERS filter.
+func isAlgo(t OrderType) bool {
+	switch t {
+	case OrderTypeStopLoss, OrderTypeStopLossLimit, OrderTypeTakeProfit,
+		OrderTypeTakeProfitLimit:
+		return true
+	}
+	return false
+}
+
+// validator collects the violations found while validating an order.
+type validator struct {
+	violations []Violation
+}
+
+func (v *validator) add(filter FilterType, field string, value, limit Decimal,
+	reason ViolationReason) {
+	v.violations = append(v.violations, Violation{
+		Filter: filter,
+		Field:  field,
+		Limit:  limit,
+		Reason: reason,
+		Value:  value,
+	})
+}
+
+// checkRange adds a violation if `value` is outside of [min, max]. Zero
+// bounds are disabled.
+func (v *validator) checkRange(filter FilterType, field string, value, min,
+	max Decimal) {
+	if !min.IsZero() && value.LessThan(min) {
+		v.add(filter, field, value, min, ViolationBelowMinimum)
+	}
+	if !max.IsZero() && value.GreaterThan(max) {
+		v.add(filter, field, value, max, ViolationAboveMaximum)
+	}
+}
+
+// checkPrecision adds a violation if `value` has more significant decimal
+// places than `precision`.
+func (v *validator) checkPrecision(precision int, field string,
+	value Decimal) {
+	if value.Truncate(int32(precision)).Equal(value) {
+		return
+	}
+	v.add("", field, value, NewDecimalFromInt(int64(precision)),
+		ViolationTooPrecise)
+}
+
+func (v *validator) checkPrice(f *PriceFilter, field string, price Decimal) {
+	if f == nil || price.IsZero() {
+		return
+	}
+
+	v.checkRange(FilterTypePrice, field, price, f.MinPrice, f.MaxPrice)
+	if !price.IsMultipleOf(f.TickSize) {
+		v.add(FilterTypePrice, field, price, f.TickSize,
+			ViolationNotMultipleOf)
+	}
+}
+
+func (v *validator) checkPercentPrice(filters Filters, req *NewOrderRequest,
+	avgPrice Decimal) {
+	if avgPrice.IsZero() || req.Price.IsZero() {
+		return
+	}
+
+	if f := filters.PercentPrice(); f != nil {
+		v.checkRange(FilterTypePercentPrice, "price", req.Price,
+			avgPrice.Mul(f.MultiplierDown), avgPrice.Mul(f.MultiplierUp))
+	}
+
+	if f := filters.PercentPriceBySide(); f != nil {
+		down, up := f.BidMultiplierDown, f.BidMultiplierUp
+		if req.Side == Sell {
+			down, up = f.AskMultiplierDown, f.AskMultiplierUp
+		}
+		v.checkRange(FilterTypePercentPriceBySide, "price", req.Price,
+			avgPrice.Mul(down), avgPrice.Mul(up))
+	}
+}
+
+func (v *validator) checkLotSize(filter FilterType, min, max, step Decimal,
+	field string, qty Decimal) {
+	if qty.IsZero() {
+		return
+	}
+
+	v.checkRange(filter, field, qty, min, max)
+	if !qty.IsMultipleOf(step) {
+		v.add(filter, field, qty, step, ViolationNotMultipleOf)
+	}
+}
+
+func (v *validator) checkIcebergParts(f *IcebergPartsFilter,
+	req *NewOrderRequest) {
+	if f == nil || req.IcebergQty.Sign() <= 0 {
+		return
+	}
+
+	parts := req.Qty.Div(req.IcebergQty, 0)
+	if parts.Mul(req.IcebergQty).LessThan(req.Qty) {
+		parts = parts.Add(NewDecimalFromInt(1))
+	}
+
+	limit := NewDecimalFromInt(int64(f.Limit))
+	if parts.GreaterThan(limit) {
+		v.add(FilterTypeIcebergParts, "icebergQty", parts, limit,
+			ViolationAboveMaximum)
+	}
+}
+
+// notional returns the value of `req` in the quote asset, and whether it
+// could be determined. MARKET orders are valued at `avgPrice`.
+func notional(req *NewOrderRequest, avgPrice Decimal) (Decimal, bool) {
+	if !req.QuoteOrderQty.IsZero() {
+		return req.QuoteOrderQty, true
+	}
+
+	price := req.Price
+	if isMarket(req.Type) {
+		price = avgPrice
+	} else if price.IsZero() {
+		price = req.StopPrice
+	}
+
+	if price.IsZero() || req.Qty.IsZero() {
+		return Decimal{}, false
+	}
+	return price.Mul(req.Qty), true
+}
+
+func (v *validator) checkNotional(filters Filters, req *NewOrderRequest,
+	avgPrice Decimal) {
+	value, ok := notional(req, avgPrice)
+	if !ok {
+		return
+	}
+
+	market := isMarket(req.Type)
+
+	if f := filters.MinNotional(); f != nil && (!market || f.ApplyToMarket) {
+		v.checkRange(FilterTypeMinNotional, "notional", value, f.MinNotional,
+			Decimal{})
+	}
+
+	if f := filters.Notional(); f != nil {
+		var min, max Decimal
+		if !market || f.ApplyMinToMarket {
+			min = f.MinNotional
+		}
+		if !market || f.ApplyMaxToMarket {
+			max = f.MaxNotional
+		}
+		v.checkRange(FilterTypeNotional, "notional", value, min, max)
+	}
+}
+
+func (v *validator) checkOpenOrders(filters Filters, req *NewOrderRequest,
+	state *MarketState) {
+	checkCount := func(filter FilterType, open, max int) {
+		if max > 0 && open >= max {
+			v.add(filter, "openOrders", NewDecimalFromInt(int64(open+1)),
+				NewDecimalFromInt(int64(max)), ViolationAboveMaximum)
+		}
+	}
+
+	if f := filters.MaxNumOrders(); f != nil {
+		checkCount(FilterTypeMaxNumOrders, state.OpenOrders, f.MaxNumOrders)
+	}
+
+	if f := filters.MaxNumAlgoOrders(); f != nil && isAlgo(req.Type) {
+		checkCount(FilterTypeMaxNumAlgoOrders, state.OpenAlgoOrders,
+			f.MaxNumAlgoOrders)
+	}
+
+	if f := filters.MaxNumIcebergOrders(); f != nil &&
+		!req.IcebergQty.IsZero() {
+		checkCount(FilterTypeMaxNumIcebergOrders, state.OpenIcebergOrders,
+			f.MaxNumIcebergOrders)
+	}
+}
@@ -0,0 +1,171 @@
+package binance
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func testSymbolInfo() *SymbolInfo {
+	d := MustParseDecimal
+	return &SymbolInfo{
+		BaseAssetPrecision:  8,
+		QuoteAssetPrecision: 8,
+		Symbol:              "ETHBTC",
+		Filters: Filters{
+			&PriceFilter{
+				MinPrice: d("0.00000100"),
+				MaxPrice: d("100000.00000000"),
+				TickSize: d("0.00000100"),
+			},
+			&PercentPriceFilter{
+				MultiplierDown: d("0.2"),
+				MultiplierUp:   d("5"),
+			},
+			&LotSizeFilter{
+				MinQty:   d("0.00100000"),
+				MaxQty:   d("100000.00000000"),
+				StepSize: d("0.00100000"),
+			},
+			&MarketLotSizeFilter{
+				MaxQty: d("2000.00000000"),
+			},
+			&MinNotionalFilter{
+				ApplyToMarket: true,
+				MinNotional:   d("0.00010000"),
+			},
+			&NotionalFilter{
+				ApplyMinToMarket: true,
+				MaxNotional:      d("100.00000000"),
+				MinNotional:      d("0.00010000"),
+			},
+			&IcebergPartsFilter{Limit: 10},
+			&MaxNumOrdersFilter{MaxNumOrders: 200},
+			&MaxNumAlgoOrdersFilter{MaxNumAlgoOrders: 5},
+		},
+	}
+}
+
+func TestSymbolInfo_ValidateOrder(t *testing.T) {
+	d := MustParseDecimal
+	state := &MarketState{AvgPrice: d("0.05")}
+
+	tests := []struct {
+		req        NewOrderRequest
+		state      *MarketState
+		violations []string
+	}{
+		{
+			req: NewOrderRequest{Type: OrderTypeLimit, Side: Buy,
+				Price: d("0.051234"), Qty: d("1.5")},
+			state: state,
+		},
+		{
+			req: NewOrderRequest{Type: OrderTypeLimit, Side: Buy,
+				Price: d("0.0512345"), Qty: d("1.5005")},
+			state: state,
+			violations: []string{
+				"PRICE_FILTER: price 0.0512345 is not a multiple of 0.00000100",
+				"LOT_SIZE: quantity 1.5005 is not a multiple of 0.00100000",
+			},
+		},
+		{
+			req: NewOrderRequest{Type: OrderTypeLimit, Side: Sell,
+				Price: d("0.3"), Qty: d("0.0001")},
+			state: state,
+			violations: []string{
+				"PERCENT_PRICE: price 0.3 is above the maximum of 0.25",
+				"LOT_SIZE: quantity 0.0001 is below the minimum of 0.00100000",
+				"LOT_SIZE: quantity 0.0001 is not a multiple of 0.00100000",
+				"MIN_NOTIONAL: notional 0.00003 is below the minimum of 0.00010000",
+				"NOTIONAL: notional 0.00003 is below the minimum of 0.00010000",
+			},
+		},
+		{
+			// Market dependent filters are skipped without a state.
+			req: NewOrderRequest{Type: OrderTypeLimit, Side: Sell,
+				Price: d("0.3"), Qty: d("1")},
+		},
+		{
+			req: NewOrderRequest{Type: OrderTypeMarket, Side: Buy,
+				Qty: d("2500")},
+			state: state,
+			violations: []string{
+				"MARKET_LOT_SIZE: quantity 2500 is above the maximum of 2000.00000000",
+			},
+		},
+		{
+			req: NewOrderRequest{Type: OrderTypeLimit, Side: Buy,
+				Price: d("0.05"), Qty: d("2500")},
+			state: state,
+			violations: []string{
+				"NOTIONAL: notional 125.00 is above the maximum of 100.00000000",
+			},
+		},
+		{
+			req: NewOrderRequest{Type: OrderTypeLimit, Side: Buy,
+				Price: d("0.05"), Qty: d("10"), IcebergQty: d("0.9")},
+			state: state,
+			violations: []string{
+				"ICEBERG_PARTS: icebergQty 12 is above the maximum of 10",
+			},
+		},
+		{
+			req: NewOrderRequest{Type: OrderTypeStopLossLimit, Side: Buy,
+				Price: d("0.05"), StopPrice: d("0.05"), Qty: d("1")},
+			state: &MarketState{
+				AvgPrice:       d("0.05"),
+				OpenAlgoOrders: 5,
+				OpenOrders:     200,
+			},
+			violations: []string{
+				"MAX_NUM_ORDERS: openOrders 201 is above the maximum of 200",
+				"MAX_NUM_ALGO_ORDERS: openOrders 6 is above the maximum of 5",
+			},
+		},
+		{
+			req: NewOrderRequest{Type: OrderTypeMarket, Side: Buy,
+				QuoteOrderQty: d("1.123456789")},
+			state: state,
+			violations: []string{
+				"quoteOrderQty 1.123456789 has more decimal places than 8",
+			},
+		},
+		{
+			// Trailing zeros don't count towards the precision.
+			req: NewOrderRequest{Type: OrderTypeLimit, Side: Buy,
+				Price: d("0.0500000000"), Qty: d("1.5000000000")},
+			state: state,
+		},
+	}
+
+	symbol := testSymbolInfo()
+	for i, test := range tests {
+		t.Run(fmt.Sprintf("%d", i), func(t *testing.T) {
+			var violations []string
+			for _, v := range symbol.ValidateOrder(&test.req, test.state) {
+				violations = append(violations, v.String())
+			}
+			require.Equal(t, test.violations, violations)
+		})
+	}
+}
+
+func TestSymbolInfo_Round(t *testing.T) {
+	symbol := testSymbolInfo()
+	price := MustParseDecimal("0.0512345")
+	qty := MustParseDecimal("1.5005")
+
+	require.Equal(t, "0.05123400", symbol.RoundPriceDown(price).String())
+	require.Equal(t, "0.05123500", symbol.RoundPriceUp(price).String())
+	require.Equal(t, "1.50000000", symbol.RoundQtyDown(qty).String())
+	require.Equal(t, "1.50100000", symbol.RoundQtyUp(qty).String())
+
+	// Rounded values pass validation.
+	require.Empty(t, symbol.ValidateOrder(&NewOrderRequest{
+		Type:  OrderTypeLimit,
+		Price: symbol.RoundPriceDown(price),
+		Qty:   symbol.RoundQtyDown(qty),
+	}, nil))
+}